@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/joho/godotenv"
@@ -9,7 +12,11 @@ import (
 	"github.com/rafli2460/culinary-blog-api/internal/handlers"
 	"github.com/rafli2460/culinary-blog-api/internal/repository"
 	"github.com/rafli2460/culinary-blog-api/internal/routes"
+	"github.com/rafli2460/culinary-blog-api/internal/scheduler"
 	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
+	"github.com/rafli2460/culinary-blog-api/internal/throttle"
+	"github.com/rafli2460/culinary-blog-api/internal/upload"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -25,18 +32,115 @@ func main() {
 	db := config.InitDB()
 	defer db.Close()
 
+	blob := config.InitStorage()
+	uploadLimits := config.UploadLimits()
+	validator := upload.NewValidator(uploadLimits)
+
 	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo)
+	sessionRepo := repository.NewSessionRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	roleService := service.NewRoleService(roleRepo, time.Minute)
+	sessionService := service.NewSessionService(sessionRepo, userRepo, roleService, config.AccessTokenTTL(), config.RefreshTokenTTL())
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	twoFactorService := service.NewTwoFactorService(twoFactorRepo, userRepo, roleService, config.TwoFactorIssuer())
+	emailTokenRepo := repository.NewEmailTokenRepository(db)
+	emailService := service.NewEmailService(userRepo, emailTokenRepo, sessionService, config.InitMailer(), config.AppURL(), config.PasswordResetURL())
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	userPolicy, ipPolicy := config.LoginPolicies()
+	loginGuard := throttle.NewGuard(config.InitLoginThrottleStore(db), userPolicy, ipPolicy)
+	userService := service.NewUserService(userRepo, loginAttemptRepo, sessionService, roleService, twoFactorService, emailService, loginGuard, config.LoginAttemptRetention())
+	identityRepo := repository.NewIdentityRepository(db)
+	identityService := service.NewIdentityService(identityRepo, userRepo, userService, emailService, config.InitOIDCProviders())
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
 
 	postRepo := repository.NewPostRepository(db)
-	postService := service.NewPostService(postRepo)
+	recipeRepo := repository.NewRecipeRepository(db)
+	taxonomyRepo := repository.NewTaxonomyRepository(db)
+	ratingRepo := repository.NewRatingRepository(db)
+	revisionRepo := repository.NewRevisionRepository(db)
+	postImageRepo := repository.NewPostImageRepository(db)
+	postService := service.NewPostService(postRepo, recipeRepo, taxonomyRepo, ratingRepo, revisionRepo, postImageRepo, blob, validator)
+	revisionService := service.NewRevisionService(revisionRepo, postRepo, blob)
+	recipeService := service.NewRecipeService(recipeRepo, postRepo, blob)
+	galleryService := service.NewGalleryService(postImageRepo, postRepo, recipeRepo, revisionRepo, blob)
+	taxonomyService := service.NewTaxonomyService(taxonomyRepo)
+	uploadGCService := service.NewUploadGCService(postRepo, blob, config.UploadGCGrace(), config.UploadGCDelete())
+
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), os.Args[1], os.Args[2:], newCommands(postService, recipeService, uploadGCService)); err != nil {
+			log.Fatal().Err(err).Str("command", os.Args[1]).Msg("command failed")
+		}
+		return
+	}
+
+	commentRepo := repository.NewCommentRepository(db)
+	commentService := service.NewCommentService(commentRepo, postRepo)
+
+	trashService := service.NewTrashService(postRepo, userRepo, blob, trashRetention())
 
-	authHandler := handlers.NewAuthHandler(userService)
-	adminHandler := handlers.NewAdminHandler(userService)
+	profileRepo := repository.NewProfileRepository(db)
+	profileService := service.NewProfileService(profileRepo, blob, validator)
+
+	accountService := service.NewAccountService(userRepo, postRepo, commentRepo, ratingRepo, profileService, postService, sessionService, blob)
+
+	searchRepo := repository.NewSearchRepository(db)
+	searchService := service.NewSearchService(searchRepo, blob)
+
+	authHandler := handlers.NewAuthHandler(userService, sessionService, twoFactorService, emailService)
+	adminHandler := handlers.NewAdminHandler(userService, roleService)
 	postHandler := handlers.NewPostService(postService)
-	app := fiber.New()
+	recipeHandler := handlers.NewRecipeHandler(recipeService)
+	taxonomyHandler := handlers.NewTaxonomyHandler(taxonomyService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	commentHandler := handlers.NewCommentHandler(commentService)
+	revisionHandler := handlers.NewRevisionHandler(revisionService)
+	trashHandler := handlers.NewTrashHandler(trashService)
+	galleryHandler := handlers.NewGalleryHandler(galleryService)
+	profileHandler := handlers.NewProfileHandler(profileService, postService)
+	accountHandler := handlers.NewAccountHandler(accountService, emailService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	identityHandler := handlers.NewIdentityHandler(identityService)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	publisher := scheduler.New("publish-scheduled-posts", postService.PublishDuePosts, scheduler.SystemClock{}, time.Minute)
+	publisher.Start(ctx)
+
+	trashPurger := scheduler.New("purge-trash", trashService.PurgeExpired, scheduler.SystemClock{}, time.Hour)
+	trashPurger.Start(ctx)
 
-	routes.InitRoutes(app, authHandler, adminHandler, postHandler)
+	sessionPurger := scheduler.New("purge-expired-sessions", sessionService.PurgeExpired, scheduler.SystemClock{}, time.Hour)
+	sessionPurger.Start(ctx)
+
+	emailTokenPurger := scheduler.New("purge-expired-email-tokens", emailService.PurgeExpired, scheduler.SystemClock{}, time.Hour)
+	emailTokenPurger.Start(ctx)
+
+	loginPruner := scheduler.New("prune-login-records", userService.PruneLoginRecords, scheduler.SystemClock{}, time.Hour)
+	loginPruner.Start(ctx)
+
+	if _, ok := blob.(storage.Lister); ok {
+		uploadCollector := scheduler.New("collect-orphaned-uploads", uploadGCService.CollectOrphans, scheduler.SystemClock{}, 24*time.Hour)
+		uploadCollector.Start(ctx)
+	} else {
+		log.Warn().Msg("storage backend cannot list files, orphaned uploads will not be collected")
+	}
+
+	proxies, proxyHeader := config.TrustedProxies()
+	app := fiber.New(fiber.Config{
+		// A post form carries a cover and up to 10 gallery images, plus room
+		// for the other form fields.
+		BodyLimit: 11*int(uploadLimits.MaxBytes) + 1024*1024,
+		// c.IP() keys the per-IP login throttle, so it only believes the
+		// proxy header on requests from the configured proxies.
+		TrustProxy:         len(proxies) > 0,
+		TrustProxyConfig:   fiber.TrustProxyConfig{Proxies: proxies},
+		ProxyHeader:        proxyHeader,
+		EnableIPValidation: true,
+	})
+
+	routes.InitRoutes(app, blob, apiKeyService, roleService, authHandler, adminHandler, postHandler, recipeHandler, taxonomyHandler, searchHandler, commentHandler, revisionHandler, trashHandler, galleryHandler, profileHandler, accountHandler, apiKeyHandler, twoFactorHandler, identityHandler)
 
 	appPort := os.Getenv("APP_PORT")
 	if appPort == "" {
@@ -52,3 +156,18 @@ func main() {
 		log.Fatal().Err(err).Msg("server failed to run")
 	}
 }
+
+// trashRetention reads how long trashed posts and users are kept before they
+// are purged from TRASH_RETENTION_DAYS, defaulting to 30 days.
+func trashRetention() time.Duration {
+	days := 30
+	if value := os.Getenv("TRASH_RETENTION_DAYS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			log.Warn().Str("TRASH_RETENTION_DAYS", value).Msg("invalid trash retention, using 30 days")
+		} else {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
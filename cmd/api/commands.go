@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// command is a maintenance task run as `api <name> [args]` instead of
+// starting the server.
+type command struct {
+	description string
+	run         func(ctx context.Context, args []string) error
+}
+
+func newCommands(postService service.PostService, recipeService service.RecipeService, uploadGCService service.UploadGCService) map[string]command {
+	return map[string]command{
+		"backfill-slugs": {
+			description: "Generate slugs for posts that do not have one yet",
+			run: func(ctx context.Context, args []string) error {
+				updated, err := postService.BackfillSlugs(ctx)
+				if err != nil {
+					return err
+				}
+				log.Info().Int("updated", updated).Msg("Post slugs backfilled")
+				return nil
+			},
+		},
+		"backfill-ingredients": {
+			description: "Link recipe ingredients to canonical ingredients where they are not linked yet",
+			run: func(ctx context.Context, args []string) error {
+				linked, err := recipeService.BackfillIngredients(ctx)
+				if err != nil {
+					return err
+				}
+				log.Info().Int("linked", linked).Msg("Recipe ingredients backfilled")
+				return nil
+			},
+		},
+		"gc-uploads": {
+			description: "Report uploaded files no post uses and posts whose files are missing; --delete removes the unused files",
+			run: func(ctx context.Context, args []string) error {
+				remove := slices.Contains(args, "--delete")
+				report, err := uploadGCService.Reconcile(ctx, time.Now(), remove)
+				if err != nil {
+					return err
+				}
+				log.Info().
+					Int("scanned", report.Scanned).
+					Int("orphans", len(report.Orphans)).
+					Int("deleted", report.Deleted).
+					Int("recent", report.Recent).
+					Int("missing", len(report.Missing)).
+					Msg("Uploads reconciled")
+				return nil
+			},
+		},
+	}
+}
+
+// runCommand runs the named command with its arguments, or prints the
+// available ones when the name is unknown.
+func runCommand(ctx context.Context, name string, args []string, commands map[string]command) error {
+	cmd, ok := commands[name]
+	if !ok {
+		names := make([]string, 0, len(commands))
+		for commandName := range commands {
+			names = append(names, commandName)
+		}
+		sort.Strings(names)
+
+		fmt.Fprintf(os.Stderr, "unknown command %q, available commands:\n", name)
+		for _, commandName := range names {
+			fmt.Fprintf(os.Stderr, "  %-20s %s\n", commandName, commands[commandName].description)
+		}
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	return cmd.run(ctx, args)
+}
@@ -11,6 +11,8 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/rs/zerolog v1.34.0
 	golang.org/x/crypto v0.47.0
+	golang.org/x/image v0.25.0
+	golang.org/x/text v0.33.0
 )
 
 require (
@@ -28,5 +30,4 @@ require (
 	github.com/valyala/fasthttp v1.69.0 // indirect
 	golang.org/x/net v0.49.0 // indirect
 	golang.org/x/sys v0.40.0 // indirect
-	golang.org/x/text v0.33.0 // indirect
 )
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/internal/throttle"
+	"github.com/rs/zerolog/log"
+)
+
+// AccessTokenTTL reads how long an access token is valid from
+// ACCESS_TOKEN_TTL_MINUTES, defaulting to 15 minutes.
+func AccessTokenTTL() time.Duration {
+	return time.Duration(envOrDefault("ACCESS_TOKEN_TTL_MINUTES", 15)) * time.Minute
+}
+
+// RefreshTokenTTL reads from REFRESH_TOKEN_TTL_DAYS how long a session may go
+// unused before the user has to log in again, defaulting to 30 days.
+func RefreshTokenTTL() time.Duration {
+	return time.Duration(envOrDefault("REFRESH_TOKEN_TTL_DAYS", 30)) * 24 * time.Hour
+}
+
+// TwoFactorIssuer reads the name authenticator apps show next to the codes
+// for this site from TOTP_ISSUER, defaulting to "Culinary Blog".
+func TwoFactorIssuer() string {
+	if issuer := strings.TrimSpace(os.Getenv("TOTP_ISSUER")); issuer != "" {
+		return issuer
+	}
+	return "Culinary Blog"
+}
+
+// envOrDefault reads a positive integer from an environment variable, falling
+// back to def when it is unset or invalid.
+func envOrDefault(name string, def int) int {
+	if value := positiveEnv(name); value > 0 {
+		return value
+	}
+	return def
+}
+
+// LoginPolicies reads the login throttling policies for usernames and client
+// IPs. A username is locked out after LOGIN_MAX_FAILURES failures (default 5)
+// and an IP after LOGIN_MAX_FAILURES_PER_IP (default 20). The first lockout
+// lasts LOGIN_LOCKOUT_MINUTES (default 1) and doubles with every further
+// failure up to LOGIN_MAX_LOCKOUT_MINUTES (default 60). Failures are forgotten
+// after LOGIN_FAILURE_WINDOW_MINUTES without one (default 60).
+func LoginPolicies() (user throttle.Policy, ip throttle.Policy) {
+	base := throttle.Policy{
+		Window:      time.Duration(envOrDefault("LOGIN_FAILURE_WINDOW_MINUTES", 60)) * time.Minute,
+		BaseLockout: time.Duration(envOrDefault("LOGIN_LOCKOUT_MINUTES", 1)) * time.Minute,
+		MaxLockout:  time.Duration(envOrDefault("LOGIN_MAX_LOCKOUT_MINUTES", 60)) * time.Minute,
+	}
+
+	user, ip = base, base
+	user.MaxFailures = envOrDefault("LOGIN_MAX_FAILURES", 5)
+	ip.MaxFailures = envOrDefault("LOGIN_MAX_FAILURES_PER_IP", 20)
+	return user, ip
+}
+
+// LoginAttemptRetention reads how long login attempts are kept for review
+// from LOGIN_ATTEMPT_RETENTION_DAYS, defaulting to 90 days.
+func LoginAttemptRetention() time.Duration {
+	return time.Duration(envOrDefault("LOGIN_ATTEMPT_RETENTION_DAYS", 90)) * 24 * time.Hour
+}
+
+// InitLoginThrottleStore creates the store selected by LOGIN_THROTTLE_STORE:
+// "mysql" (the default), which is shared by every instance of the API, or
+// "memory", which suits a single instance.
+func InitLoginThrottleStore(db *Database) throttle.Store {
+	driver := strings.ToLower(os.Getenv("LOGIN_THROTTLE_STORE"))
+
+	switch driver {
+	case "", "mysql":
+		return throttle.NewMySQL(db.Write)
+	case "memory":
+		log.Info().Msg("Keeping login throttling in memory")
+		return throttle.NewMemory()
+	default:
+		log.Fatal().Str("store", driver).Msg("Unknown LOGIN_THROTTLE_STORE, must be 'mysql' or 'memory'")
+		return nil
+	}
+}
@@ -13,6 +13,11 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
+// dsnParams make every connection work in UTC: the driver reads and writes
+// times in UTC (loc) and the session time zone makes NOW() return UTC too
+// (time_zone='+00:00'), whatever the server's own time zone is.
+const dsnParams = "parseTime=true&loc=UTC&time_zone=%27%2B00%3A00%27"
+
 type Database struct {
 	Read  *sqlx.DB
 	Write *sqlx.DB
@@ -28,8 +33,8 @@ func InitDB() *Database {
 	writeHost := os.Getenv("DB_WRITE_HOST")
 	readHost := os.Getenv("DB_READ_HOST")
 
-	writeDSN := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", user, pass, writeHost, dbPort, dbName)
-	readDSN := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", user, pass, readHost, dbPort, dbName)
+	writeDSN := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?%s", user, pass, writeHost, dbPort, dbName, dsnParams)
+	readDSN := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?%s", user, pass, readHost, dbPort, dbName, dsnParams)
 
 	writeDB, err := sqlx.Connect(dbDialect, writeDSN)
 	if err != nil {
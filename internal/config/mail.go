@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rafli2460/culinary-blog-api/internal/mailer"
+	"github.com/rs/zerolog/log"
+)
+
+// AppURL reads the address the API is reached at from APP_URL, defaulting to
+// http://localhost and the APP_PORT.
+func AppURL() string {
+	baseURL := os.Getenv("APP_URL")
+	if baseURL == "" {
+		port := os.Getenv("APP_PORT")
+		if port == "" {
+			port = "3000"
+		}
+		baseURL = "http://localhost:" + port
+	}
+	return strings.TrimSuffix(baseURL, "/")
+}
+
+// PasswordResetURL reads the page password reset emails link to from
+// PASSWORD_RESET_URL, defaulting to /reset-password on the APP_URL. The page
+// receives the token as a token query parameter.
+func PasswordResetURL() string {
+	if resetURL := strings.TrimSpace(os.Getenv("PASSWORD_RESET_URL")); resetURL != "" {
+		return resetURL
+	}
+	return AppURL() + "/reset-password"
+}
+
+// InitMailer creates the mailer selected by MAIL_DRIVER: "log" (the default),
+// which writes emails to the log, "file", which writes them to MAIL_DIR, or
+// "smtp", which sends them through SMTP_HOST. Emails come from MAIL_FROM.
+func InitMailer() mailer.Mailer {
+	driver := strings.ToLower(os.Getenv("MAIL_DRIVER"))
+
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Culinary Blog <no-reply@localhost>"
+	}
+
+	switch driver {
+	case "", "log":
+		log.Warn().Msg("Emails are written to the log, set MAIL_DRIVER=smtp to send them")
+		return mailer.NewLog()
+
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "./mail"
+		}
+		m, err := mailer.NewFile(dir, from)
+		if err != nil {
+			log.Fatal().Err(err).Str("dir", dir).Msg("Failed to create mail directory")
+		}
+		log.Warn().Str("dir", dir).Msg("Emails are written to files, set MAIL_DRIVER=smtp to send them")
+		return m
+
+	case "smtp":
+		m, err := mailer.NewSMTP(mailer.SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     positiveEnv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to configure SMTP")
+		}
+		log.Info().Str("host", os.Getenv("SMTP_HOST")).Msg("Sending emails through SMTP")
+		return m
+
+	default:
+		log.Fatal().Str("driver", driver).Msg("Unknown MAIL_DRIVER, must be 'log', 'file' or 'smtp'")
+		return nil
+	}
+}
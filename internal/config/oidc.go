@@ -0,0 +1,57 @@
+package config
+
+import (
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/internal/oidc"
+	"github.com/rs/zerolog/log"
+)
+
+var providerName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// InitOIDCProviders creates the OpenID Connect providers users can log in
+// with. OIDC_PROVIDERS lists their names, e.g. "google,keycloak", and each is
+// configured with OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET and, optionally, OIDC_<NAME>_SCOPES. The
+// callback to register with a provider is
+// <APP_URL>/v1/auth/oidc/<name>/callback.
+func InitOIDCProviders() []*oidc.Provider {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var providers []*oidc.Provider
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !providerName.MatchString(name) {
+			log.Fatal().Str("provider", name).Msg("Invalid OIDC provider name, use lowercase letters, numbers, '-' and '_'")
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		cfg := oidc.Config{
+			Name:         name,
+			Issuer:       strings.TrimSpace(os.Getenv(prefix + "ISSUER")),
+			ClientID:     strings.TrimSpace(os.Getenv(prefix + "CLIENT_ID")),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  AppURL() + "/v1/auth/oidc/" + name + "/callback",
+			Scopes:       strings.Fields(strings.ReplaceAll(os.Getenv(prefix+"SCOPES"), ",", " ")),
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" {
+			log.Fatal().Str("provider", name).Msg("OIDC provider needs " + prefix + "ISSUER and " + prefix + "CLIENT_ID")
+		}
+
+		log.Info().Str("provider", name).Str("issuer", cfg.Issuer).Msg("OpenID Connect login enabled")
+		providers = append(providers, oidc.New(cfg, client))
+	}
+	return providers
+}
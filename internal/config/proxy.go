@@ -0,0 +1,36 @@
+package config
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TrustedProxies reads the reverse proxies the API runs behind from
+// TRUSTED_PROXIES, a comma-separated list of IPs and CIDR ranges, and the
+// header they put the client IP in from PROXY_HEADER (default
+// X-Forwarded-For). Only requests from those proxies may set the client IP
+// that sessions record and failed logins are counted against; with none, it is
+// the address of the connection. The header is read from the left, so the
+// proxies must overwrite it rather than append to what the client sent.
+func TrustedProxies() (proxies []string, header string) {
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			log.Fatal().Str("proxy", proxy).Msg("Invalid TRUSTED_PROXIES entry, use IPs or CIDR ranges")
+		}
+		proxies = append(proxies, proxy)
+	}
+
+	header = http.CanonicalHeaderKey(strings.TrimSpace(os.Getenv("PROXY_HEADER")))
+	if header == "" {
+		header = "X-Forwarded-For"
+	}
+	return proxies, header
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// UploadDir is where the local storage driver keeps uploaded files.
+const UploadDir = "./uploads"
+
+// InitStorage creates the blob store selected by STORAGE_DRIVER: "local"
+// (the default) or "s3".
+func InitStorage() storage.Blob {
+	driver := strings.ToLower(os.Getenv("STORAGE_DRIVER"))
+
+	switch driver {
+	case "", "local":
+		log.Info().Str("dir", UploadDir).Msg("Using local storage for uploads")
+		return storage.NewLocal(UploadDir, AppURL()+"/uploads")
+
+	case "s3":
+		blob, err := storage.NewS3(storage.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Can't configure S3 storage")
+		}
+		log.Info().Str("bucket", os.Getenv("S3_BUCKET")).Msg("Using S3 storage for uploads")
+		return blob
+
+	default:
+		log.Fatal().Str("driver", driver).Msg("Unknown STORAGE_DRIVER, must be 'local' or 's3'")
+		return nil
+	}
+}
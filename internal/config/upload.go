@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/internal/upload"
+	"github.com/rs/zerolog/log"
+)
+
+// UploadLimits reads the upload limits from UPLOAD_MAX_SIZE_MB,
+// UPLOAD_MAX_DIMENSION and UPLOAD_MAX_MEGAPIXELS. Unset or invalid values
+// keep the defaults.
+func UploadLimits() upload.Limits {
+	limits := upload.DefaultLimits
+
+	if mb := positiveEnv("UPLOAD_MAX_SIZE_MB"); mb > 0 {
+		limits.MaxBytes = int64(mb) * 1024 * 1024
+	}
+	if dimension := positiveEnv("UPLOAD_MAX_DIMENSION"); dimension > 0 {
+		limits.MaxDimension = dimension
+	}
+	if megapixels := positiveEnv("UPLOAD_MAX_MEGAPIXELS"); megapixels > 0 {
+		limits.MaxPixels = megapixels * 1_000_000
+	}
+
+	return limits
+}
+
+// UploadGCGrace reads from UPLOAD_GC_GRACE_HOURS how old an unreferenced
+// upload must be before it counts as orphaned, defaulting to 24 hours.
+func UploadGCGrace() time.Duration {
+	hours := 24
+	if value := positiveEnv("UPLOAD_GC_GRACE_HOURS"); value > 0 {
+		hours = value
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// UploadGCDelete reports whether the scheduled upload check deletes orphaned
+// files instead of only reporting them, as set by UPLOAD_GC_DELETE=true.
+func UploadGCDelete() bool {
+	return os.Getenv("UPLOAD_GC_DELETE") == "true"
+}
+
+// positiveEnv reads a positive integer from an environment variable. It
+// returns 0 when the variable is unset or not a positive integer.
+func positiveEnv(name string) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		log.Warn().Str(name, value).Msg("invalid value, using the default")
+		return 0
+	}
+	return n
+}
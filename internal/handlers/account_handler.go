@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type AccountHandler struct {
+	accountService service.AccountService
+	emailService   service.EmailService
+}
+
+func NewAccountHandler(accountService service.AccountService, emailService service.EmailService) *AccountHandler {
+	return &AccountHandler{accountService: accountService, emailService: emailService}
+}
+
+// accountErrorStatus picks the HTTP status for an error from the account
+// endpoints.
+func accountErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "action denied"), strings.Contains(err.Error(), "password is incorrect"):
+		return fiber.StatusForbidden
+	case err.Error() == "user not found":
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+func (h *AccountHandler) GetAccount(c fiber.Ctx) error {
+	currentUserID, _ := getCurrentUser(c)
+
+	account, err := h.accountService.GetAccount(c.Context(), currentUserID)
+	if err != nil {
+		return response.Error(c, accountErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Account successfully retrieved", account, nil)
+}
+
+func (h *AccountHandler) ChangePassword(c fiber.Ctx) error {
+	var req models.ChangePasswordRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	if err := h.accountService.ChangePassword(c.Context(), currentUserID, getCurrentSession(c), req); err != nil {
+		return response.Error(c, accountErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("user_id", currentUserID).Msg("Password successfully changed")
+	return response.Success(c, fiber.StatusOK, "Password successfully changed", nil, nil)
+}
+
+// ChangeEmail replaces the current user's email and sends a verification link
+// to the new one.
+func (h *AccountHandler) ChangeEmail(c fiber.Ctx) error {
+	var req models.ChangeEmailRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	if err := h.emailService.ChangeEmail(c.Context(), currentUserID, req); err != nil {
+		return response.Error(c, accountErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("user_id", currentUserID).Msg("Email successfully changed")
+	return response.Success(c, fiber.StatusOK, "Email successfully changed, please check it for a verification link", nil, nil)
+}
+
+// ResendVerification sends a new link to verify the current user's email.
+func (h *AccountHandler) ResendVerification(c fiber.Ctx) error {
+	currentUserID, _ := getCurrentUser(c)
+
+	if err := h.emailService.ResendVerification(c.Context(), currentUserID); err != nil {
+		return response.Error(c, accountErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Verification email sent", nil, nil)
+}
+
+func (h *AccountHandler) DeleteAccount(c fiber.Ctx) error {
+	var req models.DeleteAccountRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	if err := h.accountService.DeleteAccount(c.Context(), currentUserID, req); err != nil {
+		return response.Error(c, accountErrorStatus(err), err.Error())
+	}
+
+	clearAuthCookies(c)
+
+	log.Info().Int("user_id", currentUserID).Msg("User deleted their account")
+	return response.Success(c, fiber.StatusOK, "Account successfully deleted", nil, nil)
+}
+
+// ExportAccount downloads everything stored about the current user, as a JSON
+// file or, with format=zip, as a ZIP archive that also holds the image files.
+func (h *AccountHandler) ExportAccount(c fiber.Ctx) error {
+	format := c.Query("format", "json")
+	if format != "json" && format != "zip" {
+		return response.Error(c, fiber.StatusBadRequest, "format must be 'json' or 'zip'")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	export, err := h.accountService.Export(c.Context(), currentUserID)
+	if err != nil {
+		return response.Error(c, accountErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("user_id", currentUserID).Str("format", format).Msg("Account data exported")
+
+	if format == "json" {
+		c.Attachment("account-export.json")
+		return c.JSON(export)
+	}
+
+	c.Attachment("account-export.zip")
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		// The archive is written after the handler returns, so it cannot use
+		// the request's context.
+		if err := h.accountService.WriteExportZip(context.Background(), export, w); err != nil {
+			log.Error().Err(err).Int("user_id", currentUserID).Msg("Failed to write account export")
+			return
+		}
+		if err := w.Flush(); err != nil {
+			log.Warn().Err(err).Int("user_id", currentUserID).Msg("Failed to send account export")
+		}
+	})
+}
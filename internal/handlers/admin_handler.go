@@ -12,10 +12,11 @@ import (
 
 type AdminHandler struct {
 	userService service.UserService
+	roleService service.RoleService
 }
 
-func NewAdminHandler(userService service.UserService) *AdminHandler {
-	return &AdminHandler{userService: userService}
+func NewAdminHandler(userService service.UserService, roleService service.RoleService) *AdminHandler {
+	return &AdminHandler{userService: userService, roleService: roleService}
 }
 
 func getAdminID(c fiber.Ctx) int {
@@ -50,6 +51,37 @@ func (h *AdminHandler) GetStats(c fiber.Ctx) error {
 	return response.Success(c, fiber.StatusOK, "Statistics successfully retrieved", stats, nil)
 }
 
+// GetRoles lists the roles users can be given, with their permissions.
+func (h *AdminHandler) GetRoles(c fiber.Ctx) error {
+	roles, err := h.roleService.GetRoles(c.Context())
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "failed to retrieve roles")
+	}
+
+	return response.Success(c, fiber.StatusOK, "Roles successfully retrieved", roles, nil)
+}
+
+// SetRoleTwoFactor makes two-factor authentication required or optional for
+// the users of a role.
+func (h *AdminHandler) SetRoleTwoFactor(c fiber.Ctx) error {
+	var req models.TwoFactorPolicyRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	role := c.Params("name")
+	if err := h.roleService.SetTwoFactorRequired(c.Context(), role, req.Required); err != nil {
+		if err.Error() == "role not found" {
+			return response.Error(c, fiber.StatusNotFound, err.Error())
+		}
+		return response.Error(c, fiber.StatusInternalServerError, "failed to update role")
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Str("role", role).Bool("required", req.Required).Msg("Role two-factor requirement changed")
+
+	return response.Success(c, fiber.StatusOK, "Role successfully updated", nil, nil)
+}
+
 func (h *AdminHandler) UpdateRole(c fiber.Ctx) error {
 	targetID, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
@@ -90,3 +122,50 @@ func (h *AdminHandler) DeleteUser(c fiber.Ctx) error {
 
 	return response.Success(c, fiber.StatusOK, "User successfully deleted", nil, nil)
 }
+
+// UnlockUser lifts a lockout caused by failed logins on a user's account.
+func (h *AdminHandler) UnlockUser(c fiber.Ctx) error {
+	targetID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := h.userService.UnlockUser(c.Context(), targetID); err != nil {
+		if err.Error() == "user not found" {
+			return response.Error(c, fiber.StatusNotFound, err.Error())
+		}
+		return response.Error(c, fiber.StatusInternalServerError, "failed to unlock user")
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Int("target_id", targetID).Msg("User unlocked")
+
+	return response.Success(c, fiber.StatusOK, "User successfully unlocked", nil, nil)
+}
+
+// GetLoginAttempts lists recent login attempts, optionally for one username
+// or IP address.
+func (h *AdminHandler) GetLoginAttempts(c fiber.Ctx) error {
+	page := c.Query("page", "1")
+	limit := c.Query("limit", "10")
+
+	convPage, err := strconv.Atoi(page)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Page parameter must be a number")
+	}
+	convLimit, err := strconv.Atoi(limit)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Limit parameter must be a number")
+	}
+
+	attempts, total, err := h.userService.GetLoginAttempts(c.Context(), c.Query("username"), c.Query("ip"), convPage, convLimit)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "failed to retrieve login attempts")
+	}
+
+	return response.Success(c, fiber.StatusOK, "Login attempts successfully retrieved", attempts, fiber.Map{
+		"page":  page,
+		"limit": limit,
+		"count": len(attempts),
+		"total": total,
+	})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+func (h *APIKeyHandler) GetAPIKeys(c fiber.Ctx) error {
+	currentUserID, _ := getCurrentUser(c)
+
+	keys, err := h.apiKeyService.GetAPIKeys(c.Context(), currentUserID)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Failed to retrieve API keys")
+	}
+
+	return response.Success(c, fiber.StatusOK, "API keys successfully retrieved", keys, nil)
+}
+
+// CreateAPIKey creates a key and returns it. This is the only time the key
+// itself is shown.
+func (h *APIKeyHandler) CreateAPIKey(c fiber.Ctx) error {
+	var req models.APIKeyRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	key, err := h.apiKeyService.CreateAPIKey(c.Context(), currentUserID, req)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("user_id", currentUserID).Int("api_key_id", key.ID).Strs("scopes", key.Scopes).Msg("API key created")
+	return response.Success(c, fiber.StatusCreated, "API key successfully created, copy it now as it will not be shown again", key, nil)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c fiber.Ctx) error {
+	keyID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "API key ID must be a number")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Context(), currentUserID, keyID); err != nil {
+		if err.Error() == "API key not found" {
+			return response.Error(c, fiber.StatusNotFound, err.Error())
+		}
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("user_id", currentUserID).Int("api_key_id", keyID).Msg("API key revoked")
+	return response.Success(c, fiber.StatusOK, "API key successfully revoked", nil, nil)
+}
@@ -1,21 +1,98 @@
 package handlers
 
 import (
+	"errors"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/rafli2460/culinary-blog-api/internal/models"
 	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/internal/throttle"
 	"github.com/rafli2460/culinary-blog-api/pkg/response"
 	"github.com/rs/zerolog/log"
 )
 
 type AuthHandler struct {
-	userService service.UserService
+	userService      service.UserService
+	sessionService   service.SessionService
+	twoFactorService service.TwoFactorService
+	emailService     service.EmailService
 }
 
-func NewAuthHandler(userService service.UserService) *AuthHandler {
-	return &AuthHandler{userService: userService}
+func NewAuthHandler(userService service.UserService, sessionService service.SessionService, twoFactorService service.TwoFactorService, emailService service.EmailService) *AuthHandler {
+	return &AuthHandler{userService: userService, sessionService: sessionService, twoFactorService: twoFactorService, emailService: emailService}
+}
+
+// refreshCookiePath limits the refresh token cookie to the auth endpoints, so
+// it is not sent along with every request.
+const refreshCookiePath = "/v1/auth"
+
+// getCurrentSession returns the ID of the session the access token belongs
+// to, or 0 for a token issued before sessions existed.
+func getCurrentSession(c fiber.Ctx) int {
+	if idFloat, ok := c.Locals("session_id").(float64); ok {
+		return int(idFloat)
+	}
+	return 0
+}
+
+func clientInfo(c fiber.Ctx) models.ClientInfo {
+	return models.ClientInfo{UserAgent: c.Get(fiber.HeaderUserAgent), IPAddress: c.IP()}
+}
+
+// setAuthCookies hands a token pair to a browser client.
+func setAuthCookies(c fiber.Ctx, pair *models.TokenPair) {
+	c.Cookie(&fiber.Cookie{
+		Name:     "jwt_token",
+		Value:    pair.AccessToken,
+		Expires:  pair.AccessExpiresAt,
+		HTTPOnly: true,
+		Secure:   false,
+		SameSite: "Lax",
+	})
+	c.Cookie(&fiber.Cookie{
+		Name:     "refresh_token",
+		Value:    pair.RefreshToken,
+		Path:     refreshCookiePath,
+		Expires:  pair.RefreshExpiresAt,
+		HTTPOnly: true,
+		Secure:   false,
+		SameSite: "Lax",
+	})
+}
+
+func clearAuthCookies(c fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     "jwt_token",
+		Value:    "",
+		Expires:  time.Now().Add(-time.Hour),
+		HTTPOnly: true,
+	})
+	c.Cookie(&fiber.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		Path:     refreshCookiePath,
+		Expires:  time.Now().Add(-time.Hour),
+		HTTPOnly: true,
+	})
+}
+
+// refreshToken reads the refresh token from its cookie or, for clients that
+// do not keep cookies, from the JSON body.
+func refreshToken(c fiber.Ctx) string {
+	if token := c.Cookies("refresh_token"); token != "" {
+		return token
+	}
+
+	var req models.RefreshRequest
+	if len(c.Body()) > 0 {
+		if err := c.Bind().Body(&req); err != nil {
+			return ""
+		}
+	}
+	return req.RefreshToken
 }
 
 func (h *AuthHandler) Register(c fiber.Ctx) error {
@@ -26,11 +103,68 @@ func (h *AuthHandler) Register(c fiber.Ctx) error {
 		return response.Error(c, fiber.StatusBadRequest, "Format is invalid")
 	}
 
+	if err := h.userService.Register(c.Context(), req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
 	log.Info().Str("Username", req.Username).Msg("User registration success")
 
-	return response.Success(c, fiber.StatusCreated, "Registration successful, please login", nil, nil)
+	return response.Success(c, fiber.StatusCreated, "Registration successful, please check your email to verify it and login", nil, nil)
+}
+
+// VerifyEmail verifies an email with the token from a verification email. The
+// link in the email opens it with a GET and the token in the query; apps can
+// also POST it in the body.
+func (h *AuthHandler) VerifyEmail(c fiber.Ctx) error {
+	var req models.VerifyEmailRequest
+	req.Token = c.Query("token")
+	if req.Token == "" && len(c.Body()) > 0 {
+		if err := c.Bind().Body(&req); err != nil {
+			return response.Error(c, fiber.StatusBadRequest, "Format is invalid")
+		}
+	}
+
+	if err := h.emailService.VerifyEmail(c.Context(), req.Token); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Email successfully verified", nil, nil)
+}
+
+// ForgotPassword emails a password reset link. It answers the same whether or
+// not the email belongs to an account.
+func (h *AuthHandler) ForgotPassword(c fiber.Ctx) error {
+	var req models.ForgotPasswordRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Format is invalid")
+	}
+
+	if err := h.emailService.ForgotPassword(c.Context(), req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "If an account with a verified email matches, a password reset link has been sent to it", nil, nil)
+}
+
+// ResetPassword sets a new password with the token from a password reset
+// email.
+func (h *AuthHandler) ResetPassword(c fiber.Ctx) error {
+	var req models.ResetPasswordRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Format is invalid")
+	}
+
+	if err := h.emailService.ResetPassword(c.Context(), req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Msg("Password reset with email token")
+	return response.Success(c, fiber.StatusOK, "Password successfully reset, please login", nil, nil)
 }
 
+// Login checks the credentials. Users with two-factor authentication get a
+// challenge token instead of the cookies, to be redeemed with a code at
+// CompleteLogin.
 func (h *AuthHandler) Login(c fiber.Ctx) error {
 	var req models.LoginRequest
 
@@ -38,33 +172,152 @@ func (h *AuthHandler) Login(c fiber.Ctx) error {
 		return response.Error(c, fiber.StatusUnauthorized, "invalid format")
 	}
 
-	token, err := h.userService.Login(c.Context(), req)
+	result, err := h.userService.Login(c.Context(), req, clientInfo(c))
+	if err != nil {
+		return loginError(c, err, req.Username)
+	}
+
+	if result.Tokens != nil {
+		log.Info().Str("username", req.Username).Msg("User Login Success")
+	}
+	return loginResponse(c, result)
+}
+
+// loginResponse answers a login whose first step succeeded: with the cookies,
+// or with the challenge for the second step.
+func loginResponse(c fiber.Ctx, result *models.LoginResult) error {
+	if challenge := result.Challenge; challenge != nil {
+		message := "Enter the code from your authenticator app"
+		if challenge.SetupRequired {
+			message = "Your role requires two-factor authentication, please set it up"
+		}
+		return response.Success(c, fiber.StatusOK, message, challenge, nil)
+	}
+	setAuthCookies(c, result.Tokens)
+
+	return response.Success(c, fiber.StatusOK, "Login successful", result.Tokens, nil)
+}
+
+// CompleteLogin redeems a login challenge with a code from the authenticator
+// or a recovery code, and sets the cookies.
+func (h *AuthHandler) CompleteLogin(c fiber.Ctx) error {
+	var req models.TwoFactorLoginRequest
+
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusUnauthorized, "invalid format")
+	}
+
+	result, err := h.userService.CompleteLogin(c.Context(), req, clientInfo(c))
+	if err != nil {
+		return loginError(c, err, "")
+	}
+	setAuthCookies(c, result.Tokens)
+
+	log.Info().Msg("User Login Success with two-factor authentication")
+
+	message := "Login successful"
+	if result.RecoveryCodes != nil {
+		message = "Two-factor authentication enabled and login successful, store your recovery codes somewhere safe"
+	}
+	return response.Success(c, fiber.StatusOK, message, models.TwoFactorLogin{TokenPair: result.Tokens, RecoveryCodes: result.RecoveryCodes}, nil)
+}
+
+// SetupTwoFactorLogin starts enrolling a user whose role requires two-factor
+// authentication, with the challenge token from their login. They finish it
+// by sending a code to CompleteLogin.
+func (h *AuthHandler) SetupTwoFactorLogin(c fiber.Ctx) error {
+	var req models.ChallengeRequest
+
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusUnauthorized, "invalid format")
+	}
+
+	userID, setup, err := h.twoFactorService.ParseChallenge(req.ChallengeToken)
 	if err != nil {
 		return response.Error(c, fiber.StatusUnauthorized, err.Error())
 	}
-	c.Cookie(&fiber.Cookie{
-		Name:     "jwt_token",
-		Value:    token,
-		Expires:  time.Now().Add(12 * time.Hour),
-		HTTPOnly: true,
-		Secure:   false,
-		SameSite: "Lax",
-	})
+	if !setup {
+		return response.Error(c, fiber.StatusBadRequest, "two-factor authentication is already enabled")
+	}
 
-	log.Info().Str("username", req.Username).Msg("User Login Success")
+	twoFactorSetup, err := h.twoFactorService.Setup(c.Context(), userID)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
 
-	return response.Success(c, fiber.StatusOK, "Login successful", nil, nil)
+	return response.Success(c, fiber.StatusOK, "Add the secret to your authenticator app, then log in with a code", twoFactorSetup, nil)
 }
 
+// loginError answers a failed login, with a Retry-After header when the user
+// or IP is locked out.
+func loginError(c fiber.Ctx, err error, username string) error {
+	var locked *throttle.LockedError
+	if errors.As(err, &locked) {
+		seconds := int(math.Ceil(locked.RetryAfter.Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+		log.Warn().Str("username", username).Str("ip", c.IP()).Msg("Login refused, too many failed attempts")
+		return response.Error(c, fiber.StatusTooManyRequests, err.Error())
+	}
+	return response.Error(c, fiber.StatusUnauthorized, err.Error())
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token.
+func (h *AuthHandler) Refresh(c fiber.Ctx) error {
+	pair, err := h.sessionService.Refresh(c.Context(), refreshToken(c))
+	if err != nil {
+		clearAuthCookies(c)
+		return response.Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+	setAuthCookies(c, pair)
+
+	return response.Success(c, fiber.StatusOK, "Token successfully refreshed", pair, nil)
+}
+
+// Logout ends the session of the refresh token and clears the cookies.
 func (h *AuthHandler) Logout(c fiber.Ctx) error {
-	c.Cookie(&fiber.Cookie{
-		Name:     "jwt_token",
-		Value:    "",
-		Expires:  time.Now().Add(-time.Hour),
-		HTTPOnly: true,
-	})
+	if err := h.sessionService.Logout(c.Context(), refreshToken(c)); err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "Failed to end session")
+	}
+
+	clearAuthCookies(c)
 
 	log.Info().Msg("User logout success")
 
 	return response.Success(c, fiber.StatusOK, "Logout successful", nil, nil)
 }
+
+// GetSessions lists the current user's active sessions.
+func (h *AuthHandler) GetSessions(c fiber.Ctx) error {
+	currentUserID, _ := getCurrentUser(c)
+
+	sessions, err := h.sessionService.GetSessions(c.Context(), currentUserID, getCurrentSession(c))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Failed to retrieve sessions")
+	}
+
+	return response.Success(c, fiber.StatusOK, "Sessions successfully retrieved", sessions, nil)
+}
+
+// RevokeSession logs the current user out of one of their sessions.
+func (h *AuthHandler) RevokeSession(c fiber.Ctx) error {
+	sessionID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Session ID must be a number")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	if err := h.sessionService.RevokeSession(c.Context(), currentUserID, sessionID); err != nil {
+		if err.Error() == "session not found" {
+			return response.Error(c, fiber.StatusNotFound, err.Error())
+		}
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if sessionID == getCurrentSession(c) {
+		clearAuthCookies(c)
+	}
+
+	log.Info().Int("user_id", currentUserID).Int("session_id", sessionID).Msg("Session revoked")
+	return response.Success(c, fiber.StatusOK, "Session successfully revoked", nil, nil)
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type CommentHandler struct {
+	commentService service.CommentService
+}
+
+func NewCommentHandler(commentService service.CommentService) *CommentHandler {
+	return &CommentHandler{commentService: commentService}
+}
+
+// commentErrorStatus picks the HTTP status for an error from the comment
+// endpoints.
+func commentErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "access denied"):
+		return fiber.StatusForbidden
+	case err.Error() == "post not found", err.Error() == "comment not found":
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// commentParams reads the post ID and comment ID route parameters.
+func commentParams(c fiber.Ctx) (int, int, error) {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	commentID, err := strconv.Atoi(c.Params("commentId"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return postID, commentID, nil
+}
+
+func (h *CommentHandler) GetComments(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	comments, err := h.commentService.GetComments(c.Context(), postID)
+	if err != nil {
+		return response.Error(c, commentErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Comments successfully retrieved", comments, nil)
+}
+
+func (h *CommentHandler) CreateComment(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID")
+	}
+
+	var req models.CommentRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	comment, err := h.commentService.CreateComment(c.Context(), postID, currentUserID, permissions, req)
+	if err != nil {
+		return response.Error(c, commentErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("post_id", postID).Int("comment_id", comment.ID).Int("user_id", currentUserID).Msg("Comment successfully created")
+
+	message := "Comment successfully posted"
+	if comment.Status == models.CommentPending {
+		message = "Comment submitted and awaiting moderation"
+	}
+
+	return response.Success(c, fiber.StatusCreated, message, comment, nil)
+}
+
+func (h *CommentHandler) UpdateComment(c fiber.Ctx) error {
+	postID, commentID, err := commentParams(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post or comment ID")
+	}
+
+	var req models.CommentRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	comment, err := h.commentService.UpdateComment(c.Context(), postID, commentID, currentUserID, req)
+	if err != nil {
+		return response.Error(c, commentErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Comment successfully updated", comment, nil)
+}
+
+func (h *CommentHandler) DeleteComment(c fiber.Ctx) error {
+	postID, commentID, err := commentParams(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post or comment ID")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	if err := h.commentService.DeleteComment(c.Context(), postID, commentID, currentUserID, permissions); err != nil {
+		return response.Error(c, commentErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("comment_id", commentID).Int("deleted_by", currentUserID).Msg("Comment successfully deleted")
+
+	return response.Success(c, fiber.StatusOK, "Comment successfully deleted", nil, nil)
+}
+
+func (h *CommentHandler) HideComment(c fiber.Ctx) error {
+	postID, commentID, err := commentParams(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post or comment ID")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	if err := h.commentService.HideComment(c.Context(), postID, commentID, currentUserID, permissions); err != nil {
+		return response.Error(c, commentErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("comment_id", commentID).Int("hidden_by", currentUserID).Msg("Comment successfully hidden")
+
+	return response.Success(c, fiber.StatusOK, "Comment successfully hidden", nil, nil)
+}
+
+func (h *CommentHandler) GetModerationQueue(c fiber.Ctx) error {
+	page := c.Query("page", "1")
+	limit := c.Query("limit", "10")
+
+	convPage, err := strconv.Atoi(page)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Page parameter must be a number")
+	}
+	convLimit, err := strconv.Atoi(limit)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Limit parameter must be a number")
+	}
+
+	comments, total, err := h.commentService.GetModerationQueue(c.Context(), c.Query("status"), convPage, convLimit)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Comments successfully retrieved", comments, fiber.Map{
+		"page":  page,
+		"limit": limit,
+		"count": len(comments),
+		"total": total,
+	})
+}
+
+func (h *CommentHandler) ApproveComment(c fiber.Ctx) error {
+	return h.moderate(c, "approve", "Comment successfully approved")
+}
+
+func (h *CommentHandler) ModerateHideComment(c fiber.Ctx) error {
+	return h.moderate(c, "hide", "Comment successfully hidden")
+}
+
+func (h *CommentHandler) ModerateDeleteComment(c fiber.Ctx) error {
+	return h.moderate(c, "delete", "Comment successfully deleted")
+}
+
+func (h *CommentHandler) moderate(c fiber.Ctx, action string, message string) error {
+	commentID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid comment ID")
+	}
+
+	if err := h.commentService.ModerateComment(c.Context(), commentID, action); err != nil {
+		return response.Error(c, commentErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("comment_id", commentID).Str("action", action).Int("admin_id", getAdminID(c)).Msg("Comment moderated")
+
+	return response.Success(c, fiber.StatusOK, message, nil, nil)
+}
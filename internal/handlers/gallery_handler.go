@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type GalleryHandler struct {
+	galleryService service.GalleryService
+}
+
+func NewGalleryHandler(galleryService service.GalleryService) *GalleryHandler {
+	return &GalleryHandler{galleryService: galleryService}
+}
+
+// galleryErrorStatus picks the HTTP status for an error from the gallery
+// endpoints.
+func galleryErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "access denied"):
+		return fiber.StatusForbidden
+	case err.Error() == "post not found", err.Error() == "image not found":
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// galleryParams reads the post and image IDs from the route.
+func galleryParams(c fiber.Ctx) (int, int, error) {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return 0, 0, response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	imageID, err := strconv.Atoi(c.Params("imageId"))
+	if err != nil {
+		return 0, 0, response.Error(c, fiber.StatusBadRequest, "Invalid image ID format")
+	}
+
+	return postID, imageID, nil
+}
+
+func (h *GalleryHandler) GetImages(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	images, err := h.galleryService.GetImages(c.Context(), postID, currentUserID, permissions)
+	if err != nil {
+		return response.Error(c, galleryErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Images successfully retrieved", images, nil)
+}
+
+func (h *GalleryHandler) UpdateImage(c fiber.Ctx) error {
+	postID, imageID, err := galleryParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.PostImageRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	image, err := h.galleryService.UpdateImage(c.Context(), postID, imageID, currentUserID, permissions, req)
+	if err != nil {
+		return response.Error(c, galleryErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Image successfully updated", image, nil)
+}
+
+func (h *GalleryHandler) ReorderImages(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	var req models.ReorderImagesRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	images, err := h.galleryService.ReorderImages(c.Context(), postID, currentUserID, permissions, req.ImageIDs)
+	if err != nil {
+		return response.Error(c, galleryErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Images successfully reordered", images, nil)
+}
+
+func (h *GalleryHandler) DeleteImage(c fiber.Ctx) error {
+	postID, imageID, err := galleryParams(c)
+	if err != nil {
+		return err
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	if err := h.galleryService.DeleteImage(c.Context(), postID, imageID, currentUserID, permissions); err != nil {
+		return response.Error(c, galleryErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("post_id", postID).Int("image_id", imageID).Int("deleted_by", currentUserID).Msg("Post image deleted")
+	return response.Success(c, fiber.StatusOK, "Image successfully deleted", nil, nil)
+}
+
+func (h *GalleryHandler) SetCover(c fiber.Ctx) error {
+	postID, imageID, err := galleryParams(c)
+	if err != nil {
+		return err
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	if err := h.galleryService.SetCover(c.Context(), postID, imageID, currentUserID, permissions); err != nil {
+		return response.Error(c, galleryErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Cover image successfully updated", nil, nil)
+}
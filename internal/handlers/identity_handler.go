@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type IdentityHandler struct {
+	identityService service.IdentityService
+}
+
+func NewIdentityHandler(identityService service.IdentityService) *IdentityHandler {
+	return &IdentityHandler{identityService: identityService}
+}
+
+// stateCookiePath limits the login state cookie to the provider callbacks.
+const stateCookiePath = "/v1/auth/oidc"
+
+// setStateCookie keeps the state of a login with a provider in the browser
+// until the provider sends the user back. It is Lax so the redirect back
+// from the provider carries it.
+func setStateCookie(c fiber.Ctx, authorization *models.IdentityAuthorization) {
+	c.Cookie(&fiber.Cookie{
+		Name:     "oidc_state",
+		Value:    authorization.StateToken,
+		Path:     stateCookiePath,
+		Expires:  authorization.ExpiresAt,
+		HTTPOnly: true,
+		Secure:   false,
+		SameSite: "Lax",
+	})
+}
+
+func clearStateCookie(c fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     "oidc_state",
+		Value:    "",
+		Path:     stateCookiePath,
+		Expires:  time.Now().Add(-time.Hour),
+		HTTPOnly: true,
+	})
+}
+
+func identityErrorStatus(err error) int {
+	switch err.Error() {
+	case "login provider not found", "identity not found":
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// GetProviders lists the providers users can log in with.
+func (h *IdentityHandler) GetProviders(c fiber.Ctx) error {
+	return response.Success(c, fiber.StatusOK, "Login providers successfully retrieved", h.identityService.Providers(), nil)
+}
+
+// Login sends the user to the provider's login page.
+func (h *IdentityHandler) Login(c fiber.Ctx) error {
+	authorization, err := h.identityService.Authorize(c.Context(), c.Params("provider"), 0)
+	if err != nil {
+		return response.Error(c, identityErrorStatus(err), err.Error())
+	}
+	setStateCookie(c, authorization)
+
+	return c.Redirect().Status(fiber.StatusFound).To(authorization.AuthorizationURL)
+}
+
+// Callback is where the provider sends the user back to. A login answers
+// like AuthHandler.Login, with the cookies or a two-factor challenge; a link
+// returns the new identity.
+func (h *IdentityHandler) Callback(c fiber.Ctx) error {
+	stateToken := c.Cookies("oidc_state")
+	// The state is single use.
+	clearStateCookie(c)
+
+	if providerError := c.Query("error"); providerError != "" {
+		message := c.Query("error_description")
+		if message == "" {
+			message = providerError
+		}
+		return response.Error(c, fiber.StatusBadRequest, "login was not completed: "+message)
+	}
+
+	provider := c.Params("provider")
+	result, err := h.identityService.Callback(c.Context(), provider, c.Query("code"), c.Query("state"), stateToken, clientInfo(c))
+	if err != nil {
+		return response.Error(c, identityErrorStatus(err), err.Error())
+	}
+
+	if result.Linked != nil {
+		log.Info().Str("provider", provider).Msg("Login provider linked")
+		return response.Success(c, fiber.StatusCreated, "Account successfully linked", result.Linked, nil)
+	}
+
+	if result.Login.Tokens != nil {
+		log.Info().Str("provider", provider).Msg("User Login Success with login provider")
+	}
+	return loginResponse(c, result.Login)
+}
+
+// GetIdentities lists the providers linked to the current user's account.
+func (h *IdentityHandler) GetIdentities(c fiber.Ctx) error {
+	currentUserID, _ := getCurrentUser(c)
+
+	identities, err := h.identityService.GetIdentities(c.Context(), currentUserID)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Failed to retrieve linked accounts")
+	}
+
+	return response.Success(c, fiber.StatusOK, "Linked accounts successfully retrieved", identities, nil)
+}
+
+// Link starts linking a provider to the current user's account. The client
+// sends the user to the returned authorization_url, and the provider sends
+// them back to the callback, which finishes the link.
+func (h *IdentityHandler) Link(c fiber.Ctx) error {
+	currentUserID, _ := getCurrentUser(c)
+
+	authorization, err := h.identityService.Authorize(c.Context(), c.Params("provider"), currentUserID)
+	if err != nil {
+		return response.Error(c, identityErrorStatus(err), err.Error())
+	}
+	setStateCookie(c, authorization)
+
+	return response.Success(c, fiber.StatusOK, "Log in with the provider to link it", authorization, nil)
+}
+
+// Unlink removes a provider from the current user's account.
+func (h *IdentityHandler) Unlink(c fiber.Ctx) error {
+	identityID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Identity ID must be a number")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	if err := h.identityService.Unlink(c.Context(), currentUserID, identityID); err != nil {
+		return response.Error(c, identityErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("user_id", currentUserID).Int("identity_id", identityID).Msg("Login provider unlinked")
+	return response.Success(c, fiber.StatusOK, "Account successfully unlinked", nil, nil)
+}
@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"mime/multipart"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
 	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/internal/upload"
 	"github.com/rafli2460/culinary-blog-api/pkg/response"
 	"github.com/rs/zerolog/log"
 )
@@ -18,27 +23,150 @@ func NewPostService(postService service.PostService) *PostHandler {
 	return &PostHandler{postService: postService}
 }
 
-func (h *PostHandler) CreatePost(c fiber.Ctx) error {
-	userIDVal := c.Locals("user_id")
+// getCurrentUser reads the authenticated user's ID and the permissions of
+// their role that the auth middleware stored on the request.
+func getCurrentUser(c fiber.Ctx) (int, models.Permissions) {
 	var userID int
+	userIDVal := c.Locals("user_id")
 	if idFloat, ok := userIDVal.(float64); ok {
 		userID = int(idFloat)
+	} else if idInt, ok := userIDVal.(int); ok {
+		userID = idInt
+	}
+
+	permissions, _ := c.Locals("permissions").(models.Permissions)
+
+	return userID, permissions
+}
+
+// postRequestFromForm reads the post fields from a multipart or urlencoded
+// form. Categories and cuisines are lists of IDs, tags are a list of names;
+// each may be comma-separated or repeated. published_at is only used when
+// scheduling.
+func postRequestFromForm(c fiber.Ctx) (models.PostRequest, error) {
+	req := models.PostRequest{
+		Title:   c.FormValue("title"),
+		Content: c.FormValue("content"),
+		Status:  c.FormValue("status"),
+	}
+
+	if publishedAt := strings.TrimSpace(c.FormValue("published_at")); publishedAt != "" {
+		publishAt, err := time.Parse(time.RFC3339, publishedAt)
+		if err != nil {
+			return req, errors.New("published_at must be an RFC 3339 date-time, e.g. 2025-01-31T08:00:00+07:00")
+		}
+		req.PublishAt = &publishAt
+	}
+
+	var err error
+	if req.CategoryIDs, err = formIntList(c, "categories"); err != nil {
+		return req, err
+	}
+	if req.CuisineIDs, err = formIntList(c, "cuisines"); err != nil {
+		return req, err
+	}
+	if tags, ok := formList(c, "tags"); ok {
+		req.Tags = tags
+	}
+
+	return req, nil
+}
+
+// formList reads a comma-separated or repeated form field. The second return
+// value reports whether the field was sent at all, so callers can tell
+// "clear the list" apart from "leave it alone".
+func formList(c fiber.Ctx, key string) ([]string, bool) {
+	var raw []string
+	if form, err := c.MultipartForm(); err == nil {
+		values, ok := form.Value[key]
+		if !ok {
+			return nil, false
+		}
+		raw = values
+	} else {
+		args := c.Request().PostArgs()
+		if !args.Has(key) {
+			return nil, false
+		}
+		for _, value := range args.PeekMulti(key) {
+			raw = append(raw, string(value))
+		}
+	}
+
+	items := make([]string, 0, len(raw))
+	for _, value := range raw {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				items = append(items, part)
+			}
+		}
+	}
+	return items, true
+}
+
+func formIntList(c fiber.Ctx, key string) ([]int, error) {
+	items, ok := formList(c, key)
+	if !ok {
+		return nil, nil
+	}
+
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		id, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, errors.New(key + " must be a list of IDs")
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// uploadErrorStatus picks the HTTP status for a rejected upload, or returns
+// fallback when err is not an upload error.
+func uploadErrorStatus(err error, fallback int) int {
+	var uploadErr *upload.Error
+	if !errors.As(err, &uploadErr) {
+		return fallback
+	}
+
+	switch uploadErr.Kind {
+	case upload.TooLarge:
+		return fiber.StatusRequestEntityTooLarge
+	case upload.UnsupportedType:
+		return fiber.StatusUnsupportedMediaType
+	default:
+		return fiber.StatusUnprocessableEntity
+	}
+}
+
+// galleryFiles returns the files sent in the "images" form field.
+func galleryFiles(c fiber.Ctx) []*multipart.FileHeader {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil
 	}
+	return form.File["images"]
+}
 
-	title := c.FormValue("title")
-	content := c.FormValue("content")
+func (h *PostHandler) CreatePost(c fiber.Ctx) error {
+	userID, _ := getCurrentUser(c)
+
+	req, err := postRequestFromForm(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
 
 	file, err := c.FormFile("image")
 	if err != nil {
 		file = nil
 	}
 
-	err = h.postService.CreatePost(c.Context(), userID, title, content, file)
+	err = h.postService.CreatePost(c.Context(), userID, req, file, galleryFiles(c))
 	if err != nil {
-		return response.Error(c, fiber.StatusBadRequest, err.Error())
+		return response.Error(c, uploadErrorStatus(err, fiber.StatusBadRequest), err.Error())
 	}
 
-	log.Info().Int("user_id", userID).Str("title", title).Msg("New post successfully created")
+	log.Info().Int("user_id", userID).Str("title", req.Title).Msg("New post successfully created")
 
 	return response.Success(c, fiber.StatusCreated, "Post successfully published!", nil, nil)
 }
@@ -49,21 +177,9 @@ func (h *PostHandler) DeletePost(c fiber.Ctx) error {
 		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID")
 	}
 
-	userIDVal := c.Locals("user_id")
-	var currentUserID int
-	if idFloat, ok := userIDVal.(float64); ok {
-		currentUserID = int(idFloat)
-	} else if idInt, ok := userIDVal.(int); ok {
-		currentUserID = idInt
-	}
-
-	roleVal := c.Locals("role")
-	var currentUserRole string
-	if roleStr, ok := roleVal.(string); ok {
-		currentUserRole = roleStr
-	}
+	currentUserID, permissions := getCurrentUser(c)
 
-	err = h.postService.DeletePost(c.Context(), postID, currentUserID, currentUserRole)
+	err = h.postService.DeletePost(c.Context(), postID, currentUserID, permissions)
 	if err != nil {
 		if strings.Contains(err.Error(), "access denied") {
 			return response.Error(c, fiber.StatusForbidden, err.Error())
@@ -82,31 +198,21 @@ func (h *PostHandler) UpdatePost(c fiber.Ctx) error {
 		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID")
 	}
 
-	userIDVal := c.Locals("user_id")
-	var currentUserID int
-	if idFloat, ok := userIDVal.(float64); ok {
-		currentUserID = int(idFloat)
-	} else if idInt, ok := userIDVal.(int); ok {
-		currentUserID = idInt
-	}
+	currentUserID, permissions := getCurrentUser(c)
 
-	roleVal := c.Locals("role")
-	var currentUserRole string
-	if roleStr, ok := roleVal.(string); ok {
-		currentUserRole = roleStr
+	req, err := postRequestFromForm(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
-	title := c.FormValue("title")
-	content := c.FormValue("content")
-
 	file, _ := c.FormFile("image")
 
-	err = h.postService.UpdatePost(c.Context(), postID, currentUserID, currentUserRole, title, content, file)
+	err = h.postService.UpdatePost(c.Context(), postID, currentUserID, permissions, req, file, galleryFiles(c))
 	if err != nil {
 		if strings.Contains(err.Error(), "access denied") {
 			return response.Error(c, fiber.StatusForbidden, err.Error())
 		}
-		return response.Error(c, fiber.StatusBadRequest, err.Error())
+		return response.Error(c, uploadErrorStatus(err, fiber.StatusBadRequest), err.Error())
 	}
 
 	log.Info().Int("post_id", postID).Int("updated_by", currentUserID).Msg("Post successfully updated")
@@ -141,7 +247,13 @@ func (h *PostHandler) GetAllPosts(c fiber.Ctx) error {
 		return response.Error(c, fiber.StatusBadRequest, "Page parameter must be a number")
 	}
 
-	posts, err := h.postService.GetAllPosts(c.Context(), convPage, convLimit)
+	filter := models.PostFilter{
+		Category: c.Query("category"),
+		Cuisine:  c.Query("cuisine"),
+		Tag:      c.Query("tag"),
+	}
+
+	posts, err := h.postService.GetAllPosts(c.Context(), filter, convPage, convLimit)
 	if err != nil {
 		return response.Error(c, fiber.StatusBadRequest, "Failed to retrieve posts")
 	}
@@ -152,3 +264,191 @@ func (h *PostHandler) GetAllPosts(c fiber.Ctx) error {
 		"count": len(posts),
 	})
 }
+
+// GetPostBySlug serves a post by its slug. Slugs a post had before its title
+// changed answer with a permanent redirect to the current one.
+func (h *PostHandler) GetPostBySlug(c fiber.Ctx) error {
+	post, current, err := h.postService.GetPostBySlug(c.Context(), c.Params("slug"))
+	if err != nil {
+		return response.Error(c, fiber.StatusNotFound, err.Error())
+	}
+
+	if current != "" {
+		return c.Redirect().Status(fiber.StatusMovedPermanently).To("/v1/posts/by-slug/" + current)
+	}
+
+	return response.Success(c, fiber.StatusOK, "Post detail successfully retrieved", post, nil)
+}
+
+func (h *PostHandler) GetMyPosts(c fiber.Ctx) error {
+	page := c.Query("page", "1")
+	limit := c.Query("limit", "10")
+
+	convPage, err := strconv.Atoi(page)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Page parameter must be a number")
+	}
+	convLimit, err := strconv.Atoi(limit)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Limit parameter must be a number")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	posts, err := h.postService.GetMyPosts(c.Context(), currentUserID, c.Query("status"), convPage, convLimit)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Posts successfully retrieved", posts, fiber.Map{
+		"page":  page,
+		"limit": limit,
+		"count": len(posts),
+	})
+}
+
+func (h *PostHandler) PreviewPost(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	post, err := h.postService.PreviewPost(c.Context(), id, currentUserID, permissions)
+	if err != nil {
+		if strings.Contains(err.Error(), "access denied") {
+			return response.Error(c, fiber.StatusForbidden, err.Error())
+		}
+		return response.Error(c, fiber.StatusNotFound, err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Post detail successfully retrieved", post, nil)
+}
+
+func (h *PostHandler) GetScaledPost(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	servings, err := strconv.Atoi(c.Query("servings", "0"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Servings parameter must be a number")
+	}
+
+	scaled, err := h.postService.GetScaledPost(c.Context(), id, servings, c.Query("units"))
+	if err != nil {
+		if err.Error() == "post not found" {
+			return response.Error(c, fiber.StatusNotFound, err.Error())
+		}
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Scaled recipe successfully retrieved", scaled, nil)
+}
+
+// ratingErrorStatus picks the HTTP status for an error from the rating
+// endpoints.
+func ratingErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "access denied"):
+		return fiber.StatusForbidden
+	case err.Error() == "post not found", err.Error() == "rating not found":
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+func (h *PostHandler) RatePost(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID")
+	}
+
+	var req models.RatingRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	rating, err := h.postService.RatePost(c.Context(), postID, currentUserID, req)
+	if err != nil {
+		return response.Error(c, ratingErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("post_id", postID).Int("user_id", currentUserID).Int("rating", rating.Rating).Msg("Post successfully rated")
+
+	return response.Success(c, fiber.StatusCreated, "Rating successfully saved", rating, nil)
+}
+
+func (h *PostHandler) UpdateRating(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID")
+	}
+
+	var req models.RatingRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	rating, err := h.postService.UpdateRating(c.Context(), postID, currentUserID, req)
+	if err != nil {
+		return response.Error(c, ratingErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Rating successfully updated", rating, nil)
+}
+
+func (h *PostHandler) DeleteRating(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	if err := h.postService.DeleteRating(c.Context(), postID, currentUserID); err != nil {
+		return response.Error(c, ratingErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("post_id", postID).Int("user_id", currentUserID).Msg("Rating successfully deleted")
+
+	return response.Success(c, fiber.StatusOK, "Rating successfully deleted", nil, nil)
+}
+
+func (h *PostHandler) GetRatings(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	page := c.Query("page", "1")
+	limit := c.Query("limit", "10")
+
+	convPage, err := strconv.Atoi(page)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Page parameter must be a number")
+	}
+	convLimit, err := strconv.Atoi(limit)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Limit parameter must be a number")
+	}
+
+	ratings, total, err := h.postService.GetRatings(c.Context(), postID, c.Query("sort"), convPage, convLimit)
+	if err != nil {
+		return response.Error(c, ratingErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Ratings successfully retrieved", ratings, fiber.Map{
+		"page":  page,
+		"limit": limit,
+		"count": len(ratings),
+		"total": total,
+	})
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type ProfileHandler struct {
+	profileService service.ProfileService
+	postService    service.PostService
+}
+
+func NewProfileHandler(profileService service.ProfileService, postService service.PostService) *ProfileHandler {
+	return &ProfileHandler{profileService: profileService, postService: postService}
+}
+
+// GetProfile shows an author's profile with a page of their published posts.
+func (h *ProfileHandler) GetProfile(c fiber.Ctx) error {
+	page := c.Query("page", "1")
+	limit := c.Query("limit", "10")
+
+	convPage, err := strconv.Atoi(page)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Page parameter must be a number")
+	}
+	convLimit, err := strconv.Atoi(limit)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Limit parameter must be a number")
+	}
+
+	profile, err := h.profileService.GetProfile(c.Context(), c.Params("username"))
+	if err != nil {
+		if err.Error() == "user not found" {
+			return response.Error(c, fiber.StatusNotFound, err.Error())
+		}
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	posts, err := h.postService.GetAuthorPosts(c.Context(), profile.ID, convPage, convLimit)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Failed to retrieve posts")
+	}
+
+	return response.Success(c, fiber.StatusOK, "Profile successfully retrieved", fiber.Map{
+		"profile": profile,
+		"posts":   posts,
+	}, fiber.Map{
+		"page":  page,
+		"limit": limit,
+		"count": len(posts),
+		"total": profile.PostCount,
+	})
+}
+
+// UpdateProfile replaces the current user's profile from a multipart or
+// urlencoded form. Each social platform is its own field, e.g. instagram.
+func (h *ProfileHandler) UpdateProfile(c fiber.Ctx) error {
+	currentUserID, _ := getCurrentUser(c)
+
+	req := models.ProfileRequest{
+		DisplayName:  c.FormValue("display_name"),
+		Bio:          c.FormValue("bio"),
+		Location:     c.FormValue("location"),
+		SocialLinks:  make(map[string]string),
+		RemoveAvatar: c.FormValue("remove_avatar") == "true",
+	}
+	for _, platform := range models.SocialPlatforms {
+		if link := c.FormValue(platform); link != "" {
+			req.SocialLinks[platform] = link
+		}
+	}
+
+	avatar, _ := c.FormFile("avatar")
+
+	profile, err := h.profileService.UpdateProfile(c.Context(), currentUserID, req, avatar)
+	if err != nil {
+		return response.Error(c, uploadErrorStatus(err, fiber.StatusBadRequest), err.Error())
+	}
+
+	log.Info().Int("user_id", currentUserID).Msg("Profile successfully updated")
+
+	return response.Success(c, fiber.StatusOK, "Profile successfully updated", profile, nil)
+}
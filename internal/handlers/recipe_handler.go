@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type RecipeHandler struct {
+	recipeService service.RecipeService
+}
+
+func NewRecipeHandler(recipeService service.RecipeService) *RecipeHandler {
+	return &RecipeHandler{recipeService: recipeService}
+}
+
+func (h *RecipeHandler) CreateRecipe(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID")
+	}
+
+	var req models.RecipeRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	recipe, err := h.recipeService.CreateRecipe(c.Context(), postID, currentUserID, permissions, req)
+	if err != nil {
+		if strings.Contains(err.Error(), "access denied") {
+			return response.Error(c, fiber.StatusForbidden, err.Error())
+		}
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("post_id", postID).Int("user_id", currentUserID).Msg("Recipe successfully created")
+
+	return response.Success(c, fiber.StatusCreated, "Recipe successfully created", recipe, nil)
+}
+
+func (h *RecipeHandler) UpdateRecipe(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID")
+	}
+
+	var req models.RecipeRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	recipe, err := h.recipeService.UpdateRecipe(c.Context(), postID, currentUserID, permissions, req)
+	if err != nil {
+		if strings.Contains(err.Error(), "access denied") {
+			return response.Error(c, fiber.StatusForbidden, err.Error())
+		}
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("post_id", postID).Int("updated_by", currentUserID).Msg("Recipe successfully updated")
+
+	return response.Success(c, fiber.StatusOK, "Recipe successfully updated", recipe, nil)
+}
+
+func (h *RecipeHandler) GetRecipe(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	recipe, err := h.recipeService.GetRecipe(c.Context(), postID, currentUserID, permissions)
+	if err != nil {
+		return response.Error(c, fiber.StatusNotFound, err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Recipe successfully retrieved", recipe, nil)
+}
+
+func (h *RecipeHandler) MatchRecipes(c fiber.Ctx) error {
+	var req models.RecipeMatchRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	matches, err := h.recipeService.MatchRecipes(c.Context(), req)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Matching recipes successfully retrieved", matches, fiber.Map{
+		"page":  max(req.Page, 1),
+		"count": len(matches),
+	})
+}
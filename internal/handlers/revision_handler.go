@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type RevisionHandler struct {
+	revisionService service.RevisionService
+}
+
+func NewRevisionHandler(revisionService service.RevisionService) *RevisionHandler {
+	return &RevisionHandler{revisionService: revisionService}
+}
+
+// revisionErrorStatus picks the HTTP status for an error from the revision
+// endpoints.
+func revisionErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "access denied"):
+		return fiber.StatusForbidden
+	case err.Error() == "post not found", err.Error() == "revision not found":
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+func (h *RevisionHandler) GetRevisions(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	revisions, err := h.revisionService.GetRevisions(c.Context(), postID, currentUserID, permissions)
+	if err != nil {
+		return response.Error(c, revisionErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Revisions successfully retrieved", revisions, nil)
+}
+
+func (h *RevisionHandler) GetRevision(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	revision, err := strconv.Atoi(c.Params("rev"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid revision number")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	snapshot, err := h.revisionService.GetRevision(c.Context(), postID, revision, currentUserID, permissions)
+	if err != nil {
+		return response.Error(c, revisionErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Revision successfully retrieved", snapshot, nil)
+}
+
+func (h *RevisionHandler) DiffRevisions(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "From parameter must be a revision number")
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "To parameter must be a revision number")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	result, err := h.revisionService.DiffRevisions(c.Context(), postID, from, to, currentUserID, permissions)
+	if err != nil {
+		return response.Error(c, revisionErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Revision diff successfully generated", result, nil)
+}
+
+func (h *RevisionHandler) RestoreRevision(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID")
+	}
+
+	revision, err := strconv.Atoi(c.Params("rev"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid revision number")
+	}
+
+	currentUserID, permissions := getCurrentUser(c)
+
+	restored, err := h.revisionService.RestoreRevision(c.Context(), postID, revision, currentUserID, permissions)
+	if err != nil {
+		return response.Error(c, revisionErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("post_id", postID).Int("restored_revision", revision).Int("restored_by", currentUserID).Msg("Post revision successfully restored")
+
+	return response.Success(c, fiber.StatusOK, "Revision successfully restored", restored, nil)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/pkg/response"
+)
+
+type SearchHandler struct {
+	searchService service.SearchService
+}
+
+func NewSearchHandler(searchService service.SearchService) *SearchHandler {
+	return &SearchHandler{searchService: searchService}
+}
+
+func (h *SearchHandler) SearchPosts(c fiber.Ctx) error {
+	page := c.Query("page", "1")
+	limit := c.Query("limit", "10")
+
+	convPage, err := strconv.Atoi(page)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Page parameter must be a number")
+	}
+	convLimit, err := strconv.Atoi(limit)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Limit parameter must be a number")
+	}
+
+	results, total, err := h.searchService.SearchPosts(c.Context(), c.Query("q"), convPage, convLimit)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Search results successfully retrieved", results, fiber.Map{
+		"page":  page,
+		"limit": limit,
+		"count": len(results),
+		"total": total,
+	})
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type TaxonomyHandler struct {
+	taxonomyService service.TaxonomyService
+}
+
+func NewTaxonomyHandler(taxonomyService service.TaxonomyService) *TaxonomyHandler {
+	return &TaxonomyHandler{taxonomyService: taxonomyService}
+}
+
+func (h *TaxonomyHandler) GetCategories(c fiber.Ctx) error {
+	categories, err := h.taxonomyService.GetCategoryTree(c.Context())
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "failed to retrieve categories")
+	}
+
+	return response.Success(c, fiber.StatusOK, "Categories successfully retrieved", categories, nil)
+}
+
+func (h *TaxonomyHandler) CreateCategory(c fiber.Ctx) error {
+	var req models.CategoryRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	category, err := h.taxonomyService.CreateCategory(c.Context(), req)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Str("category", category.Slug).Msg("Category successfully created")
+
+	return response.Success(c, fiber.StatusCreated, "Category successfully created", category, nil)
+}
+
+func (h *TaxonomyHandler) UpdateCategory(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid category ID")
+	}
+
+	var req models.CategoryRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	category, err := h.taxonomyService.UpdateCategory(c.Context(), id, req)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Int("category_id", id).Msg("Category successfully updated")
+
+	return response.Success(c, fiber.StatusOK, "Category successfully updated", category, nil)
+}
+
+func (h *TaxonomyHandler) DeleteCategory(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid category ID")
+	}
+
+	if err := h.taxonomyService.DeleteCategory(c.Context(), id); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Int("category_id", id).Msg("Category successfully deleted")
+
+	return response.Success(c, fiber.StatusOK, "Category successfully deleted", nil, nil)
+}
+
+func (h *TaxonomyHandler) GetCuisines(c fiber.Ctx) error {
+	cuisines, err := h.taxonomyService.GetCuisines(c.Context())
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "failed to retrieve cuisines")
+	}
+
+	return response.Success(c, fiber.StatusOK, "Cuisines successfully retrieved", cuisines, nil)
+}
+
+func (h *TaxonomyHandler) CreateCuisine(c fiber.Ctx) error {
+	var req models.TermRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	cuisine, err := h.taxonomyService.CreateCuisine(c.Context(), req)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Str("cuisine", cuisine.Slug).Msg("Cuisine successfully created")
+
+	return response.Success(c, fiber.StatusCreated, "Cuisine successfully created", cuisine, nil)
+}
+
+func (h *TaxonomyHandler) UpdateCuisine(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid cuisine ID")
+	}
+
+	var req models.TermRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	cuisine, err := h.taxonomyService.UpdateCuisine(c.Context(), id, req)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Int("cuisine_id", id).Msg("Cuisine successfully updated")
+
+	return response.Success(c, fiber.StatusOK, "Cuisine successfully updated", cuisine, nil)
+}
+
+func (h *TaxonomyHandler) DeleteCuisine(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid cuisine ID")
+	}
+
+	if err := h.taxonomyService.DeleteCuisine(c.Context(), id); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Int("cuisine_id", id).Msg("Cuisine successfully deleted")
+
+	return response.Success(c, fiber.StatusOK, "Cuisine successfully deleted", nil, nil)
+}
+
+func (h *TaxonomyHandler) GetTags(c fiber.Ctx) error {
+	tags, err := h.taxonomyService.GetTags(c.Context())
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "failed to retrieve tags")
+	}
+
+	return response.Success(c, fiber.StatusOK, "Tags successfully retrieved", tags, nil)
+}
+
+func (h *TaxonomyHandler) AutocompleteTags(c fiber.Ctx) error {
+	tags, err := h.taxonomyService.AutocompleteTags(c.Context(), c.Query("q"))
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "failed to retrieve tags")
+	}
+
+	return response.Success(c, fiber.StatusOK, "Tag suggestions successfully retrieved", tags, nil)
+}
+
+func (h *TaxonomyHandler) CreateTag(c fiber.Ctx) error {
+	var req models.TermRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	tag, err := h.taxonomyService.CreateTag(c.Context(), req)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Str("tag", tag.Slug).Msg("Tag successfully created")
+
+	return response.Success(c, fiber.StatusCreated, "Tag successfully created", tag, nil)
+}
+
+func (h *TaxonomyHandler) UpdateTag(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid tag ID")
+	}
+
+	var req models.TermRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	tag, err := h.taxonomyService.UpdateTag(c.Context(), id, req)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Int("tag_id", id).Msg("Tag successfully updated")
+
+	return response.Success(c, fiber.StatusOK, "Tag successfully updated", tag, nil)
+}
+
+func (h *TaxonomyHandler) DeleteTag(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid tag ID")
+	}
+
+	if err := h.taxonomyService.DeleteTag(c.Context(), id); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Int("tag_id", id).Msg("Tag successfully deleted")
+
+	return response.Success(c, fiber.StatusOK, "Tag successfully deleted", nil, nil)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type TrashHandler struct {
+	trashService service.TrashService
+}
+
+func NewTrashHandler(trashService service.TrashService) *TrashHandler {
+	return &TrashHandler{trashService: trashService}
+}
+
+// trashErrorStatus picks the HTTP status for an error from the trash
+// endpoints.
+func trashErrorStatus(err error) int {
+	if strings.HasSuffix(err.Error(), "not found in trash") {
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusBadRequest
+}
+
+func (h *TrashHandler) GetTrashedPosts(c fiber.Ctx) error {
+	page := c.Query("page", "1")
+	limit := c.Query("limit", "10")
+
+	convPage, err := strconv.Atoi(page)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Page parameter must be a number")
+	}
+	convLimit, err := strconv.Atoi(limit)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Limit parameter must be a number")
+	}
+
+	posts, total, err := h.trashService.GetTrashedPosts(c.Context(), convPage, convLimit)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "failed to retrieve trashed posts")
+	}
+
+	return response.Success(c, fiber.StatusOK, "Trashed posts successfully retrieved", posts, fiber.Map{
+		"page":  page,
+		"limit": limit,
+		"count": len(posts),
+		"total": total,
+	})
+}
+
+func (h *TrashHandler) RestorePost(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	if err := h.trashService.RestorePost(c.Context(), postID); err != nil {
+		return response.Error(c, trashErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Int("post_id", postID).Msg("Post successfully restored from trash")
+
+	return response.Success(c, fiber.StatusOK, "Post successfully restored", nil, nil)
+}
+
+func (h *TrashHandler) PurgePost(c fiber.Ctx) error {
+	postID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid post ID format")
+	}
+
+	if err := h.trashService.PurgePost(c.Context(), postID); err != nil {
+		return response.Error(c, trashErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Int("post_id", postID).Msg("Post permanently deleted")
+
+	return response.Success(c, fiber.StatusOK, "Post permanently deleted", nil, nil)
+}
+
+func (h *TrashHandler) GetTrashedUsers(c fiber.Ctx) error {
+	users, err := h.trashService.GetTrashedUsers(c.Context())
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "failed to retrieve trashed users")
+	}
+
+	return response.Success(c, fiber.StatusOK, "Trashed users successfully retrieved", users, nil)
+}
+
+func (h *TrashHandler) RestoreUser(c fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := h.trashService.RestoreUser(c.Context(), userID); err != nil {
+		return response.Error(c, trashErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Int("target_id", userID).Msg("User successfully restored from trash")
+
+	return response.Success(c, fiber.StatusOK, "User successfully restored", nil, nil)
+}
+
+func (h *TrashHandler) PurgeUser(c fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := h.trashService.PurgeUser(c.Context(), userID); err != nil {
+		return response.Error(c, trashErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("admin_id", getAdminID(c)).Int("target_id", userID).Msg("User permanently deleted")
+
+	return response.Success(c, fiber.StatusOK, "User permanently deleted", nil, nil)
+}
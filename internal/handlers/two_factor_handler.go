@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/service"
+	"github.com/rafli2460/culinary-blog-api/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService service.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: twoFactorService}
+}
+
+func (h *TwoFactorHandler) GetStatus(c fiber.Ctx) error {
+	currentUserID, _ := getCurrentUser(c)
+
+	status, err := h.twoFactorService.GetStatus(c.Context(), currentUserID)
+	if err != nil {
+		return response.Error(c, accountErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Two-factor status successfully retrieved", status, nil)
+}
+
+// Setup starts enrolling the current user and returns the secret for their
+// authenticator app.
+func (h *TwoFactorHandler) Setup(c fiber.Ctx) error {
+	currentUserID, _ := getCurrentUser(c)
+
+	setup, err := h.twoFactorService.Setup(c.Context(), currentUserID)
+	if err != nil {
+		return response.Error(c, accountErrorStatus(err), err.Error())
+	}
+
+	return response.Success(c, fiber.StatusOK, "Add the secret to your authenticator app, then confirm with a code", setup, nil)
+}
+
+// Enable confirms the enrollment with a first code and returns the recovery
+// codes. This is the only time they are shown.
+func (h *TwoFactorHandler) Enable(c fiber.Ctx) error {
+	var req models.TwoFactorCodeRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	codes, err := h.twoFactorService.Enable(c.Context(), currentUserID, req.Code)
+	if err != nil {
+		return response.Error(c, accountErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("user_id", currentUserID).Msg("Two-factor authentication enabled")
+	return response.Success(c, fiber.StatusOK, "Two-factor authentication enabled, store your recovery codes somewhere safe", models.RecoveryCodes{Codes: codes}, nil)
+}
+
+func (h *TwoFactorHandler) Disable(c fiber.Ctx) error {
+	var req models.ConfirmTwoFactorRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	if err := h.twoFactorService.Disable(c.Context(), currentUserID, req); err != nil {
+		return response.Error(c, accountErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("user_id", currentUserID).Msg("Two-factor authentication disabled")
+	return response.Success(c, fiber.StatusOK, "Two-factor authentication disabled", nil, nil)
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes.
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c fiber.Ctx) error {
+	var req models.ConfirmTwoFactorRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid data format")
+	}
+
+	currentUserID, _ := getCurrentUser(c)
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Context(), currentUserID, req)
+	if err != nil {
+		return response.Error(c, accountErrorStatus(err), err.Error())
+	}
+
+	log.Info().Int("user_id", currentUserID).Msg("Recovery codes regenerated")
+	return response.Success(c, fiber.StatusOK, "Recovery codes regenerated, the old ones no longer work", models.RecoveryCodes{Codes: codes}, nil)
+}
@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// File writes every message to its own .eml file in a directory instead of
+// sending it, for development. The files open in any mail client.
+type File struct {
+	dir  string
+	from string
+}
+
+func NewFile(dir string, from string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+func (f *File) Send(ctx context.Context, msg Message) error {
+	now := time.Now()
+	data, err := msg.bytes(f.from, now)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(f.dir, now.UTC().Format("20060102-150405")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	log.Info().Str("to", msg.To).Str("subject", msg.Subject).Str("file", file.Name()).Msg("Email written to file")
+	return nil
+}
+
+// Log writes messages to the application log instead of sending them, for
+// development. Messages carry tokens, so it must not be used in production.
+type Log struct{}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	log.Info().Str("to", msg.To).Str("subject", msg.Subject).Msg("Email not sent, MAIL_DRIVER is log:\n" + msg.Body)
+	return nil
+}
@@ -0,0 +1,77 @@
+// Package mailer sends transactional email such as verification and password
+// reset links behind a small interface, so the same code can deliver through
+// an SMTP server or, in development, write the messages to disk or a log.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	// Send delivers msg, or returns an error when it could not be handed
+	// over for delivery.
+	Send(ctx context.Context, msg Message) error
+}
+
+// validate rejects messages whose recipient or subject could inject extra
+// headers.
+func (m Message) validate() error {
+	if m.To == "" {
+		return errors.New("mailer: message has no recipient")
+	}
+	if strings.ContainsAny(m.To, "\r\n") || strings.ContainsAny(m.Subject, "\r\n") {
+		return errors.New("mailer: line break in a header")
+	}
+	return nil
+}
+
+// bytes formats the message as an RFC 5322 email sent by from.
+func (m Message) bytes(from string, now time.Time) ([]byte, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.TrimSuffix(from[at+1:], ">")
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+
+	body := strings.ReplaceAll(m.Body, "\r\n", "\n")
+	for line := range strings.SplitSeq(body, "\n") {
+		b.WriteString(line)
+		b.WriteString("\r\n")
+	}
+
+	return b.Bytes(), nil
+}
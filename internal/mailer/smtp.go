@@ -0,0 +1,123 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig holds the settings for an SMTP server.
+type SMTPConfig struct {
+	Host string
+	// Port 465 uses TLS from the start; on other ports, usually 587 or 25,
+	// the connection is upgraded with STARTTLS when the server offers it.
+	Port     int
+	Username string
+	Password string
+	// From is the sender, e.g. "Culinary Blog <no-reply@example.com>".
+	From string
+}
+
+// sendTimeout bounds a delivery when the context has no deadline.
+const sendTimeout = 30 * time.Second
+
+// SMTP delivers messages through an SMTP server.
+type SMTP struct {
+	cfg      SMTPConfig
+	envelope string
+	// rootCAs verifies the server certificate; nil uses the system roots.
+	rootCAs *x509.CertPool
+}
+
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("mailer: SMTP host is required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, errors.New("mailer: invalid sender address: " + cfg.From)
+	}
+
+	return &SMTP{cfg: cfg, envelope: from.Address}, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	data, err := msg.bytes(s.cfg.From, time.Now())
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(sendTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host, RootCAs: s.rootCAs}
+	if s.cfg.Port == 465 {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if s.cfg.Port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.cfg.Username != "" {
+		// PlainAuth refuses to send the password over a connection without
+		// TLS, unless the server is on localhost.
+		auth := smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.envelope); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,356 @@
+package mailer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpSession is what the stand-in server saw of one delivery.
+type smtpSession struct {
+	tlsBeforeAuth bool
+	username      string
+	password      string
+	mailFrom      string
+	rcptTo        string
+	data          string
+}
+
+// smtpServer is a minimal SMTP server on a local listener. It offers
+// STARTTLS when tlsConfig is set, accepts AUTH PLAIN with the configured
+// credentials and records every delivery.
+type smtpServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	username  string
+	password  string
+
+	mu       sync.Mutex
+	sessions []smtpSession
+}
+
+func newSMTPServer(t *testing.T, tlsConfig *tls.Config) *smtpServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &smtpServer{listener: listener, tlsConfig: tlsConfig, username: "mailer", password: "secret"}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *smtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) delivered() []smtpSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpSession(nil), s.sessions...)
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	text := textproto.NewConn(conn)
+	secure := false
+	var session smtpSession
+
+	text.PrintfLine("220 test.local ESMTP ready")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			text.PrintfLine("250-test.local")
+			if s.tlsConfig != nil && !secure {
+				text.PrintfLine("250-STARTTLS")
+			}
+			text.PrintfLine("250-AUTH PLAIN")
+			text.PrintfLine("250 8BITMIME")
+		case "STARTTLS":
+			text.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			text = textproto.NewConn(conn)
+		case "AUTH":
+			mechanism, response, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(response)
+			parts := strings.Split(string(decoded), "\x00")
+			if mechanism != "PLAIN" || len(parts) != 3 || parts[1] != s.username || parts[2] != s.password {
+				text.PrintfLine("535 authentication failed")
+				continue
+			}
+			session.tlsBeforeAuth = secure
+			session.username, session.password = parts[1], parts[2]
+			text.PrintfLine("235 authenticated")
+		case "MAIL":
+			session.mailFrom = arg
+			text.PrintfLine("250 ok")
+		case "RCPT":
+			session.rcptTo = arg
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 end with .")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			session.data = string(data)
+			s.mu.Lock()
+			s.sessions = append(s.sessions, session)
+			s.mu.Unlock()
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+// testCertificate returns a self-signed certificate for 127.0.0.1 and a pool
+// that trusts it.
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func TestSMTPSendWithSTARTTLS(t *testing.T) {
+	cert, pool := testCertificate(t)
+	server := newSMTPServer(t, &tls.Config{Certificates: []tls.Certificate{cert}})
+
+	smtpMailer, err := NewSMTP(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Username: "mailer",
+		Password: "secret",
+		From:     "Culinary Blog <no-reply@culinary.example>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	smtpMailer.rootCAs = pool
+
+	msg := Message{
+		To:      "cook@example.com",
+		Subject: "Vérifiez votre adresse",
+		Body:    "Hello,\nclick the link.\r\nThanks",
+	}
+	if err := smtpMailer.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	sessions := server.delivered()
+	if len(sessions) != 1 {
+		t.Fatalf("server received %d messages, want 1", len(sessions))
+	}
+	session := sessions[0]
+
+	if !session.tlsBeforeAuth {
+		t.Error("credentials were sent before STARTTLS")
+	}
+	if session.username != "mailer" || session.password != "secret" {
+		t.Errorf("authenticated as %q/%q", session.username, session.password)
+	}
+	if !strings.HasPrefix(session.mailFrom, "FROM:<no-reply@culinary.example>") {
+		t.Errorf("MAIL %s, want the bare sender address", session.mailFrom)
+	}
+	if session.rcptTo != "TO:<cook@example.com>" {
+		t.Errorf("RCPT %s", session.rcptTo)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(session.data))
+	if err != nil {
+		t.Fatalf("delivered message does not parse: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q (%v), want %q", subject, err, msg.Subject)
+	}
+
+	headers := map[string]string{
+		"From":                      "Culinary Blog <no-reply@culinary.example>",
+		"To":                        "cook@example.com",
+		"MIME-Version":              "1.0",
+		"Content-Type":              "text/plain; charset=utf-8",
+		"Content-Transfer-Encoding": "8bit",
+	}
+	for name, want := range headers {
+		if got := parsed.Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := parsed.Header.Date(); err != nil {
+		t.Errorf("Date header: %v", err)
+	}
+	if id := parsed.Header.Get("Message-ID"); !strings.HasSuffix(id, "@culinary.example>") {
+		t.Errorf("Message-ID = %q, want one on the sender's domain", id)
+	}
+
+	// The DATA reader turns CRLF back into LF, so mixed line endings in the
+	// body must arrive as three plain lines.
+	body, _ := io.ReadAll(parsed.Body)
+	if string(body) != "Hello,\nclick the link.\nThanks\n" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestSMTPSendWithoutTLS(t *testing.T) {
+	// Without STARTTLS the password may only go to a server on localhost.
+	server := newSMTPServer(t, nil)
+
+	smtpMailer, err := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: server.port(), Username: "mailer", Password: "secret", From: "no-reply@culinary.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := smtpMailer.Send(context.Background(), Message{To: "cook@example.com", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	sessions := server.delivered()
+	if len(sessions) != 1 || sessions[0].tlsBeforeAuth {
+		t.Fatalf("sessions = %+v, want one plain delivery", sessions)
+	}
+}
+
+func TestSMTPSendFailures(t *testing.T) {
+	cert, pool := testCertificate(t)
+	server := newSMTPServer(t, &tls.Config{Certificates: []tls.Certificate{cert}})
+
+	tests := []struct {
+		name     string
+		password string
+		rootCAs  *x509.CertPool
+	}{
+		{"wrong password", "guess", pool},
+		{"untrusted certificate", "secret", x509.NewCertPool()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			smtpMailer, err := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: server.port(), Username: "mailer", Password: tt.password, From: "no-reply@culinary.example"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			smtpMailer.rootCAs = tt.rootCAs
+
+			if err := smtpMailer.Send(context.Background(), Message{To: "cook@example.com", Subject: "Hi", Body: "Hello"}); err == nil {
+				t.Error("Send succeeded")
+			}
+		})
+	}
+	if len(server.delivered()) != 0 {
+		t.Error("a message was delivered")
+	}
+}
+
+func TestSMTPRejectsHeaderInjection(t *testing.T) {
+	server := newSMTPServer(t, nil)
+	smtpMailer, err := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: server.port(), From: "no-reply@culinary.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"no recipient", Message{Subject: "Hi", Body: "Hello"}},
+		{"bcc in recipient", Message{To: "cook@example.com\r\nBcc: everyone@example.com", Subject: "Hi"}},
+		{"newline in recipient", Message{To: "cook@example.com\nBcc: everyone@example.com", Subject: "Hi"}},
+		{"header in subject", Message{To: "cook@example.com", Subject: "Hi\r\nBcc: everyone@example.com"}},
+		{"body in subject", Message{To: "cook@example.com", Subject: "Hi\n\nFake body"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := smtpMailer.Send(context.Background(), tt.msg); err == nil {
+				t.Error("Send accepted the message")
+			}
+		})
+	}
+	if len(server.delivered()) != 0 {
+		t.Error("an unsafe message was delivered")
+	}
+}
+
+func TestNewSMTP(t *testing.T) {
+	if _, err := NewSMTP(SMTPConfig{From: "no-reply@culinary.example"}); err == nil {
+		t.Error("NewSMTP accepted a config without a host")
+	}
+	if _, err := NewSMTP(SMTPConfig{Host: "smtp.example.com", From: "not an address"}); err == nil {
+		t.Error("NewSMTP accepted an invalid sender")
+	}
+
+	smtpMailer, err := NewSMTP(SMTPConfig{Host: "smtp.example.com", From: "Blog <no-reply@culinary.example>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if smtpMailer.cfg.Port != 587 || smtpMailer.envelope != "no-reply@culinary.example" {
+		t.Errorf("port %d, envelope %q", smtpMailer.cfg.Port, smtpMailer.envelope)
+	}
+}
@@ -1,76 +1,160 @@
 package middleware
 
 import (
+	"context"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
 	"github.com/rafli2460/culinary-blog-api/pkg/response"
 	"github.com/rs/zerolog/log"
 )
 
-func Protected() fiber.Handler {
+// APIKeyVerifier checks an API key sent as a bearer token and returns it with
+// its owner's role and scopes.
+type APIKeyVerifier interface {
+	Verify(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+// PermissionLookup resolves what a role is allowed to do.
+type PermissionLookup interface {
+	Permissions(ctx context.Context, role string) (models.Permissions, error)
+}
+
+// Protected lets a request through when it carries a valid access token, in
+// the jwt_token cookie or an Authorization: Bearer header, or a valid API key
+// as a bearer token. Requests with an API key only reach the routes its
+// scopes cover; see RequireScope. The permissions of the user's role are
+// stored for Require.
+func Protected(keys APIKeyVerifier, roles PermissionLookup) fiber.Handler {
 	return func(c fiber.Ctx) error {
-		tokenString := c.Cookies("jwt_token")
+		if err := authenticate(c, keys); err != nil {
+			return response.Error(c, err.Code, err.Message)
+		}
 
-		if tokenString == "" {
-			log.Warn().Msg("Access Denied: token not found")
-			return response.Error(c, fiber.StatusUnauthorized, "access denied")
+		role, _ := c.Locals("role").(string)
+		permissions, err := roles.Permissions(c.Context(), role)
+		if err != nil {
+			return response.Error(c, fiber.StatusInternalServerError, "failed to load permissions")
 		}
+		c.Locals("permissions", permissions)
 
-		secretKey := os.Getenv("JWT_SECRET")
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fiber.ErrUnauthorized
-			}
-			return []byte(secretKey), nil
-		})
-
-		if err != nil || !token.Valid {
-			log.Warn().Err(err).Msg("token invalid: token expired or not valid")
-			return response.Error(c, fiber.StatusUnauthorized, "session is not valid or has ended. Please re-login")
+		return c.Next()
+	}
+}
+
+// Require lets a request through only when the user's role has permission.
+// It runs after Protected.
+func Require(permission string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		permissions, _ := c.Locals("permissions").(models.Permissions)
+		if !permissions.Has(permission) {
+			log.Warn().Interface("user_id", c.Locals("user_id")).Str("permission", permission).Msg("access attempt without permission")
+			return response.Error(c, fiber.StatusForbidden, "Access prohibited. You do not have the "+permission+" permission.")
 		}
+		return c.Next()
+	}
+}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Locals("user_id", claims["user_id"])
+// RequireScope rejects requests made with an API key that lacks scope.
+// Requests made with an access token have every scope.
+func RequireScope(scope string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if !hasScope(c, scope) {
+			return response.Error(c, fiber.StatusForbidden, "API key is missing the "+scope+" scope")
 		}
+		return c.Next()
+	}
+}
 
+// ReadWriteScope requires readScope for GET and HEAD requests and writeScope
+// for everything else.
+func ReadWriteScope(readScope string, writeScope string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		scope := writeScope
+		if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			scope = readScope
+		}
+		if !hasScope(c, scope) {
+			return response.Error(c, fiber.StatusForbidden, "API key is missing the "+scope+" scope")
+		}
 		return c.Next()
 	}
 }
 
-func AdminOnly() fiber.Handler {
+// SessionOnly rejects API keys, for routes such as managing API keys that need
+// the user to have logged in.
+func SessionOnly() fiber.Handler {
 	return func(c fiber.Ctx) error {
-		tokenString := c.Cookies("jwt_token")
+		if _, isAPIKey := c.Locals("scopes").([]string); isAPIKey {
+			return response.Error(c, fiber.StatusForbidden, "This action cannot be done with an API key")
+		}
+		return c.Next()
+	}
+}
+
+func hasScope(c fiber.Ctx, scope string) bool {
+	scopes, isAPIKey := c.Locals("scopes").([]string)
+	return !isAPIKey || slices.Contains(scopes, scope)
+}
+
+// authenticate identifies the user behind a request and stores them in
+// c.Locals.
+func authenticate(c fiber.Ctx, keys APIKeyVerifier) *fiber.Error {
+	tokenString := c.Cookies("jwt_token")
+	if bearer, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "); found {
+		tokenString = strings.TrimSpace(bearer)
+	}
+
+	if tokenString == "" {
+		log.Warn().Msg("Access Denied: token not found")
+		return fiber.NewError(fiber.StatusUnauthorized, "access denied")
+	}
 
-		if tokenString == "" {
-			log.Warn().Msg("Access Denied: token not found")
-			return response.Error(c, fiber.StatusUnauthorized, "access denied")
+	if strings.HasPrefix(tokenString, models.APIKeyPrefix) {
+		key, err := keys.Verify(c.Context(), tokenString)
+		if err != nil {
+			log.Warn().Err(err).Msg("API key rejected")
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 		}
 
-		secretKey := os.Getenv("JWT_SECRET")
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fiber.ErrUnauthorized
-			}
-			return []byte(secretKey), nil
-		})
-
-		if err != nil || !token.Valid {
-			log.Warn().Err(err).Msg("token invalid: token expired or not valid")
-			return response.Error(c, fiber.StatusUnauthorized, "session is not valid or has ended. Please re-login")
+		c.Locals("user_id", key.UserID)
+		c.Locals("role", key.Role)
+		c.Locals("api_key_id", key.ID)
+		c.Locals("scopes", key.Scopes)
+		return nil
+	}
+
+	secretKey := os.Getenv("JWT_SECRET")
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fiber.ErrUnauthorized
 		}
+		return []byte(secretKey), nil
+	})
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			role, roleExists := claims["role"].(string)
-			if !roleExists || role != "admin" {
-				log.Warn().Interface("user_id", claims["user_id"]).Msg("admin access attempts by non-admins")
-				return response.Error(c, fiber.StatusForbidden, "Access prohibited. You do not have admin permission.")
-			}
-			c.Locals("user_id", claims["user_id"])
-			c.Locals("role", role)
+	if err != nil || !token.Valid {
+		log.Warn().Err(err).Msg("token invalid: token expired or not valid")
+		return fiber.NewError(fiber.StatusUnauthorized, "session is not valid or has ended. Please re-login")
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		// Login challenges are signed with the same key but only prove the
+		// password, not the second factor.
+		if _, isChallenge := claims["purpose"]; isChallenge {
+			log.Warn().Msg("token invalid: login challenge used as access token")
+			return fiber.NewError(fiber.StatusUnauthorized, "session is not valid or has ended. Please re-login")
 		}
 
-		return c.Next()
+		c.Locals("user_id", claims["user_id"])
+		c.Locals("session_id", claims["sid"])
+		if role, ok := claims["role"].(string); ok {
+			c.Locals("role", role)
+		}
 	}
+
+	return nil
 }
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// APIKeyPrefix starts every API key, which tells them apart from JWTs in an
+// Authorization header.
+const APIKeyPrefix = "cbk_"
+
+// APIKeyScopes lists what an API key can be allowed to do. Keys only reach
+// the endpoints their scopes cover; logins through a session reach them all.
+var APIKeyScopes = []string{"posts:read", "posts:write", "account:read", "account:write", "admin"}
+
+// APIKey is a long-lived personal token for scripts and apps. Only the
+// SHA-256 of the key is stored; Prefix is kept so users can tell their keys
+// apart.
+type APIKey struct {
+	ID         int        `db:"id" json:"id"`
+	UserID     int        `db:"user_id" json:"-"`
+	Name       string     `db:"name" json:"name"`
+	Prefix     string     `db:"prefix" json:"prefix"`
+	KeyHash    string     `db:"key_hash" json:"-"`
+	ScopeList  string     `db:"scopes" json:"-"`
+	Scopes     []string   `db:"-" json:"scopes"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"-"`
+
+	// Role is the owner's current role, loaded when a key is verified.
+	Role string `db:"role" json:"-"`
+}
+
+// CreatedAPIKey is returned once, when a key is created; Key cannot be
+// retrieved again.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
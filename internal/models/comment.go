@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	CommentPending  = "pending"
+	CommentApproved = "approved"
+	CommentHidden   = "hidden"
+)
+
+type Comment struct {
+	ID        int        `db:"id" json:"id"`
+	PostID    int        `db:"post_id" json:"post_id"`
+	PostTitle string     `db:"post_title" json:"post_title,omitempty"`
+	UserID    int        `db:"user_id" json:"user_id"`
+	ParentID  *int       `db:"parent_id" json:"parent_id"`
+	Username  string     `db:"username" json:"author"`
+	Content   string     `db:"content" json:"content"`
+	Status    string     `db:"status" json:"status"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	EditedAt  *time.Time `db:"edited_at" json:"edited_at"`
+	DeletedAt *time.Time `db:"deleted_at" json:"-"`
+	// Removed marks a deleted, hidden or unapproved comment that is only
+	// listed to keep its replies in place; its content and author are blank.
+	Removed bool      `db:"-" json:"removed"`
+	Replies []Comment `db:"-" json:"replies"`
+}
+
+type CommentRequest struct {
+	Content  string `json:"content"`
+	ParentID *int   `json:"parent_id"`
+}
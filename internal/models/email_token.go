@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Purposes of an email token.
+const (
+	EmailTokenVerify = "verify_email"
+	EmailTokenReset  = "reset_password"
+)
+
+// EmailToken is a single-use link sent by email to prove a user can read
+// their mailbox. Only the SHA-256 of the token is stored. Email is the
+// address it was sent to, so a verification link stops working once the user
+// changes their email.
+type EmailToken struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	Purpose   string     `db:"purpose"`
+	Email     string     `db:"email"`
+	TokenHash string     `db:"token_hash"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token           string `json:"token"`
+	NewPassword     string `json:"new_password"`
+	ConfirmPassword string `json:"confirm_password"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
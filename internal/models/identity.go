@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// UserIdentity links a user to their account at an OpenID Connect provider,
+// so they can log in with it. Subject is the provider's ID for the account,
+// which unlike the email never changes.
+type UserIdentity struct {
+	ID          int        `db:"id" json:"id"`
+	UserID      int        `db:"user_id" json:"-"`
+	Provider    string     `db:"provider" json:"provider"`
+	Subject     string     `db:"subject" json:"-"`
+	Email       *string    `db:"email" json:"email"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	LastLoginAt *time.Time `db:"last_login_at" json:"last_login_at"`
+}
+
+// IdentityAuthorization starts a login or link with a provider. The user is
+// sent to AuthorizationURL, and StateToken is kept in a cookie until the
+// provider sends them back.
+type IdentityAuthorization struct {
+	AuthorizationURL string    `json:"authorization_url"`
+	StateToken       string    `json:"-"`
+	ExpiresAt        time.Time `json:"-"`
+}
+
+// IdentityCallback is the outcome of the provider sending a user back: a
+// login for a login, or the new identity for a link.
+type IdentityCallback struct {
+	Login  *LoginResult
+	Linked *UserIdentity
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	LoginSucceeded = "success"
+	LoginFailed    = "failed"
+	LoginLocked    = "locked"
+)
+
+// LoginAttempt records one try to log in, kept for admins to review.
+// UserID is set when the username belongs to an account.
+type LoginAttempt struct {
+	ID        int64     `db:"id" json:"id"`
+	Username  string    `db:"username" json:"username"`
+	UserID    *int      `db:"user_id" json:"user_id"`
+	IPAddress string    `db:"ip_address" json:"ip_address"`
+	UserAgent *string   `db:"user_agent" json:"user_agent"`
+	Outcome   string    `db:"outcome" json:"outcome"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
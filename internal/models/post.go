@@ -2,20 +2,70 @@ package models
 
 import "time"
 
+const (
+	PostDraft     = "draft"
+	PostPublished = "published"
+	PostScheduled = "scheduled"
+	PostArchived  = "archived"
+)
+
 type Post struct {
-	ID       int       `db:"id" json:"id"`
-	UserID   int       `db:"user_id" json:"user_id"`
-	Title    string    `db:"title" json:"title"`
-	Content  string    `db:"content" json:"content"`
-	Image    *string   `db:"image" json:"image"`
-	CreateAt time.Time `db:"created_at" json:"created_at"`
+	ID          int        `db:"id" json:"id"`
+	UserID      int        `db:"user_id" json:"user_id"`
+	Title       string     `db:"title" json:"title"`
+	Slug        *string    `db:"slug" json:"slug"`
+	Content     string     `db:"content" json:"content"`
+	Image       *string    `db:"image" json:"image"`
+	Status      string     `db:"status" json:"status"`
+	PublishedAt *time.Time `db:"published_at" json:"published_at"`
+	CreateAt    time.Time  `db:"created_at" json:"created_at"`
 }
 
 type PostDetail struct {
+	ID            int               `db:"id" json:"id"`
+	Title         string            `db:"title" json:"title"`
+	Slug          *string           `db:"slug" json:"slug"`
+	Content       string            `db:"content" json:"content"`
+	Image         *string           `db:"image" json:"image"`
+	ImageVariants map[string]string `db:"-" json:"image_variants,omitempty"`
+	Status        string            `db:"status" json:"status"`
+	PublishedAt   *time.Time        `db:"published_at" json:"published_at"`
+	CreatedAt     time.Time         `db:"created_at" json:"created_at"`
+	Username      string            `db:"username" json:"author"`
+	AuthorName    *string           `db:"author_name" json:"author_name"`
+	AuthorAvatar  *string           `db:"author_avatar" json:"author_avatar"`
+	AverageRating float64           `db:"average_rating" json:"average_rating"`
+	RatingCount   int               `db:"rating_count" json:"rating_count"`
+	CommentCount  int               `db:"comment_count" json:"comment_count"`
+	Recipe        *Recipe           `db:"-" json:"recipe,omitempty"`
+	Images        []PostImage       `db:"-" json:"images"`
+	Categories    []Category        `db:"-" json:"categories"`
+	Cuisines      []Term            `db:"-" json:"cuisines"`
+	Tags          []Term            `db:"-" json:"tags"`
+}
+
+// TrashedPost is a soft-deleted post as listed in the admin trash.
+type TrashedPost struct {
 	ID        int       `db:"id" json:"id"`
+	UserID    int       `db:"user_id" json:"user_id"`
+	Username  string    `db:"username" json:"author"`
 	Title     string    `db:"title" json:"title"`
-	Content   string    `db:"content" json:"content"`
-	Image     *string   `db:"image" json:"image"`
+	Slug      *string   `db:"slug" json:"slug"`
+	Status    string    `db:"status" json:"status"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	Username  string    `db:"username" json:"author"`
+	DeletedAt time.Time `db:"deleted_at" json:"deleted_at"`
+}
+
+// PostRequest carries the editable fields of a post. On update, a nil
+// taxonomy slice leaves that assignment unchanged while an empty one clears it,
+// and an empty Status keeps the current one. PublishAt is required when
+// scheduling a post.
+type PostRequest struct {
+	Title       string
+	Content     string
+	Status      string
+	PublishAt   *time.Time
+	CategoryIDs []int
+	CuisineIDs  []int
+	Tags        []string
 }
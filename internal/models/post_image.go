@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// PostImage is one photo in a post's gallery. StepNumber optionally ties the
+// photo to a step of the post's recipe. IsCover reports whether the photo is
+// the post's cover image.
+type PostImage struct {
+	ID         int               `db:"id" json:"id"`
+	PostID     int               `db:"post_id" json:"post_id"`
+	Image      string            `db:"image" json:"image"`
+	Variants   map[string]string `db:"-" json:"variants"`
+	Position   int               `db:"position" json:"position"`
+	Caption    *string           `db:"caption" json:"caption"`
+	AltText    *string           `db:"alt_text" json:"alt_text"`
+	StepNumber *int              `db:"step_number" json:"step_number"`
+	IsCover    bool              `db:"-" json:"is_cover"`
+	CreatedAt  time.Time         `db:"created_at" json:"created_at"`
+}
+
+// PostImageRequest replaces the caption, alt text and recipe step of a
+// gallery image. Omitted fields are cleared.
+type PostImageRequest struct {
+	Caption    *string `json:"caption"`
+	AltText    *string `json:"alt_text"`
+	StepNumber *int    `json:"step_number"`
+}
+
+// ReorderImagesRequest lists every image of a gallery in its new order.
+type ReorderImagesRequest struct {
+	ImageIDs []int `json:"image_ids"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// SocialPlatforms are the sites a profile can link to, in display order.
+var SocialPlatforms = []string{"website", "instagram", "facebook", "x", "youtube", "tiktok"}
+
+// Profile is the public page of an author. PostCount counts their published
+// posts.
+type Profile struct {
+	ID             int               `db:"id" json:"id"`
+	Username       string            `db:"username" json:"username"`
+	DisplayName    *string           `db:"display_name" json:"display_name"`
+	Bio            *string           `db:"bio" json:"bio"`
+	Avatar         *string           `db:"avatar" json:"avatar"`
+	AvatarVariants map[string]string `db:"-" json:"avatar_variants,omitempty"`
+	Location       *string           `db:"location" json:"location"`
+	SocialLinks    []SocialLink      `db:"-" json:"social_links"`
+	PostCount      int               `db:"post_count" json:"post_count"`
+	CreatedAt      time.Time         `db:"created_at" json:"joined_at"`
+}
+
+type SocialLink struct {
+	Platform string `db:"platform" json:"platform"`
+	URL      string `db:"url" json:"url"`
+}
+
+// ProfileRequest replaces the editable fields of the current user's profile.
+// Empty fields are cleared. The avatar is kept unless a new one is uploaded
+// or RemoveAvatar is set.
+type ProfileRequest struct {
+	DisplayName  string
+	Bio          string
+	Location     string
+	SocialLinks  map[string]string
+	RemoveAvatar bool
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type Rating struct {
+	ID        int       `db:"id" json:"id"`
+	PostID    int       `db:"post_id" json:"post_id"`
+	UserID    int       `db:"user_id" json:"user_id"`
+	Username  string    `db:"username" json:"author"`
+	Rating    int       `db:"rating" json:"rating"`
+	Review    *string   `db:"review" json:"review"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type RatingRequest struct {
+	Rating int    `json:"rating"`
+	Review string `json:"review"`
+}
@@ -0,0 +1,103 @@
+package models
+
+import "time"
+
+type Recipe struct {
+	ID          int                `db:"id" json:"id"`
+	PostID      int                `db:"post_id" json:"post_id"`
+	Servings    int                `db:"servings" json:"servings"`
+	PrepTime    int                `db:"prep_time" json:"prep_time"`
+	CookTime    int                `db:"cook_time" json:"cook_time"`
+	TotalTime   int                `db:"total_time" json:"total_time"`
+	Difficulty  string             `db:"difficulty" json:"difficulty"`
+	Ingredients []RecipeIngredient `db:"-" json:"ingredients"`
+	Steps       []RecipeStep       `db:"-" json:"steps"`
+	CreatedAt   time.Time          `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `db:"updated_at" json:"updated_at"`
+}
+
+// RecipeIngredient is one line of a recipe. CanonicalName is the normalized
+// ingredient it refers to (see pkg/ingredient), used for matching recipes.
+type RecipeIngredient struct {
+	ID            int      `db:"id" json:"id"`
+	RecipeID      int      `db:"recipe_id" json:"-"`
+	IngredientID  *int     `db:"ingredient_id" json:"-"`
+	CanonicalName string   `db:"canonical_name" json:"canonical_name"`
+	Position      int      `db:"position" json:"position"`
+	Quantity      *float64 `db:"quantity" json:"quantity"`
+	Unit          *string  `db:"unit" json:"unit"`
+	Name          string   `db:"name" json:"name"`
+	Note          *string  `db:"note" json:"note"`
+}
+
+type RecipeStep struct {
+	ID          int    `db:"id" json:"id"`
+	RecipeID    int    `db:"recipe_id" json:"-"`
+	StepNumber  int    `db:"step_number" json:"step_number"`
+	Instruction string `db:"instruction" json:"instruction"`
+}
+
+// RecipeRequest is the payload for creating or replacing a post's recipe.
+// Prep, cook and total times are expressed in minutes.
+type RecipeRequest struct {
+	Servings    int                       `json:"servings"`
+	PrepTime    int                       `json:"prep_time"`
+	CookTime    int                       `json:"cook_time"`
+	TotalTime   int                       `json:"total_time"`
+	Difficulty  string                    `json:"difficulty"`
+	Ingredients []RecipeIngredientRequest `json:"ingredients"`
+	Steps       []string                  `json:"steps"`
+}
+
+type RecipeIngredientRequest struct {
+	Quantity *float64 `json:"quantity"`
+	Unit     string   `json:"unit"`
+	Name     string   `json:"name"`
+	Note     string   `json:"note"`
+}
+
+// ScaledRecipe is a post's ingredient list rescaled to a number of servings
+// and optionally converted to another measurement system.
+type ScaledRecipe struct {
+	PostID           int                `json:"post_id"`
+	Title            string             `json:"title"`
+	OriginalServings int                `json:"original_servings"`
+	Servings         int                `json:"servings"`
+	Units            string             `json:"units"`
+	Ingredients      []ScaledIngredient `json:"ingredients"`
+	Content          string             `json:"content"`
+}
+
+type ScaledIngredient struct {
+	Original    string   `json:"original"`
+	Quantity    *float64 `json:"quantity"`
+	QuantityMax *float64 `json:"quantity_max,omitempty"`
+	Unit        *string  `json:"unit"`
+	Name        string   `json:"name"`
+	Display     string   `json:"display"`
+}
+
+// RecipeMatchRequest lists what the reader has at hand and what they want to
+// avoid. Page and limit follow the same rules as post listings.
+type RecipeMatchRequest struct {
+	Ingredients []string `json:"ingredients"`
+	Exclude     []string `json:"exclude"`
+	Page        int      `json:"page"`
+	Limit       int      `json:"limit"`
+}
+
+// RecipeMatch is a post whose recipe uses some of the requested ingredients.
+// Coverage is the share of the recipe's (non-staple) ingredients on hand.
+type RecipeMatch struct {
+	RecipeID     int       `db:"recipe_id" json:"-"`
+	PostID       int       `db:"post_id" json:"post_id"`
+	Title        string    `db:"title" json:"title"`
+	Slug         *string   `db:"slug" json:"slug"`
+	Image        *string   `db:"image" json:"image"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	Username     string    `db:"username" json:"author"`
+	MatchedCount int       `db:"matched_count" json:"matched_count"`
+	TotalCount   int       `db:"total_count" json:"total_count"`
+	Coverage     float64   `db:"coverage" json:"coverage"`
+	Missing      []string  `db:"-" json:"missing"`
+}
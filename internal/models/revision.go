@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/pkg/diff"
+)
+
+// PostRevision is an immutable snapshot of a post taken each time it is
+// saved. Revisions are numbered from 1 per post.
+type PostRevision struct {
+	ID        int       `db:"id" json:"-"`
+	PostID    int       `db:"post_id" json:"post_id"`
+	Revision  int       `db:"revision" json:"revision"`
+	EditorID  *int      `db:"editor_id" json:"editor_id"`
+	Editor    *string   `db:"editor" json:"editor"`
+	Title     string    `db:"title" json:"title"`
+	Content   string    `db:"content" json:"content,omitempty"`
+	Image     *string   `db:"image" json:"image"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type RevisionDiff struct {
+	PostID       int         `json:"post_id"`
+	From         int         `json:"from"`
+	To           int         `json:"to"`
+	Title        []diff.Line `json:"title"`
+	Content      []diff.Line `json:"content"`
+	ImageChanged bool        `json:"image_changed"`
+}
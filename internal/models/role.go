@@ -0,0 +1,43 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Permissions granted to roles in the role_permissions table. A user without
+// any of them can still manage their own posts and comments.
+const (
+	PermEditAnyPost      = "posts:edit:any"
+	PermDeleteAnyPost    = "posts:delete:any"
+	PermModerateComments = "comments:moderate"
+	PermManageTaxonomy   = "taxonomy:manage"
+	PermManageTrash      = "trash:manage"
+	PermManageUsers      = "users:manage"
+)
+
+// AdminRole is the role that holds every permission. It cannot be taken away
+// from the last user who has it.
+const AdminRole = "admin"
+
+type Role struct {
+	Name        string  `db:"name" json:"name"`
+	Description *string `db:"description" json:"description"`
+	// RequireTwoFactor makes users with the role set up two-factor
+	// authentication before they can log in.
+	RequireTwoFactor bool      `db:"require_2fa" json:"require_2fa"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	Permissions      []string  `db:"-" json:"permissions"`
+}
+
+type RolePermission struct {
+	Role       string `db:"role"`
+	Permission string `db:"permission"`
+}
+
+// Permissions is what the current user's role allows them to do.
+type Permissions []string
+
+func (p Permissions) Has(permission string) bool {
+	return slices.Contains(p, permission)
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// SearchResult is a post matched by a full-text search. Snippet and
+// TitleHighlight are HTML-escaped with matched terms wrapped in <mark>.
+type SearchResult struct {
+	ID             int       `db:"id" json:"id"`
+	Title          string    `db:"title" json:"title"`
+	Slug           *string   `db:"slug" json:"slug"`
+	Content        string    `db:"content" json:"-"`
+	Image          *string   `db:"image" json:"image"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	Username       string    `db:"username" json:"author"`
+	Score          float64   `db:"score" json:"score"`
+	TitleHighlight string    `db:"-" json:"title_highlight"`
+	Snippet        string    `db:"-" json:"snippet"`
+}
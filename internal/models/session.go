@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Session is a login on one device. It stays alive as long as its refresh
+// tokens are used before they expire and it is not revoked.
+type Session struct {
+	ID         int        `db:"id" json:"id"`
+	UserID     int        `db:"user_id" json:"-"`
+	UserAgent  *string    `db:"user_agent" json:"user_agent"`
+	IPAddress  *string    `db:"ip_address" json:"ip_address"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	LastUsedAt time.Time  `db:"last_used_at" json:"last_used_at"`
+	ExpiresAt  time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"-"`
+	Current    bool       `db:"-" json:"current"`
+}
+
+// RefreshToken is one link in a session's chain of refresh tokens. Only the
+// SHA-256 of the token is stored. UsedAt is set once the token has been
+// exchanged for a new one; presenting it again means it was stolen.
+type RefreshToken struct {
+	ID        int        `db:"id"`
+	SessionID int        `db:"session_id"`
+	TokenHash string     `db:"token_hash"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
+
+// TokenPair is what a login or refresh hands to the client.
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// ClientInfo describes the device a session is used from.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
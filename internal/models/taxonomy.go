@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type Category struct {
+	ID        int        `db:"id" json:"id"`
+	ParentID  *int       `db:"parent_id" json:"parent_id"`
+	Name      string     `db:"name" json:"name"`
+	Slug      string     `db:"slug" json:"slug"`
+	PostCount int        `db:"post_count" json:"post_count"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	Children  []Category `db:"-" json:"children,omitempty"`
+}
+
+// Term is a flat taxonomy entry, used for both cuisines and tags.
+type Term struct {
+	ID        int       `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Slug      string    `db:"slug" json:"slug"`
+	PostCount int       `db:"post_count" json:"post_count"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type CategoryRequest struct {
+	Name     string `json:"name"`
+	ParentID *int   `json:"parent_id"`
+}
+
+type TermRequest struct {
+	Name string `json:"name"`
+}
+
+// PostTaxonomy groups the categories, cuisines and tags assigned to a post.
+type PostTaxonomy struct {
+	Categories []Category
+	Cuisines   []Term
+	Tags       []Term
+}
+
+// PostFilter narrows post listings by taxonomy slug, status and author. Empty
+// fields are ignored.
+type PostFilter struct {
+	Category string
+	Cuisine  string
+	Tag      string
+	Status   string
+	AuthorID int
+}
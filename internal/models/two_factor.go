@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// TwoFactor is a user's TOTP enrollment. Secret is set once they start
+// enrolling and EnabledAt once they confirm it with a first code. LastStep is
+// the time step of the last code accepted, so a code cannot be used twice.
+type TwoFactor struct {
+	UserID    int        `db:"id"`
+	Secret    *string    `db:"totp_secret"`
+	EnabledAt *time.Time `db:"totp_enabled_at"`
+	LastStep  *int64     `db:"totp_last_step"`
+}
+
+// RecoveryCode stands in for a TOTP code once, for users who lost their
+// authenticator. Only the SHA-256 of the code is stored.
+type RecoveryCode struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	CodeHash  string     `db:"code_hash"`
+	CreatedAt time.Time  `db:"created_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
+
+// TwoFactorSetup is what a user adds to their authenticator app, either by
+// typing the secret or by scanning the URI as a QR code.
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TwoFactorStatus struct {
+	Enabled bool `json:"enabled"`
+	// Required is set when the user's role requires two-factor
+	// authentication.
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// RecoveryCodes are shown once, when they are generated.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// ConfirmTwoFactorRequest guards disabling two-factor authentication and
+// regenerating recovery codes, which need both the password and a code.
+type ConfirmTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// LoginChallenge is returned by a login with the right password when a second
+// step is needed. SetupRequired is set when the user's role requires
+// two-factor authentication and they have not enrolled yet: they set it up
+// with the challenge token before the login completes.
+type LoginChallenge struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	SetupRequired     bool      `json:"setup_required"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+type ChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+// TwoFactorLoginRequest completes a login. Code is a TOTP code or a recovery
+// code.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+// LoginResult holds either the tokens of a completed login or the challenge
+// for its second step. RecoveryCodes is set when the user enrolled in
+// two-factor authentication while logging in.
+type LoginResult struct {
+	Tokens        *TokenPair
+	Challenge     *LoginChallenge
+	RecoveryCodes []string
+}
+
+// TwoFactorLogin is what the second step of a login hands to the client.
+type TwoFactorLogin struct {
+	*TokenPair
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+type TwoFactorPolicyRequest struct {
+	Required bool `json:"required"`
+}
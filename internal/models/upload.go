@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// ImageReference is a stored image file named by a database row. Source is
+// "post" for a post's cover, "revision", "gallery" or "avatar"; OwnerID is
+// the post's ID, or the user's for an avatar.
+type ImageReference struct {
+	Source  string `db:"source" json:"source"`
+	OwnerID int    `db:"owner_id" json:"owner_id"`
+	Image   string `db:"image" json:"image"`
+}
+
+// OrphanedUpload is a stored file that no database row refers to.
+type OrphanedUpload struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modified_at"`
+}
+
+// UploadReport is the result of reconciling stored files with the database.
+// Recent counts unreferenced files still inside the grace period, which may
+// belong to an upload that is being saved.
+type UploadReport struct {
+	Scanned int              `json:"scanned"`
+	Recent  int              `json:"recent"`
+	Orphans []OrphanedUpload `json:"orphans"`
+	Deleted int              `json:"deleted"`
+	Missing []ImageReference `json:"missing"`
+}
@@ -3,11 +3,17 @@ package models
 import "time"
 
 type User struct {
-	ID        int       `db:"id" json:"id"`
-	Username  string    `db:"username" json:"username"`
-	Password  string    `db:"password" json:"-"`
-	Role      string    `db:"role" json:"role"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	ID        int        `db:"id" json:"id"`
+	Username  string     `db:"username" json:"username"`
+	Password  string     `db:"password" json:"-"`
+	Role      string     `db:"role" json:"role"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	// Email, EmailVerifiedAt and TwoFactorEnabled are only read by GetByID,
+	// GetByUsername and GetByEmail.
+	Email            *string    `db:"email" json:"-"`
+	EmailVerifiedAt  *time.Time `db:"email_verified_at" json:"-"`
+	TwoFactorEnabled bool       `db:"two_factor_enabled" json:"-"`
 }
 
 type UserStats struct {
@@ -22,6 +28,7 @@ type UpdateRoleRequest struct {
 
 type RegisterRequest struct {
 	Username        string `json:"username"`
+	Email           string `json:"email"`
 	Password        string `json:"password"`
 	ConfirmPassword string `json:"confirm_password"`
 }
@@ -30,3 +37,42 @@ type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 }
+
+// Account is the current user's own view of their account.
+type Account struct {
+	Profile
+	Role             string  `json:"role"`
+	Email            *string `json:"email"`
+	EmailVerified    bool    `json:"email_verified"`
+	HasPassword      bool    `json:"has_password"`
+	TwoFactorEnabled bool    `json:"two_factor_enabled"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword     string `json:"old_password"`
+	NewPassword     string `json:"new_password"`
+	ConfirmPassword string `json:"confirm_password"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// AccountExport holds everything stored about a user, for data requests.
+// Images lists every image file of the account, including those only kept
+// by post revisions.
+type AccountExport struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	Account    Account         `json:"account"`
+	Posts      []PostDetail    `json:"posts"`
+	Comments   []Comment       `json:"comments"`
+	Ratings    []Rating        `json:"ratings"`
+	Images     []ExportedImage `json:"images"`
+}
+
+// ExportedImage is an image file in an account export. File is its path in
+// the ZIP export.
+type ExportedImage struct {
+	File string `json:"file"`
+	URL  string `json:"url"`
+}
@@ -0,0 +1,186 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refetchInterval limits how often an unknown key ID makes the key set be
+// fetched again, so tokens with made-up key IDs cannot flood the provider.
+const refetchInterval = time.Minute
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type publicKey struct {
+	key any
+	alg string
+}
+
+// keySet caches a provider's signing keys by key ID. Providers rotate their
+// keys, so a key ID that is not in the cache fetches the set again.
+type keySet struct {
+	uri   string
+	fetch func(ctx context.Context, target string, v any) error
+
+	mu        sync.Mutex
+	keys      map[string]publicKey
+	fetchedAt time.Time
+}
+
+func newKeySet(uri string, fetch func(ctx context.Context, target string, v any) error) *keySet {
+	return &keySet{uri: uri, fetch: fetch}
+}
+
+// get returns the key that signed a token with the given key ID and
+// algorithm. A token without a key ID can only be checked when the provider
+// publishes a single key.
+func (s *keySet) get(ctx context.Context, kid string, alg string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.lookup(kid)
+	if !ok && time.Since(s.fetchedAt) >= refetchInterval {
+		if err := s.refresh(ctx); err != nil {
+			return nil, err
+		}
+		key, ok = s.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+	}
+
+	if key.alg != "" && key.alg != alg {
+		return nil, fmt.Errorf("oidc: signing key %q is not for %s", kid, alg)
+	}
+	if !keyMatchesAlg(key.key, alg) {
+		return nil, fmt.Errorf("oidc: signing key %q cannot verify %s", kid, alg)
+	}
+	return key.key, nil
+}
+
+func (s *keySet) lookup(kid string) (publicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *keySet) refresh(ctx context.Context) error {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := s.fetch(ctx, s.uri, &set); err != nil {
+		return fmt.Errorf("oidc: failed to fetch signing keys: %w", err)
+	}
+
+	keys := make(map[string]publicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of types this package does not use are skipped rather than
+		// failing the whole set.
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = publicKey{key: key, alg: jwk.Alg}
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("oidc: invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("oidc: invalid EC key")
+		}
+
+		// The uncompressed point encoding is 0x04 followed by X and Y.
+		point := append([]byte{4}, x...)
+		point = append(point, y...)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+
+	default:
+		return nil, fmt.Errorf("oidc: unsupported key type %q", k.Kty)
+	}
+}
+
+func keyMatchesAlg(key any, alg string) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		return strings.HasPrefix(alg, "ES")
+	default:
+		return false
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(raw) == 0 {
+		return nil, errors.New("oidc: invalid key parameter")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
@@ -0,0 +1,281 @@
+// Package oidc logs users in with an OpenID Connect provider. It implements
+// the authorization code flow with PKCE: the provider's endpoints come from
+// its discovery document, and the ID tokens it returns are checked against
+// the keys it publishes as a JWKS.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Config describes a provider registered with this site.
+type Config struct {
+	// Name identifies the provider in URLs, e.g. "google".
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback the provider sends users back to. It must
+	// be registered with the provider.
+	RedirectURL string
+	// Scopes defaults to openid, email and profile.
+	Scopes []string
+}
+
+// Claims are what this site uses from an ID token.
+type Claims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// maxResponseSize caps what is read from the provider.
+const maxResponseSize = 1 << 20
+
+// clockSkew is how far the provider's clock may be off from ours.
+const clockSkew = time.Minute
+
+// signingMethods are the ID token algorithms accepted. Symmetric algorithms
+// are left out, since those would be signed with the client secret.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+type metadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+}
+
+// Provider talks to one OpenID Connect provider. Its discovery document is
+// fetched on first use, so a provider that is down does not keep the API from
+// starting.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     *keySet
+}
+
+// New creates a provider. client is used for every request to it.
+func New(cfg Config, client *http.Client) *Provider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{cfg: cfg, client: client}
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// discover fetches the discovery document once it is first needed and keeps
+// it. A failed fetch is retried on the next use.
+func (p *Provider) discover(ctx context.Context) (*metadata, *keySet, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, p.keys, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	var meta metadata
+	if err := p.getJSON(ctx, wellKnown, &meta); err != nil {
+		return nil, nil, fmt.Errorf("oidc: discovery for %s failed: %w", p.cfg.Name, err)
+	}
+
+	if strings.TrimSuffix(meta.Issuer, "/") != strings.TrimSuffix(p.cfg.Issuer, "/") {
+		return nil, nil, fmt.Errorf("oidc: discovery for %s returned issuer %q", p.cfg.Name, meta.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, nil, fmt.Errorf("oidc: discovery for %s is missing an endpoint", p.cfg.Name)
+	}
+	if len(meta.CodeChallengeMethodsSupported) > 0 && !contains(meta.CodeChallengeMethodsSupported, "S256") {
+		return nil, nil, fmt.Errorf("oidc: %s does not support PKCE with S256", p.cfg.Name)
+	}
+
+	p.metadata = &meta
+	p.keys = newKeySet(meta.JWKSURI, p.getJSON)
+	return p.metadata, p.keys, nil
+}
+
+// AuthCodeURL returns the provider's login page for a new login. state and
+// nonce tie the callback to this login, and verifier is the PKCE secret that
+// Exchange has to present.
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error) {
+	meta, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("oidc: invalid authorization endpoint: %w", err)
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// Exchange trades the code from the callback for an ID token.
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string) (string, error) {
+	meta, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oidc: token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&token); err != nil {
+		return "", fmt.Errorf("oidc: invalid token response (status %d): %w", resp.StatusCode, err)
+	}
+	if token.Error != "" {
+		return "", fmt.Errorf("oidc: token request rejected: %s %s", token.Error, token.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK || token.IDToken == "" {
+		return "", fmt.Errorf("oidc: token response without an ID token (status %d)", resp.StatusCode)
+	}
+
+	return token.IDToken, nil
+}
+
+// VerifyIDToken checks an ID token's signature against the provider's keys,
+// its issuer, audience and expiry, and that it carries the nonce of this
+// login.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw string, nonce string) (*Claims, error) {
+	meta, keys, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse(raw, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return keys.get(ctx, kid, t.Method.Alg())
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid ID token: %w", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("oidc: invalid ID token claims")
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return nil, errors.New("oidc: ID token nonce does not match")
+	}
+
+	// A token meant for several clients must name this one as the party it
+	// was issued to.
+	if audiences, _ := claims.GetAudience(); len(audiences) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.cfg.ClientID {
+			return nil, errors.New("oidc: ID token was issued to another client")
+		}
+	}
+
+	result := &Claims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.Name, _ = claims["name"].(string)
+	result.PreferredUsername, _ = claims["preferred_username"].(string)
+
+	// Some providers send email_verified as a string.
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = verified == "true"
+	}
+
+	if result.Subject == "" {
+		return nil, errors.New("oidc: ID token has no subject")
+	}
+	return result, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, target string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,420 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "blog-client"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "https://blog.example/v1/auth/oidc/test/callback"
+)
+
+// testProvider is an OpenID Connect provider served by httptest. It publishes
+// the public half of its keys, and its token endpoint only hands out idToken
+// when the code verifier matches the challenge the code was issued for.
+type testProvider struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu          sync.Mutex
+	keys        map[string]any
+	jwksFetches int
+	challenges  map[string]string
+	idToken     string
+	discovery   map[string]any
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	p := &testProvider{t: t, keys: map[string]any{}, challenges: map[string]string{}}
+	p.addRSAKey("key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.serveDiscovery)
+	mux.HandleFunc("GET /jwks", p.serveJWKS)
+	mux.HandleFunc("POST /token", p.serveToken)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	p.discovery = map[string]any{
+		"issuer":                           p.server.URL,
+		"authorization_endpoint":           p.server.URL + "/authorize",
+		"token_endpoint":                   p.server.URL + "/token",
+		"jwks_uri":                         p.server.URL + "/jwks",
+		"code_challenge_methods_supported": []string{"plain", "S256"},
+	}
+	return p
+}
+
+func (p *testProvider) client() *Provider {
+	return New(Config{
+		Name:         "test",
+		Issuer:       p.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+	}, p.server.Client())
+}
+
+func (p *testProvider) addRSAKey(kid string) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	p.mu.Lock()
+	p.keys[kid] = key
+	p.mu.Unlock()
+	return key
+}
+
+func (p *testProvider) addECKey(kid string) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	p.mu.Lock()
+	p.keys[kid] = key
+	p.mu.Unlock()
+	return key
+}
+
+func (p *testProvider) fetches() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.jwksFetches
+}
+
+func (p *testProvider) serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	json.NewEncoder(w).Encode(p.discovery)
+}
+
+func (p *testProvider) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.jwksFetches++
+
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	keys := []map[string]string{
+		// Encryption keys are not used for ID tokens.
+		{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}
+	for kid, key := range p.keys {
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+				"n": encode(key.N.Bytes()), "e": encode(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case *ecdsa.PrivateKey:
+			keys = append(keys, map[string]string{
+				"kty": "EC", "kid": kid, "crv": "P-256",
+				"x": encode(key.X.FillBytes(make([]byte, 32))), "y": encode(key.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+}
+
+// authorize stands in for the user logging in at the provider: it issues a
+// code for the challenge in an authorization URL.
+func (p *testProvider) authorize(authURL string) string {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	code := "code-" + parsed.Query().Get("state")
+	p.challenges[code] = parsed.Query().Get("code_challenge")
+	return code
+}
+
+func (p *testProvider) serveToken(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	tokenError := func(code string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+
+	clientID, secret, ok := r.BasicAuth()
+	if !ok || clientID != testClientID || secret != testClientSecret {
+		tokenError("invalid_client")
+		return
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("redirect_uri") != testRedirectURL {
+		tokenError("invalid_request")
+		return
+	}
+
+	challenge, ok := p.challenges[r.PostFormValue("code")]
+	if !ok || CodeChallenge(r.PostFormValue("code_verifier")) != challenge {
+		tokenError("invalid_grant")
+		return
+	}
+	delete(p.challenges, r.PostFormValue("code"))
+
+	json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "token_type": "Bearer", "id_token": p.idToken})
+}
+
+// claims returns valid ID token claims for the nonce.
+func (p *testProvider) claims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            p.server.URL,
+		"aud":            testClientID,
+		"sub":            "user-123",
+		"email":          "cook@example.com",
+		"email_verified": true,
+		"name":           "Cook",
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+}
+
+// sign signs claims with the provider's key kid.
+func (p *testProvider) sign(claims jwt.MapClaims, kid string) string {
+	p.mu.Lock()
+	key := p.keys[kid]
+	p.mu.Unlock()
+
+	method := jwt.SigningMethod(jwt.SigningMethodRS256)
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		method = jwt.SigningMethodES256
+	}
+	return signWith(p.t, method, claims, kid, key)
+}
+
+func signWith(t *testing.T, method jwt.SigningMethod, claims jwt.MapClaims, kid string, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// The example from RFC 7636, appendix B.
+	if got := CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("CodeChallenge = %q", got)
+	}
+
+	a, err := RandomString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := RandomString()
+	if len(a) != 43 || a == b {
+		t.Errorf("RandomString returned %q and %q", a, b)
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	p := newTestProvider(t)
+
+	authURL, err := p.client().AuthCodeURL(context.Background(), "the-state", "the-nonce", "the-verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.Scheme + "://" + parsed.Host + parsed.Path; got != p.server.URL+"/authorize" {
+		t.Errorf("authorization endpoint = %q", got)
+	}
+
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "the-state",
+		"nonce":                 "the-nonce",
+		"code_challenge":        CodeChallenge("the-verifier"),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := parsed.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if parsed.Query().Has("code_verifier") {
+		t.Error("the verifier leaked into the authorization URL")
+	}
+}
+
+func TestExchange(t *testing.T) {
+	p := newTestProvider(t)
+	p.idToken = "the-id-token"
+	provider := p.client()
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "s1", "n1", "right-verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The provider only accepts the verifier whose challenge was sent.
+	if _, err := provider.Exchange(ctx, p.authorize(authURL), "wrong-verifier"); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("Exchange with the wrong verifier error = %v, want invalid_grant", err)
+	}
+
+	idToken, err := provider.Exchange(ctx, p.authorize(authURL), "right-verifier")
+	if err != nil || idToken != "the-id-token" {
+		t.Errorf("Exchange = %q, %v", idToken, err)
+	}
+
+	// Codes are single use.
+	if _, err := provider.Exchange(ctx, "code-s1", "right-verifier"); err == nil {
+		t.Error("Exchange accepted a used code")
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	p := newTestProvider(t)
+	p.addECKey("ec-1")
+	stranger, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	with := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := p.claims("n1")
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+			} else {
+				claims[name] = value
+			}
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", p.sign(p.claims("n1"), "key-1"), true},
+		{"valid with an EC key", p.sign(p.claims("n1"), "ec-1"), true},
+		{"several audiences naming this client", p.sign(with(jwt.MapClaims{"aud": []string{testClientID, "other"}, "azp": testClientID}), "key-1"), true},
+		{"nonce mismatch", p.sign(p.claims("someone-else"), "key-1"), false},
+		{"no nonce", p.sign(with(jwt.MapClaims{"nonce": nil}), "key-1"), false},
+		{"wrong audience", p.sign(with(jwt.MapClaims{"aud": "another-client"}), "key-1"), false},
+		{"several audiences for another party", p.sign(with(jwt.MapClaims{"aud": []string{testClientID, "other"}, "azp": "other"}), "key-1"), false},
+		{"wrong issuer", p.sign(with(jwt.MapClaims{"iss": "https://evil.example"}), "key-1"), false},
+		{"expired", p.sign(with(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}), "key-1"), false},
+		{"no expiry", p.sign(with(jwt.MapClaims{"exp": nil}), "key-1"), false},
+		{"no subject", p.sign(with(jwt.MapClaims{"sub": nil}), "key-1"), false},
+		{"HS256 with the client secret", signWith(t, jwt.SigningMethodHS256, p.claims("n1"), "key-1", []byte(testClientSecret)), false},
+		{"alg none", signWith(t, jwt.SigningMethodNone, p.claims("n1"), "key-1", jwt.UnsafeAllowNoneSignatureType), false},
+		{"signed by another key", signWith(t, jwt.SigningMethodRS256, p.claims("n1"), "key-1", stranger), false},
+		{"RSA key used as EC", signWith(t, jwt.SigningMethodES256, p.claims("n1"), "key-1", p.keys["ec-1"]), false},
+		{"encryption key", signWith(t, jwt.SigningMethodRS256, p.claims("n1"), "enc-1", stranger), false},
+		{"no key ID with several keys", signWith(t, jwt.SigningMethodRS256, p.claims("n1"), "", p.keys["key-1"]), false},
+	}
+
+	provider := p.client()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := provider.VerifyIDToken(context.Background(), tt.token, "n1")
+			if tt.ok != (err == nil) {
+				t.Fatalf("VerifyIDToken error = %v, want ok = %v", err, tt.ok)
+			}
+			if tt.ok && (claims.Subject != "user-123" || claims.Email != "cook@example.com" || !claims.EmailVerified || claims.Name != "Cook") {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenRefetchesKeys(t *testing.T) {
+	p := newTestProvider(t)
+	provider := p.client()
+	ctx := context.Background()
+
+	if _, err := provider.VerifyIDToken(ctx, p.sign(p.claims("n1"), "key-1"), "n1"); err != nil {
+		t.Fatal(err)
+	}
+	if p.fetches() != 1 {
+		t.Fatalf("keys fetched %d times, want 1", p.fetches())
+	}
+
+	// The provider rotates to a new key. Right after a fetch, an unknown key
+	// ID does not fetch again.
+	p.addRSAKey("key-2")
+	rotated := p.sign(p.claims("n1"), "key-2")
+	if _, err := provider.VerifyIDToken(ctx, rotated, "n1"); err == nil {
+		t.Error("a key published after the last fetch was accepted without fetching")
+	}
+	if p.fetches() != 1 {
+		t.Errorf("keys fetched %d times within the refetch interval, want 1", p.fetches())
+	}
+
+	// Once the interval has passed, the new key ID fetches the keys again.
+	provider.keys.fetchedAt = time.Now().Add(-refetchInterval)
+	if _, err := provider.VerifyIDToken(ctx, rotated, "n1"); err != nil {
+		t.Errorf("rotated key rejected: %v", err)
+	}
+	if p.fetches() != 2 {
+		t.Errorf("keys fetched %d times, want 2", p.fetches())
+	}
+
+	// Known keys are served from the cache.
+	if _, err := provider.VerifyIDToken(ctx, p.sign(p.claims("n1"), "key-1"), "n1"); err != nil {
+		t.Error(err)
+	}
+	if p.fetches() != 2 {
+		t.Errorf("keys fetched %d times for a known key, want 2", p.fetches())
+	}
+}
+
+func TestDiscoveryRejectsBadProviders(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(discovery map[string]any)
+	}{
+		{"issuer mismatch", func(d map[string]any) { d["issuer"] = "https://evil.example" }},
+		{"no PKCE with S256", func(d map[string]any) { d["code_challenge_methods_supported"] = []string{"plain"} }},
+		{"no token endpoint", func(d map[string]any) { delete(d, "token_endpoint") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvider(t)
+			tt.change(p.discovery)
+
+			if _, err := p.client().AuthCodeURL(context.Background(), "s", "n", "v"); err == nil {
+				t.Error("AuthCodeURL succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// RandomString returns 32 random bytes encoded for use in a URL. It is used
+// for the state, the nonce and the PKCE verifier of a login.
+func RandomString() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// CodeChallenge returns the S256 PKCE challenge for a verifier, as described
+// in RFC 7636.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByUser(ctx context.Context, userID int) ([]models.APIKey, error)
+	CountByUser(ctx context.Context, userID int) (int, error)
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	Revoke(ctx context.Context, userID int, id int) error
+	Touch(ctx context.Context, id int) error
+}
+
+type apiKeyRepository struct {
+	db *config.Database
+}
+
+func NewAPIKeyRepository(db *config.Database) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	query := `INSERT INTO api_keys(user_id, name, prefix, key_hash, scopes, created_at, expires_at)
+			  VALUES(:user_id, :name, :prefix, :key_hash, :scopes, NOW(), :expires_at)`
+	result, err := r.db.Write.NamedExecContext(ctx, query, key)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to save API key into database", map[string]interface{}{
+			"user_id": key.UserID,
+		})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new API key id")
+	}
+	key.ID = int(id)
+	return nil
+}
+
+// GetByUser lists a user's keys that have not been revoked, newest first.
+// Expired keys are included so their owner can see why they stopped working.
+func (r *apiKeyRepository) GetByUser(ctx context.Context, userID int) ([]models.APIKey, error) {
+	keys := make([]models.APIKey, 0)
+	query := `SELECT id, user_id, name, prefix, scopes, created_at, last_used_at, expires_at
+			  FROM api_keys WHERE user_id = ? AND revoked_at IS NULL
+			  ORDER BY created_at DESC, id DESC`
+
+	if err := r.db.Read.SelectContext(ctx, &keys, query, userID); err != nil {
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve API keys", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) CountByUser(ctx context.Context, userID int) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM api_keys WHERE user_id = ? AND revoked_at IS NULL`
+
+	if err := r.db.Read.GetContext(ctx, &count, query, userID); err != nil {
+		return 0, logger.LogErrorWithFields(err, "Failed to count API keys", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	return count, nil
+}
+
+// GetByHash finds a key together with its owner's role. Keys of deleted
+// users are not found.
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	query := `SELECT api_keys.id, api_keys.user_id, api_keys.name, api_keys.prefix, api_keys.scopes,
+				api_keys.created_at, api_keys.last_used_at, api_keys.expires_at, api_keys.revoked_at, users.role
+			  FROM api_keys
+			  JOIN users ON users.id = api_keys.user_id AND users.deleted_at IS NULL
+			  WHERE api_keys.key_hash = ?`
+
+	if err := r.db.Read.GetContext(ctx, &key, query, keyHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("API key not found")
+		}
+		return nil, logger.LogError(err, "Failed to retrieve API key")
+	}
+
+	return &key, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, userID int, id int) error {
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = ? AND user_id = ? AND revoked_at IS NULL`
+
+	result, err := r.db.Write.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to revoke API key", map[string]interface{}{
+			"api_key_id": id,
+			"user_id":    userID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("API key not found")
+	}
+	return nil
+}
+
+// Touch records that a key was used. It writes at most once a minute per key
+// so a busy script does not turn every request into a write.
+func (r *apiKeyRepository) Touch(ctx context.Context, id int) error {
+	query := `UPDATE api_keys SET last_used_at = NOW()
+			  WHERE id = ? AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL 1 MINUTE)`
+	if _, err := r.db.Write.ExecContext(ctx, query, id); err != nil {
+		return logger.LogErrorWithFields(err, "failed to record API key use", map[string]interface{}{
+			"api_key_id": id,
+		})
+	}
+	return nil
+}
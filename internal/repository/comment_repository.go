@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *models.Comment) error
+	GetByID(ctx context.Context, id int) (*models.Comment, error)
+	GetByPostID(ctx context.Context, postID int) ([]models.Comment, error)
+	GetByStatus(ctx context.Context, status string, limit int, offset int) ([]models.Comment, int, error)
+	GetByUserID(ctx context.Context, userID int) ([]models.Comment, error)
+	UpdateContent(ctx context.Context, id int, content string, window time.Duration) error
+	UpdateStatus(ctx context.Context, id int, status string) error
+	SoftDelete(ctx context.Context, id int) error
+}
+
+type commentRepository struct {
+	db *config.Database
+}
+
+func NewCommentRepository(db *config.Database) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+const commentColumns = `
+		SELECT comments.id, comments.post_id, comments.user_id, comments.parent_id, users.username,
+			comments.content, comments.status, comments.created_at, comments.edited_at, comments.deleted_at
+		FROM comments
+		JOIN users ON users.id = comments.user_id`
+
+func (r *commentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	query := `INSERT INTO comments(post_id, user_id, parent_id, content, status, created_at)
+			  VALUES(:post_id, :user_id, :parent_id, :content, :status, NOW())`
+	result, err := r.db.Write.NamedExecContext(ctx, query, comment)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to save comment into database", map[string]interface{}{
+			"post_id": comment.PostID,
+			"user_id": comment.UserID,
+		})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new comment id")
+	}
+	comment.ID = int(id)
+	return nil
+}
+
+func (r *commentRepository) GetByID(ctx context.Context, id int) (*models.Comment, error) {
+	var comment models.Comment
+	query := commentColumns + ` WHERE comments.id = ?`
+
+	if err := r.db.Read.GetContext(ctx, &comment, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("comment not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve comment", map[string]interface{}{
+			"comment_id": id,
+		})
+	}
+
+	return &comment, nil
+}
+
+// GetByPostID returns every comment on a post, oldest first, whatever its
+// status. Deciding what readers get to see is left to the caller.
+func (r *commentRepository) GetByPostID(ctx context.Context, postID int) ([]models.Comment, error) {
+	comments := make([]models.Comment, 0)
+	query := commentColumns + `
+		WHERE comments.post_id = ?
+		ORDER BY comments.created_at ASC, comments.id ASC`
+
+	if err := r.db.Read.SelectContext(ctx, &comments, query, postID); err != nil {
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve comments", map[string]interface{}{
+			"post_id": postID,
+		})
+	}
+
+	return comments, nil
+}
+
+// GetByStatus lists comments that are not deleted and have the given status,
+// oldest first, for the moderation queue.
+func (r *commentRepository) GetByStatus(ctx context.Context, status string, limit int, offset int) ([]models.Comment, int, error) {
+	comments := make([]models.Comment, 0)
+
+	var total int
+	countQuery := `
+		SELECT COUNT(*) FROM comments
+		JOIN posts ON posts.id = comments.post_id
+		WHERE comments.status = ? AND comments.deleted_at IS NULL AND posts.deleted_at IS NULL`
+	if err := r.db.Read.GetContext(ctx, &total, countQuery, status); err != nil {
+		return nil, 0, logger.LogErrorWithFields(err, "Failed to count comments", map[string]interface{}{
+			"status": status,
+		})
+	}
+
+	if total == 0 {
+		return comments, 0, nil
+	}
+
+	query := `
+		SELECT comments.id, comments.post_id, posts.title AS post_title, comments.user_id, comments.parent_id,
+			users.username, comments.content, comments.status, comments.created_at, comments.edited_at,
+			comments.deleted_at
+		FROM comments
+		JOIN users ON users.id = comments.user_id
+		JOIN posts ON posts.id = comments.post_id
+		WHERE comments.status = ? AND comments.deleted_at IS NULL AND posts.deleted_at IS NULL
+		ORDER BY comments.created_at ASC, comments.id ASC
+		LIMIT ? OFFSET ?`
+
+	if err := r.db.Read.SelectContext(ctx, &comments, query, status, limit, offset); err != nil {
+		return nil, 0, logger.LogErrorWithFields(err, "Failed to retrieve comments", map[string]interface{}{
+			"status": status,
+		})
+	}
+
+	return comments, total, nil
+}
+
+// GetByUserID returns every comment a user has not deleted, oldest first,
+// with the title of the post it is on.
+func (r *commentRepository) GetByUserID(ctx context.Context, userID int) ([]models.Comment, error) {
+	comments := make([]models.Comment, 0)
+	query := `
+		SELECT comments.id, comments.post_id, posts.title AS post_title, comments.user_id, comments.parent_id,
+			users.username, comments.content, comments.status, comments.created_at, comments.edited_at,
+			comments.deleted_at
+		FROM comments
+		JOIN users ON users.id = comments.user_id
+		JOIN posts ON posts.id = comments.post_id
+		WHERE comments.user_id = ? AND comments.deleted_at IS NULL
+		ORDER BY comments.created_at ASC, comments.id ASC`
+
+	if err := r.db.Read.SelectContext(ctx, &comments, query, userID); err != nil {
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve user comments", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	return comments, nil
+}
+
+// UpdateContent edits a comment as long as it was posted less than window
+// ago. The age is checked by the database so it uses the same clock that
+// stamped created_at.
+func (r *commentRepository) UpdateContent(ctx context.Context, id int, content string, window time.Duration) error {
+	query := `UPDATE comments SET content = ?, edited_at = NOW()
+			  WHERE id = ? AND deleted_at IS NULL AND created_at >= NOW() - INTERVAL ? SECOND`
+	result, err := r.db.Write.ExecContext(ctx, query, content, id, int(window.Seconds()))
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to update comment in database", map[string]interface{}{
+			"comment_id": id,
+		})
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return logger.LogError(err, "failed to read updated comment count")
+	}
+	if affected == 0 {
+		return logger.ValidationError("this comment can no longer be edited")
+	}
+	return nil
+}
+
+func (r *commentRepository) UpdateStatus(ctx context.Context, id int, status string) error {
+	if _, err := r.db.Write.ExecContext(ctx, `UPDATE comments SET status = ? WHERE id = ?`, status, id); err != nil {
+		return logger.LogErrorWithFields(err, "failed to update comment status", map[string]interface{}{
+			"comment_id": id,
+			"status":     status,
+		})
+	}
+	return nil
+}
+
+// SoftDelete marks a comment as deleted but keeps the row so its replies stay
+// attached to the thread.
+func (r *commentRepository) SoftDelete(ctx context.Context, id int) error {
+	query := `UPDATE comments SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL`
+	if _, err := r.db.Write.ExecContext(ctx, query, id); err != nil {
+		return logger.LogErrorWithFields(err, "Failed to delete comment from database", map[string]interface{}{
+			"comment_id": id,
+		})
+	}
+	return nil
+}
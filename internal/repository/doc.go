@@ -0,0 +1,6 @@
+// Package repository reads and writes the database.
+//
+// Every timestamp is stored in UTC. The connection pins both the driver and
+// the session time zone to UTC (see config.InitDB), so NOW() in a query and a
+// time.Time passed from Go mean the same instant and either may be used.
+package repository
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type EmailTokenRepository interface {
+	Create(ctx context.Context, token *models.EmailToken) error
+	Consume(ctx context.Context, tokenHash string, purpose string) (*models.EmailToken, error)
+	InvalidateForUser(ctx context.Context, userID int, purpose string) error
+	CountSince(ctx context.Context, userID int, purpose string, since time.Time) (int, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int, error)
+}
+
+type emailTokenRepository struct {
+	db *config.Database
+}
+
+func NewEmailTokenRepository(db *config.Database) EmailTokenRepository {
+	return &emailTokenRepository{db: db}
+}
+
+// Create saves a token.
+func (r *emailTokenRepository) Create(ctx context.Context, token *models.EmailToken) error {
+	token.CreatedAt = time.Now()
+	query := `INSERT INTO email_tokens(user_id, purpose, email, token_hash, created_at, expires_at)
+			  VALUES(:user_id, :purpose, :email, :token_hash, :created_at, :expires_at)`
+	if _, err := r.db.Write.NamedExecContext(ctx, query, token); err != nil {
+		return logger.LogErrorWithFields(err, "failed to save email token", map[string]interface{}{
+			"user_id": token.UserID,
+			"purpose": token.Purpose,
+		})
+	}
+	return nil
+}
+
+// Consume marks an unused, unexpired token as used and returns it. Marking
+// it in the same statement that checks it keeps two requests from both using
+// the token.
+func (r *emailTokenRepository) Consume(ctx context.Context, tokenHash string, purpose string) (*models.EmailToken, error) {
+	now := time.Now()
+	query := `UPDATE email_tokens SET used_at = ?
+			  WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?`
+
+	result, err := r.db.Write.ExecContext(ctx, query, now, tokenHash, purpose, now)
+	if err != nil {
+		return nil, logger.LogError(err, "failed to use email token")
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return nil, logger.ValidationError("email token not found")
+	}
+
+	var token models.EmailToken
+	query = `SELECT id, user_id, purpose, email, token_hash, created_at, expires_at, used_at
+			 FROM email_tokens WHERE token_hash = ?`
+	if err := r.db.Write.GetContext(ctx, &token, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("email token not found")
+		}
+		return nil, logger.LogError(err, "failed to retrieve email token")
+	}
+	return &token, nil
+}
+
+// InvalidateForUser marks a user's unused tokens for a purpose as used.
+func (r *emailTokenRepository) InvalidateForUser(ctx context.Context, userID int, purpose string) error {
+	query := `UPDATE email_tokens SET used_at = ? WHERE user_id = ? AND purpose = ? AND used_at IS NULL`
+	if _, err := r.db.Write.ExecContext(ctx, query, time.Now(), userID, purpose); err != nil {
+		return logger.LogErrorWithFields(err, "failed to invalidate email tokens", map[string]interface{}{
+			"user_id": userID,
+			"purpose": purpose,
+		})
+	}
+	return nil
+}
+
+// CountSince returns how many tokens for a purpose a user was sent since the
+// given time.
+func (r *emailTokenRepository) CountSince(ctx context.Context, userID int, purpose string, since time.Time) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM email_tokens WHERE user_id = ? AND purpose = ? AND created_at >= ?`
+	if err := r.db.Write.GetContext(ctx, &count, query, userID, purpose, since); err != nil {
+		return 0, logger.LogError(err, "failed to count email tokens")
+	}
+	return count, nil
+}
+
+// DeleteExpired deletes tokens that expired before the given time, used or
+// not.
+func (r *emailTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.Write.ExecContext(ctx, `DELETE FROM email_tokens WHERE expires_at < ?`, before)
+	if err != nil {
+		return 0, logger.LogError(err, "failed to delete expired email tokens")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, logger.LogError(err, "failed to read deleted email token count")
+	}
+	return int(rows), nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type IdentityRepository interface {
+	GetByProviderSubject(ctx context.Context, provider string, subject string) (*models.UserIdentity, error)
+	GetByUser(ctx context.Context, userID int) ([]models.UserIdentity, error)
+	Create(ctx context.Context, identity *models.UserIdentity) error
+	CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) error
+	Delete(ctx context.Context, userID int, identityID int) error
+	Touch(ctx context.Context, identityID int) error
+}
+
+type identityRepository struct {
+	db *config.Database
+}
+
+func NewIdentityRepository(db *config.Database) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+// GetByProviderSubject reads from the primary, so a user who just linked an
+// identity can log in with it straight away.
+func (r *identityRepository) GetByProviderSubject(ctx context.Context, provider string, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	query := `SELECT id, user_id, provider, subject, email, created_at, last_login_at
+			  FROM user_identities WHERE provider = ? AND subject = ?`
+
+	if err := r.db.Write.GetContext(ctx, &identity, query, provider, subject); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("identity not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "failed to retrieve identity", map[string]interface{}{
+			"provider": provider,
+		})
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) GetByUser(ctx context.Context, userID int) ([]models.UserIdentity, error) {
+	identities := make([]models.UserIdentity, 0)
+	query := `SELECT id, user_id, provider, subject, email, created_at, last_login_at
+			  FROM user_identities WHERE user_id = ? ORDER BY created_at ASC`
+
+	if err := r.db.Write.SelectContext(ctx, &identities, query, userID); err != nil {
+		return nil, logger.LogErrorWithFields(err, "failed to retrieve identities", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	return identities, nil
+}
+
+func (r *identityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	query := `INSERT INTO user_identities(user_id, provider, subject, email, created_at)
+			  VALUES(:user_id, :provider, :subject, :email, NOW())`
+
+	result, err := r.db.Write.NamedExecContext(ctx, query, identity)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return logger.ValidationError("identity is already linked")
+		}
+		return logger.LogErrorWithFields(err, "failed to save identity", map[string]interface{}{
+			"user_id":  identity.UserID,
+			"provider": identity.Provider,
+		})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new identity id")
+	}
+	identity.ID = int(id)
+	return nil
+}
+
+// CreateUserWithIdentity creates the account of someone who logs in with a
+// provider for the first time, together with the identity they log in with.
+// The user has no password, and their email counts as verified when
+// EmailVerifiedAt is set.
+func (r *identityRepository) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) error {
+	tx, err := r.db.Write.BeginTxx(ctx, nil)
+	if err != nil {
+		return logger.LogError(err, "failed to start identity transaction")
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO users(username, password, email, email_verified_at, created_at)
+			  VALUES (:username, :password, :email, :email_verified_at, NOW())`
+	result, err := tx.NamedExecContext(ctx, query, user)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return logger.ValidationError("username or email is already registered")
+		}
+		return logger.LogErrorWithFields(err, "failed to create user for identity", map[string]interface{}{
+			"username": user.Username,
+		})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new user id")
+	}
+	user.ID = int(id)
+	identity.UserID = user.ID
+
+	query = `INSERT INTO user_identities(user_id, provider, subject, email, created_at, last_login_at)
+			 VALUES(:user_id, :provider, :subject, :email, NOW(), NOW())`
+	result, err = tx.NamedExecContext(ctx, query, identity)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return logger.ValidationError("identity is already linked")
+		}
+		return logger.LogErrorWithFields(err, "failed to save identity", map[string]interface{}{
+			"user_id":  identity.UserID,
+			"provider": identity.Provider,
+		})
+	}
+
+	id, err = result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new identity id")
+	}
+	identity.ID = int(id)
+
+	if err := tx.Commit(); err != nil {
+		return logger.LogError(err, "failed to commit identity transaction")
+	}
+	return nil
+}
+
+func (r *identityRepository) Delete(ctx context.Context, userID int, identityID int) error {
+	query := `DELETE FROM user_identities WHERE id = ? AND user_id = ?`
+
+	result, err := r.db.Write.ExecContext(ctx, query, identityID, userID)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to delete identity", map[string]interface{}{
+			"user_id":     userID,
+			"identity_id": identityID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("identity not found")
+	}
+	return nil
+}
+
+// Touch records a login with an identity.
+func (r *identityRepository) Touch(ctx context.Context, identityID int) error {
+	query := `UPDATE user_identities SET last_login_at = NOW() WHERE id = ?`
+	if _, err := r.db.Write.ExecContext(ctx, query, identityID); err != nil {
+		return logger.LogErrorWithFields(err, "failed to record identity login", map[string]interface{}{
+			"identity_id": identityID,
+		})
+	}
+	return nil
+}
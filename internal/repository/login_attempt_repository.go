@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type LoginAttemptRepository interface {
+	Create(ctx context.Context, attempt *models.LoginAttempt) error
+	GetAll(ctx context.Context, username string, ipAddress string, limit int, offset int) ([]models.LoginAttempt, int, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+type loginAttemptRepository struct {
+	db *config.Database
+}
+
+func NewLoginAttemptRepository(db *config.Database) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+// Create records an attempt.
+func (r *loginAttemptRepository) Create(ctx context.Context, attempt *models.LoginAttempt) error {
+	attempt.CreatedAt = time.Now()
+	query := `INSERT INTO login_attempts(username, user_id, ip_address, user_agent, outcome, created_at)
+			  VALUES(:username, :user_id, :ip_address, :user_agent, :outcome, :created_at)`
+	if _, err := r.db.Write.NamedExecContext(ctx, query, attempt); err != nil {
+		return logger.LogErrorWithFields(err, "failed to save login attempt", map[string]interface{}{
+			"username": attempt.Username,
+		})
+	}
+	return nil
+}
+
+// GetAll lists login attempts, newest first, optionally only those for a
+// username or from an IP address.
+func (r *loginAttemptRepository) GetAll(ctx context.Context, username string, ipAddress string, limit int, offset int) ([]models.LoginAttempt, int, error) {
+	attempts := make([]models.LoginAttempt, 0)
+
+	where := ` WHERE 1 = 1`
+	var args []interface{}
+	if username != "" {
+		where += ` AND username = ?`
+		args = append(args, username)
+	}
+	if ipAddress != "" {
+		where += ` AND ip_address = ?`
+		args = append(args, ipAddress)
+	}
+
+	var total int
+	if err := r.db.Read.GetContext(ctx, &total, `SELECT COUNT(*) FROM login_attempts`+where, args...); err != nil {
+		return nil, 0, logger.LogError(err, "Failed to count login attempts")
+	}
+
+	if total == 0 {
+		return attempts, 0, nil
+	}
+
+	query := `SELECT id, username, user_id, ip_address, user_agent, outcome, created_at FROM login_attempts` + where +
+		` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`
+
+	if err := r.db.Read.SelectContext(ctx, &attempts, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, logger.LogError(err, "Failed to retrieve login attempts")
+	}
+
+	return attempts, total, nil
+}
+
+func (r *loginAttemptRepository) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.Write.ExecContext(ctx, `DELETE FROM login_attempts WHERE created_at < ?`, before)
+	if err != nil {
+		return 0, logger.LogError(err, "failed to delete old login attempts")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, logger.LogError(err, "failed to read deleted login attempt count")
+	}
+	return int(rows), nil
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type PostImageRepository interface {
+	Create(ctx context.Context, image *models.PostImage) error
+	GetByID(ctx context.Context, id int) (*models.PostImage, error)
+	GetByPostID(ctx context.Context, postID int) ([]models.PostImage, error)
+	GetByPostIDs(ctx context.Context, postIDs []int) (map[int][]models.PostImage, error)
+	Count(ctx context.Context, postID int) (int, error)
+	Update(ctx context.Context, image *models.PostImage) error
+	Reorder(ctx context.Context, postID int, imageIDs []int) error
+	Delete(ctx context.Context, id int) error
+}
+
+type postImageRepository struct {
+	db *config.Database
+}
+
+func NewPostImageRepository(db *config.Database) PostImageRepository {
+	return &postImageRepository{db: db}
+}
+
+const postImageColumns = `SELECT id, post_id, image, position, caption, alt_text, step_number, created_at FROM post_images`
+
+// Create adds an image at the end of its post's gallery and sets its ID and
+// position.
+func (r *postImageRepository) Create(ctx context.Context, image *models.PostImage) error {
+	tx, err := r.db.Write.BeginTxx(ctx, nil)
+	if err != nil {
+		return logger.LogError(err, "failed to start post image transaction")
+	}
+	defer tx.Rollback()
+
+	var next int
+	query := `SELECT COALESCE(MAX(position), 0) + 1 FROM post_images WHERE post_id = ? FOR UPDATE`
+	if err := tx.GetContext(ctx, &next, query, image.PostID); err != nil {
+		return logger.LogErrorWithFields(err, "failed to read next image position", map[string]interface{}{
+			"post_id": image.PostID,
+		})
+	}
+	image.Position = next
+
+	insert := `INSERT INTO post_images(post_id, image, position, caption, alt_text, step_number, created_at)
+			   VALUES(:post_id, :image, :position, :caption, :alt_text, :step_number, NOW())`
+	result, err := tx.NamedExecContext(ctx, insert, image)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to save post image into database", map[string]interface{}{
+			"post_id": image.PostID,
+		})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new post image id")
+	}
+	image.ID = int(id)
+
+	if err := tx.Commit(); err != nil {
+		return logger.LogError(err, "failed to commit post image transaction")
+	}
+	return nil
+}
+
+func (r *postImageRepository) GetByID(ctx context.Context, id int) (*models.PostImage, error) {
+	var image models.PostImage
+	if err := r.db.Read.GetContext(ctx, &image, postImageColumns+` WHERE id = ?`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("image not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve post image", map[string]interface{}{
+			"image_id": id,
+		})
+	}
+
+	return &image, nil
+}
+
+// GetByPostID returns a post's gallery in display order.
+func (r *postImageRepository) GetByPostID(ctx context.Context, postID int) ([]models.PostImage, error) {
+	images := make([]models.PostImage, 0)
+	query := postImageColumns + ` WHERE post_id = ? ORDER BY position, id`
+
+	if err := r.db.Read.SelectContext(ctx, &images, query, postID); err != nil {
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve post images", map[string]interface{}{
+			"post_id": postID,
+		})
+	}
+
+	return images, nil
+}
+
+// GetByPostIDs returns the galleries of several posts, keyed by post ID.
+func (r *postImageRepository) GetByPostIDs(ctx context.Context, postIDs []int) (map[int][]models.PostImage, error) {
+	byPost := make(map[int][]models.PostImage)
+	if len(postIDs) == 0 {
+		return byPost, nil
+	}
+
+	query, args, err := sqlx.In(postImageColumns+` WHERE post_id IN (?) ORDER BY position, id`, postIDs)
+	if err != nil {
+		return nil, logger.LogError(err, "failed to build post image query")
+	}
+
+	var rows []models.PostImage
+	if err := r.db.Read.SelectContext(ctx, &rows, r.db.Read.Rebind(query), args...); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve post images")
+	}
+
+	for _, row := range rows {
+		byPost[row.PostID] = append(byPost[row.PostID], row)
+	}
+	return byPost, nil
+}
+
+func (r *postImageRepository) Count(ctx context.Context, postID int) (int, error) {
+	var count int
+	if err := r.db.Read.GetContext(ctx, &count, `SELECT COUNT(*) FROM post_images WHERE post_id = ?`, postID); err != nil {
+		return 0, logger.LogErrorWithFields(err, "Failed to count post images", map[string]interface{}{
+			"post_id": postID,
+		})
+	}
+	return count, nil
+}
+
+func (r *postImageRepository) Update(ctx context.Context, image *models.PostImage) error {
+	query := `UPDATE post_images SET caption = :caption, alt_text = :alt_text, step_number = :step_number WHERE id = :id`
+	if _, err := r.db.Write.NamedExecContext(ctx, query, image); err != nil {
+		return logger.LogErrorWithFields(err, "failed to update post image", map[string]interface{}{
+			"image_id": image.ID,
+		})
+	}
+	return nil
+}
+
+// Reorder numbers a post's images 1, 2, 3, ... in the order of imageIDs.
+func (r *postImageRepository) Reorder(ctx context.Context, postID int, imageIDs []int) error {
+	tx, err := r.db.Write.BeginTxx(ctx, nil)
+	if err != nil {
+		return logger.LogError(err, "failed to start post image transaction")
+	}
+	defer tx.Rollback()
+
+	for i, id := range imageIDs {
+		query := `UPDATE post_images SET position = ? WHERE id = ? AND post_id = ?`
+		if _, err := tx.ExecContext(ctx, query, i+1, id, postID); err != nil {
+			return logger.LogErrorWithFields(err, "failed to reorder post images", map[string]interface{}{
+				"post_id":  postID,
+				"image_id": id,
+			})
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return logger.LogError(err, "failed to commit post image transaction")
+	}
+	return nil
+}
+
+func (r *postImageRepository) Delete(ctx context.Context, id int) error {
+	if _, err := r.db.Write.ExecContext(ctx, `DELETE FROM post_images WHERE id = ?`, id); err != nil {
+		return logger.LogErrorWithFields(err, "Failed to delete post image from database", map[string]interface{}{
+			"image_id": id,
+		})
+	}
+	return nil
+}
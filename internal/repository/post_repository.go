@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/rafli2460/culinary-blog-api/internal/config"
@@ -16,8 +17,21 @@ type PostRepository interface {
 	GetByID(ctx context.Context, id int) (*models.Post, error)
 	Delete(ctx context.Context, id int) error
 	Update(ctx context.Context, post *models.Post) error
-	GetAll(ctx context.Context, limit int, offset int) ([]models.PostDetail, error)
+	GetAll(ctx context.Context, filter models.PostFilter, limit int, offset int) ([]models.PostDetail, error)
 	GetPostDetailByID(ctx context.Context, id int) (*models.PostDetail, error)
+	PublishScheduled(ctx context.Context, now time.Time) (int, error)
+	GetPostDetailBySlug(ctx context.Context, slug string) (*models.PostDetail, error)
+	GetCurrentSlug(ctx context.Context, oldSlug string) (string, error)
+	SlugTaken(ctx context.Context, slug string, postID int) (bool, error)
+	UpdateSlug(ctx context.Context, postID int, newSlug string, oldSlug *string) error
+	GetWithoutSlug(ctx context.Context, limit int) ([]models.Post, error)
+	GetTrashed(ctx context.Context, limit int, offset int) ([]models.TrashedPost, int, error)
+	GetTrashedBefore(ctx context.Context, cutoff time.Time) ([]int, error)
+	Restore(ctx context.Context, id int) error
+	Purge(ctx context.Context, id int) error
+	GetImages(ctx context.Context, postID int) ([]string, error)
+	GetImagesByUser(ctx context.Context, userID int) ([]string, error)
+	GetImageReferences(ctx context.Context) ([]models.ImageReference, error)
 }
 
 type postRepository struct {
@@ -29,20 +43,27 @@ func NewPostRepository(db *config.Database) PostRepository {
 }
 
 func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
-	query := `INSERT INTO posts(user_id, title, content, image, created_at)
-			  VALUES(:user_id, :title, :content, :image, NOW())`
-	_, err := r.db.Write.NamedExecContext(ctx, query, post)
+	query := `INSERT INTO posts(user_id, title, slug, content, image, status, published_at, created_at)
+			  VALUES(:user_id, :title, :slug, :content, :image, :status, :published_at, NOW())`
+	result, err := r.db.Write.NamedExecContext(ctx, query, post)
 	if err != nil {
 		return logger.LogErrorWithFields(err, "failed to save post into database", map[string]interface{}{
 			"title": post.Title,
 		})
 	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new post id")
+	}
+	post.ID = int(id)
 	return nil
 }
 
 func (r *postRepository) GetByID(ctx context.Context, id int) (*models.Post, error) {
 	var post models.Post
-	query := `SELECT id, user_id, title, content, image, created_at FROM posts WHERE id = ?`
+	query := `SELECT id, user_id, title, slug, content, image, status, published_at, created_at
+			  FROM posts WHERE id = ? AND deleted_at IS NULL`
 
 	err := r.db.Read.GetContext(ctx, &post, query, id)
 	if err != nil {
@@ -52,10 +73,12 @@ func (r *postRepository) GetByID(ctx context.Context, id int) (*models.Post, err
 	return &post, nil
 }
 
+// Delete moves a post to the trash. It stays out of every listing until an
+// admin restores or purges it.
 func (r *postRepository) Delete(ctx context.Context, id int) error {
-	query := `DELETE FROM posts WHERE id = ?`
+	query := `UPDATE posts SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`
 
-	_, err := r.db.Write.ExecContext(ctx, query, id)
+	_, err := r.db.Write.ExecContext(ctx, query, time.Now(), id)
 	if err != nil {
 		return logger.LogErrorWithFields(err, "Failed to delete post from database", map[string]interface{}{
 			"post_id": id,
@@ -65,7 +88,8 @@ func (r *postRepository) Delete(ctx context.Context, id int) error {
 }
 
 func (r *postRepository) Update(ctx context.Context, post *models.Post) error {
-	query := `UPDATE posts SET title = :title, content = :content, image = :image WHERE id = :id`
+	query := `UPDATE posts SET title = :title, content = :content, image = :image, status = :status,
+			  published_at = :published_at WHERE id = :id`
 	_, err := r.db.Write.NamedExecContext(ctx, query, post)
 	if err != nil {
 		return logger.LogErrorWithFields(err, "failed to update post in database", map[string]interface{}{
@@ -75,14 +99,29 @@ func (r *postRepository) Update(ctx context.Context, post *models.Post) error {
 	return nil
 }
 
+// postDetailQuery selects posts with their author's username, display name and
+// avatar, average rating, rating count and visible comment count, leaving out
+// posts by trashed users. Callers append the WHERE clause and must exclude
+// trashed posts themselves.
+const postDetailQuery = `
+		SELECT posts.id, posts.title, posts.slug, posts.content, posts.image, posts.status, posts.published_at,
+			posts.created_at, users.username, users.display_name AS author_name, users.avatar AS author_avatar,
+			COALESCE(rating_stats.average_rating, 0) AS average_rating,
+			COALESCE(rating_stats.rating_count, 0) AS rating_count,
+			(SELECT COUNT(*) FROM comments
+				WHERE comments.post_id = posts.id AND comments.status = 'approved' AND comments.deleted_at IS NULL) AS comment_count
+		FROM posts
+		JOIN users ON posts.user_id = users.id AND users.deleted_at IS NULL
+		LEFT JOIN (
+			SELECT post_id, ROUND(AVG(rating), 2) AS average_rating, COUNT(*) AS rating_count
+			FROM ratings GROUP BY post_id
+		) rating_stats ON rating_stats.post_id = posts.id`
+
 func (r *postRepository) GetPostDetailByID(ctx context.Context, id int) (*models.PostDetail, error) {
 	var post models.PostDetail
 
-	query := `
-		SELECT posts.id, posts.title, posts.content, posts.image, posts.created_at, users.username 
-		FROM posts 
-		JOIN users ON posts.user_id = users.id 
-		WHERE posts.id = ?`
+	query := postDetailQuery + `
+		WHERE posts.id = ? AND posts.deleted_at IS NULL`
 
 	err := r.db.Read.GetContext(ctx, &post, query, id)
 	if err != nil {
@@ -97,20 +136,334 @@ func (r *postRepository) GetPostDetailByID(ctx context.Context, id int) (*models
 	return &post, nil
 }
 
-func (r *postRepository) GetAll(ctx context.Context, limit int, offset int) ([]models.PostDetail, error) {
+func (r *postRepository) GetAll(ctx context.Context, filter models.PostFilter, limit int, offset int) ([]models.PostDetail, error) {
 	posts := make([]models.PostDetail, 0)
 
-	query := `
-		SELECT posts.id, posts.title, posts.content, posts.image, posts.created_at, users.username 
-		FROM posts 
-		JOIN users ON posts.user_id = users.id 
-		ORDER BY posts.created_at DESC
+	query := postDetailQuery + `
+		WHERE posts.deleted_at IS NULL`
+	var args []interface{}
+
+	if filter.Category != "" {
+		// Browsing a category includes posts filed under any of its subcategories.
+		query += ` AND posts.id IN (
+			WITH RECURSIVE category_tree AS (
+				SELECT id FROM categories WHERE slug = ?
+				UNION ALL
+				SELECT categories.id FROM categories JOIN category_tree ON categories.parent_id = category_tree.id
+			)
+			SELECT post_categories.post_id FROM post_categories
+			JOIN category_tree ON post_categories.category_id = category_tree.id)`
+		args = append(args, filter.Category)
+	}
+
+	if filter.Cuisine != "" {
+		query += ` AND posts.id IN (
+			SELECT post_cuisines.post_id FROM post_cuisines
+			JOIN cuisines ON cuisines.id = post_cuisines.cuisine_id
+			WHERE cuisines.slug = ?)`
+		args = append(args, filter.Cuisine)
+	}
+
+	if filter.Tag != "" {
+		query += ` AND posts.id IN (
+			SELECT post_tags.post_id FROM post_tags
+			JOIN tags ON tags.id = post_tags.tag_id
+			WHERE tags.slug = ?)`
+		args = append(args, filter.Tag)
+	}
+
+	if filter.Status != "" {
+		query += ` AND posts.status = ?`
+		args = append(args, filter.Status)
+	}
+
+	if filter.AuthorID != 0 {
+		query += ` AND posts.user_id = ?`
+		args = append(args, filter.AuthorID)
+	}
+
+	query += `
+		ORDER BY COALESCE(posts.published_at, posts.created_at) DESC
 		LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
 
-	err := r.db.Read.SelectContext(ctx, &posts, query, limit, offset)
+	err := r.db.Read.SelectContext(ctx, &posts, query, args...)
 	if err != nil {
-		return nil, logger.LogError(err, "Failed to retrieve post list")
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve post list", map[string]interface{}{
+			"category": filter.Category,
+			"cuisine":  filter.Cuisine,
+			"tag":      filter.Tag,
+			"status":   filter.Status,
+		})
 	}
 
 	return posts, nil
 }
+
+// PublishScheduled publishes every scheduled post whose publish time is at or
+// before now and returns how many were published.
+func (r *postRepository) PublishScheduled(ctx context.Context, now time.Time) (int, error) {
+	query := `UPDATE posts SET status = 'published'
+			  WHERE status = 'scheduled' AND published_at <= ? AND deleted_at IS NULL`
+	result, err := r.db.Write.ExecContext(ctx, query, now.UTC())
+	if err != nil {
+		return 0, logger.LogError(err, "Failed to publish scheduled posts")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, logger.LogError(err, "failed to read published post count")
+	}
+	return int(affected), nil
+}
+
+func (r *postRepository) GetPostDetailBySlug(ctx context.Context, slug string) (*models.PostDetail, error) {
+	var post models.PostDetail
+
+	query := postDetailQuery + `
+		WHERE posts.slug = ? AND posts.deleted_at IS NULL`
+
+	if err := r.db.Read.GetContext(ctx, &post, query, slug); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("post not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve post details", map[string]interface{}{
+			"slug": slug,
+		})
+	}
+
+	return &post, nil
+}
+
+// GetCurrentSlug finds the post that used to be reachable under oldSlug and
+// returns the slug it has now.
+func (r *postRepository) GetCurrentSlug(ctx context.Context, oldSlug string) (string, error) {
+	var current string
+	query := `
+		SELECT posts.slug FROM post_slug_history
+		JOIN posts ON posts.id = post_slug_history.post_id
+		WHERE post_slug_history.slug = ? AND posts.slug IS NOT NULL AND posts.deleted_at IS NULL`
+
+	if err := r.db.Read.GetContext(ctx, &current, query, oldSlug); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", logger.ValidationError("post not found")
+		}
+		return "", logger.LogErrorWithFields(err, "Failed to look up old post slug", map[string]interface{}{
+			"slug": oldSlug,
+		})
+	}
+
+	return current, nil
+}
+
+// SlugTaken reports whether slug is in use, now or in the past, by any post
+// other than postID.
+func (r *postRepository) SlugTaken(ctx context.Context, slug string, postID int) (bool, error) {
+	var taken bool
+	query := `
+		SELECT EXISTS(SELECT 1 FROM posts WHERE slug = ? AND id <> ?)
+			OR EXISTS(SELECT 1 FROM post_slug_history WHERE slug = ? AND post_id <> ?)`
+
+	if err := r.db.Read.GetContext(ctx, &taken, query, slug, postID, slug, postID); err != nil {
+		return false, logger.LogErrorWithFields(err, "Failed to check post slug", map[string]interface{}{
+			"slug": slug,
+		})
+	}
+
+	return taken, nil
+}
+
+// UpdateSlug gives a post a new slug and keeps the old one in the slug
+// history so existing links can be redirected. A post taking back one of its
+// own old slugs removes it from the history.
+func (r *postRepository) UpdateSlug(ctx context.Context, postID int, newSlug string, oldSlug *string) error {
+	tx, err := r.db.Write.BeginTxx(ctx, nil)
+	if err != nil {
+		return logger.LogError(err, "failed to start slug transaction")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM post_slug_history WHERE post_id = ? AND slug = ?`, postID, newSlug); err != nil {
+		return logger.LogErrorWithFields(err, "failed to clear reused post slug", map[string]interface{}{
+			"post_id": postID,
+		})
+	}
+
+	if oldSlug != nil && *oldSlug != "" && *oldSlug != newSlug {
+		query := `INSERT IGNORE INTO post_slug_history(post_id, slug, created_at) VALUES(?, ?, NOW())`
+		if _, err := tx.ExecContext(ctx, query, postID, *oldSlug); err != nil {
+			return logger.LogErrorWithFields(err, "failed to save old post slug", map[string]interface{}{
+				"post_id": postID,
+			})
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE posts SET slug = ? WHERE id = ?`, newSlug, postID); err != nil {
+		if isDuplicateEntry(err) {
+			return logger.ValidationError("slug is already in use")
+		}
+		return logger.LogErrorWithFields(err, "failed to update post slug", map[string]interface{}{
+			"post_id": postID,
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return logger.LogError(err, "failed to commit slug transaction")
+	}
+	return nil
+}
+
+// GetWithoutSlug returns up to limit posts that have no slug yet.
+func (r *postRepository) GetWithoutSlug(ctx context.Context, limit int) ([]models.Post, error) {
+	posts := make([]models.Post, 0)
+	query := `SELECT id, user_id, title, slug, content, image, status, published_at, created_at
+			  FROM posts WHERE slug IS NULL ORDER BY id LIMIT ?`
+
+	if err := r.db.Read.SelectContext(ctx, &posts, query, limit); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve posts without slug")
+	}
+
+	return posts, nil
+}
+
+// GetTrashed lists trashed posts, most recently deleted first.
+func (r *postRepository) GetTrashed(ctx context.Context, limit int, offset int) ([]models.TrashedPost, int, error) {
+	posts := make([]models.TrashedPost, 0)
+
+	var total int
+	if err := r.db.Read.GetContext(ctx, &total, `SELECT COUNT(*) FROM posts WHERE deleted_at IS NOT NULL`); err != nil {
+		return nil, 0, logger.LogError(err, "Failed to count trashed posts")
+	}
+
+	if total == 0 {
+		return posts, 0, nil
+	}
+
+	query := `
+		SELECT posts.id, posts.user_id, users.username, posts.title, posts.slug, posts.status, posts.created_at,
+			posts.deleted_at
+		FROM posts
+		JOIN users ON users.id = posts.user_id
+		WHERE posts.deleted_at IS NOT NULL
+		ORDER BY posts.deleted_at DESC, posts.id DESC
+		LIMIT ? OFFSET ?`
+
+	if err := r.db.Read.SelectContext(ctx, &posts, query, limit, offset); err != nil {
+		return nil, 0, logger.LogError(err, "Failed to retrieve trashed posts")
+	}
+
+	return posts, total, nil
+}
+
+// GetTrashedBefore returns the ids of posts that were trashed before cutoff.
+func (r *postRepository) GetTrashedBefore(ctx context.Context, cutoff time.Time) ([]int, error) {
+	ids := make([]int, 0)
+	query := `SELECT id FROM posts WHERE deleted_at IS NOT NULL AND deleted_at < ?`
+
+	if err := r.db.Read.SelectContext(ctx, &ids, query, cutoff); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve expired trashed posts")
+	}
+
+	return ids, nil
+}
+
+func (r *postRepository) Restore(ctx context.Context, id int) error {
+	query := `UPDATE posts SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`
+
+	result, err := r.db.Write.ExecContext(ctx, query, id)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "Failed to restore post", map[string]interface{}{
+			"post_id": id,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("post not found in trash")
+	}
+	return nil
+}
+
+// Purge permanently deletes a trashed post along with its recipe, ratings,
+// comments and revisions.
+func (r *postRepository) Purge(ctx context.Context, id int) error {
+	query := `DELETE FROM posts WHERE id = ? AND deleted_at IS NOT NULL`
+
+	result, err := r.db.Write.ExecContext(ctx, query, id)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "Failed to purge post from database", map[string]interface{}{
+			"post_id": id,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("post not found in trash")
+	}
+	return nil
+}
+
+// GetImages returns the distinct image file names used by a post, either as
+// its current image, in its gallery or in one of its revisions.
+func (r *postRepository) GetImages(ctx context.Context, postID int) ([]string, error) {
+	images := make([]string, 0)
+	query := `
+		SELECT image FROM posts WHERE id = ? AND image IS NOT NULL AND image <> ''
+		UNION
+		SELECT image FROM post_revisions WHERE post_id = ? AND image IS NOT NULL AND image <> ''
+		UNION
+		SELECT image FROM post_images WHERE post_id = ?`
+
+	if err := r.db.Read.SelectContext(ctx, &images, query, postID, postID, postID); err != nil {
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve post images", map[string]interface{}{
+			"post_id": postID,
+		})
+	}
+
+	return images, nil
+}
+
+// GetImagesByUser returns the distinct image file names used by a user's
+// avatar or by any of their posts, trashed or not.
+func (r *postRepository) GetImagesByUser(ctx context.Context, userID int) ([]string, error) {
+	images := make([]string, 0)
+	query := `
+		SELECT image FROM posts WHERE user_id = ? AND image IS NOT NULL AND image <> ''
+		UNION
+		SELECT post_revisions.image FROM post_revisions
+		JOIN posts ON posts.id = post_revisions.post_id
+		WHERE posts.user_id = ? AND post_revisions.image IS NOT NULL AND post_revisions.image <> ''
+		UNION
+		SELECT post_images.image FROM post_images
+		JOIN posts ON posts.id = post_images.post_id
+		WHERE posts.user_id = ?
+		UNION
+		SELECT avatar FROM users WHERE id = ? AND avatar IS NOT NULL AND avatar <> ''`
+
+	if err := r.db.Read.SelectContext(ctx, &images, query, userID, userID, userID, userID); err != nil {
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve user post images", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	return images, nil
+}
+
+// GetImageReferences returns every image file named by a post, a revision, a
+// gallery or a user's avatar, including those of trashed posts and users,
+// whose files are kept until they are purged.
+func (r *postRepository) GetImageReferences(ctx context.Context) ([]models.ImageReference, error) {
+	references := make([]models.ImageReference, 0)
+	query := `
+		SELECT 'post' AS source, id AS owner_id, image FROM posts WHERE image IS NOT NULL AND image <> ''
+		UNION
+		SELECT 'revision', post_id, image FROM post_revisions WHERE image IS NOT NULL AND image <> ''
+		UNION
+		SELECT 'gallery', post_id, image FROM post_images
+		UNION
+		SELECT 'avatar', id, avatar FROM users WHERE avatar IS NOT NULL AND avatar <> ''`
+
+	if err := r.db.Read.SelectContext(ctx, &references, query); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve image references")
+	}
+
+	return references, nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type ProfileRepository interface {
+	GetByUsername(ctx context.Context, username string) (*models.Profile, error)
+	GetByID(ctx context.Context, userID int) (*models.Profile, error)
+	Update(ctx context.Context, profile *models.Profile) error
+	GetSocialLinks(ctx context.Context, userID int) ([]models.SocialLink, error)
+	ReplaceSocialLinks(ctx context.Context, userID int, links []models.SocialLink) error
+}
+
+type profileRepository struct {
+	db *config.Database
+}
+
+func NewProfileRepository(db *config.Database) ProfileRepository {
+	return &profileRepository{db: db}
+}
+
+// profileQuery selects users with their profile fields and the number of
+// posts they have published. Callers append the WHERE clause.
+const profileQuery = `
+		SELECT users.id, users.username, users.display_name, users.bio, users.avatar, users.location, users.created_at,
+			(SELECT COUNT(*) FROM posts
+				WHERE posts.user_id = users.id AND posts.status = 'published' AND posts.deleted_at IS NULL) AS post_count
+		FROM users`
+
+func (r *profileRepository) GetByUsername(ctx context.Context, username string) (*models.Profile, error) {
+	var profile models.Profile
+	query := profileQuery + ` WHERE users.username = ? AND users.deleted_at IS NULL`
+
+	if err := r.db.Read.GetContext(ctx, &profile, query, username); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("user not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve user profile", map[string]interface{}{
+			"username": username,
+		})
+	}
+
+	return &profile, nil
+}
+
+func (r *profileRepository) GetByID(ctx context.Context, userID int) (*models.Profile, error) {
+	var profile models.Profile
+	query := profileQuery + ` WHERE users.id = ? AND users.deleted_at IS NULL`
+
+	if err := r.db.Read.GetContext(ctx, &profile, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("user not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve user profile", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	return &profile, nil
+}
+
+func (r *profileRepository) Update(ctx context.Context, profile *models.Profile) error {
+	query := `UPDATE users SET display_name = :display_name, bio = :bio, avatar = :avatar, location = :location
+			  WHERE id = :id AND deleted_at IS NULL`
+
+	if _, err := r.db.Write.NamedExecContext(ctx, query, profile); err != nil {
+		return logger.LogErrorWithFields(err, "failed to update user profile", map[string]interface{}{
+			"user_id": profile.ID,
+		})
+	}
+	return nil
+}
+
+func (r *profileRepository) GetSocialLinks(ctx context.Context, userID int) ([]models.SocialLink, error) {
+	links := make([]models.SocialLink, 0)
+	query := `SELECT platform, url FROM user_social_links WHERE user_id = ? ORDER BY id`
+
+	if err := r.db.Read.SelectContext(ctx, &links, query, userID); err != nil {
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve social links", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	return links, nil
+}
+
+// ReplaceSocialLinks swaps a user's social links for the given ones.
+func (r *profileRepository) ReplaceSocialLinks(ctx context.Context, userID int, links []models.SocialLink) error {
+	tx, err := r.db.Write.BeginTxx(ctx, nil)
+	if err != nil {
+		return logger.LogError(err, "failed to start social link transaction")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_social_links WHERE user_id = ?`, userID); err != nil {
+		return logger.LogErrorWithFields(err, "failed to clear social links", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	for _, link := range links {
+		query := `INSERT INTO user_social_links(user_id, platform, url) VALUES(?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, userID, link.Platform, link.URL); err != nil {
+			return logger.LogErrorWithFields(err, "failed to save social link", map[string]interface{}{
+				"user_id":  userID,
+				"platform": link.Platform,
+			})
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return logger.LogError(err, "failed to commit social link transaction")
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type RatingRepository interface {
+	Create(ctx context.Context, rating *models.Rating) error
+	Update(ctx context.Context, rating *models.Rating) error
+	Delete(ctx context.Context, id int) error
+	GetByPostAndUser(ctx context.Context, postID, userID int) (*models.Rating, error)
+	GetByPostID(ctx context.Context, postID int, sort string, limit int, offset int) ([]models.Rating, int, error)
+	GetByUserID(ctx context.Context, userID int) ([]models.Rating, error)
+}
+
+// RatingSorts maps the accepted sort names for a rating list to their
+// ORDER BY clause.
+var RatingSorts = map[string]string{
+	"newest":  "ratings.created_at DESC, ratings.id DESC",
+	"oldest":  "ratings.created_at ASC, ratings.id ASC",
+	"highest": "ratings.rating DESC, ratings.created_at DESC",
+	"lowest":  "ratings.rating ASC, ratings.created_at DESC",
+}
+
+type ratingRepository struct {
+	db *config.Database
+}
+
+func NewRatingRepository(db *config.Database) RatingRepository {
+	return &ratingRepository{db: db}
+}
+
+func (r *ratingRepository) Create(ctx context.Context, rating *models.Rating) error {
+	query := `INSERT INTO ratings(post_id, user_id, rating, review, created_at, updated_at)
+			  VALUES(:post_id, :user_id, :rating, :review, NOW(), NOW())`
+	result, err := r.db.Write.NamedExecContext(ctx, query, rating)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return logger.ValidationError("you have already rated this post, update your rating instead")
+		}
+		return logger.LogErrorWithFields(err, "failed to save rating into database", map[string]interface{}{
+			"post_id": rating.PostID,
+			"user_id": rating.UserID,
+		})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new rating id")
+	}
+	rating.ID = int(id)
+	return nil
+}
+
+func (r *ratingRepository) Update(ctx context.Context, rating *models.Rating) error {
+	query := `UPDATE ratings SET rating = :rating, review = :review WHERE id = :id`
+	if _, err := r.db.Write.NamedExecContext(ctx, query, rating); err != nil {
+		return logger.LogErrorWithFields(err, "failed to update rating in database", map[string]interface{}{
+			"rating_id": rating.ID,
+		})
+	}
+	return nil
+}
+
+func (r *ratingRepository) Delete(ctx context.Context, id int) error {
+	if _, err := r.db.Write.ExecContext(ctx, `DELETE FROM ratings WHERE id = ?`, id); err != nil {
+		return logger.LogErrorWithFields(err, "Failed to delete rating from database", map[string]interface{}{
+			"rating_id": id,
+		})
+	}
+	return nil
+}
+
+func (r *ratingRepository) GetByPostAndUser(ctx context.Context, postID, userID int) (*models.Rating, error) {
+	var rating models.Rating
+	query := `
+		SELECT ratings.id, ratings.post_id, ratings.user_id, users.username, ratings.rating, ratings.review,
+			ratings.created_at, ratings.updated_at
+		FROM ratings
+		JOIN users ON users.id = ratings.user_id
+		WHERE ratings.post_id = ? AND ratings.user_id = ?`
+
+	if err := r.db.Read.GetContext(ctx, &rating, query, postID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("rating not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve rating", map[string]interface{}{
+			"post_id": postID,
+			"user_id": userID,
+		})
+	}
+
+	return &rating, nil
+}
+
+// GetByPostID lists a post's ratings in the given sort order (a key of
+// RatingSorts) along with the total number of ratings for pagination.
+func (r *ratingRepository) GetByPostID(ctx context.Context, postID int, sort string, limit int, offset int) ([]models.Rating, int, error) {
+	ratings := make([]models.Rating, 0)
+
+	orderBy, ok := RatingSorts[sort]
+	if !ok {
+		orderBy = RatingSorts["newest"]
+	}
+
+	var total int
+	if err := r.db.Read.GetContext(ctx, &total, `SELECT COUNT(*) FROM ratings WHERE post_id = ?`, postID); err != nil {
+		return nil, 0, logger.LogErrorWithFields(err, "Failed to count ratings", map[string]interface{}{
+			"post_id": postID,
+		})
+	}
+
+	if total == 0 {
+		return ratings, 0, nil
+	}
+
+	query := `
+		SELECT ratings.id, ratings.post_id, ratings.user_id, users.username, ratings.rating, ratings.review,
+			ratings.created_at, ratings.updated_at
+		FROM ratings
+		JOIN users ON users.id = ratings.user_id
+		WHERE ratings.post_id = ?
+		ORDER BY ` + orderBy + `
+		LIMIT ? OFFSET ?`
+
+	if err := r.db.Read.SelectContext(ctx, &ratings, query, postID, limit, offset); err != nil {
+		return nil, 0, logger.LogErrorWithFields(err, "Failed to retrieve ratings", map[string]interface{}{
+			"post_id": postID,
+		})
+	}
+
+	return ratings, total, nil
+}
+
+// GetByUserID returns every rating a user has given, newest first.
+func (r *ratingRepository) GetByUserID(ctx context.Context, userID int) ([]models.Rating, error) {
+	ratings := make([]models.Rating, 0)
+	query := `
+		SELECT ratings.id, ratings.post_id, ratings.user_id, users.username, ratings.rating, ratings.review,
+			ratings.created_at, ratings.updated_at
+		FROM ratings
+		JOIN users ON users.id = ratings.user_id
+		WHERE ratings.user_id = ?
+		ORDER BY ratings.created_at DESC, ratings.id DESC`
+
+	if err := r.db.Read.SelectContext(ctx, &ratings, query, userID); err != nil {
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve user ratings", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	return ratings, nil
+}
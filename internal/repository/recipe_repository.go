@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type RecipeRepository interface {
+	Create(ctx context.Context, recipe *models.Recipe) error
+	Update(ctx context.Context, recipe *models.Recipe) error
+	GetByPostID(ctx context.Context, postID int) (*models.Recipe, error)
+	GetByPostIDs(ctx context.Context, postIDs []int) (map[int]*models.Recipe, error)
+
+	GetUnlinkedIngredients(ctx context.Context, limit int) ([]models.RecipeIngredient, error)
+	LinkIngredient(ctx context.Context, recipeIngredientID int, canonicalName string) error
+	MatchByIngredients(ctx context.Context, have, exclude, ignore []string, limit int, offset int) ([]models.RecipeMatch, error)
+}
+
+type recipeRepository struct {
+	db *config.Database
+}
+
+func NewRecipeRepository(db *config.Database) RecipeRepository {
+	return &recipeRepository{db: db}
+}
+
+func (r *recipeRepository) Create(ctx context.Context, recipe *models.Recipe) error {
+	tx, err := r.db.Write.BeginTxx(ctx, nil)
+	if err != nil {
+		return logger.LogError(err, "failed to start recipe transaction")
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO recipes(post_id, servings, prep_time, cook_time, total_time, difficulty, created_at, updated_at)
+			  VALUES(:post_id, :servings, :prep_time, :cook_time, :total_time, :difficulty, NOW(), NOW())`
+	result, err := tx.NamedExecContext(ctx, query, recipe)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to save recipe into database", map[string]interface{}{
+			"post_id": recipe.PostID,
+		})
+	}
+
+	recipeID, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new recipe id")
+	}
+	recipe.ID = int(recipeID)
+
+	if err := insertRecipeChildren(ctx, tx, recipe); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return logger.LogError(err, "failed to commit recipe transaction")
+	}
+	return nil
+}
+
+func (r *recipeRepository) Update(ctx context.Context, recipe *models.Recipe) error {
+	tx, err := r.db.Write.BeginTxx(ctx, nil)
+	if err != nil {
+		return logger.LogError(err, "failed to start recipe transaction")
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE recipes SET servings = :servings, prep_time = :prep_time, cook_time = :cook_time,
+			  total_time = :total_time, difficulty = :difficulty WHERE id = :id`
+	if _, err := tx.NamedExecContext(ctx, query, recipe); err != nil {
+		return logger.LogErrorWithFields(err, "failed to update recipe in database", map[string]interface{}{
+			"recipe_id": recipe.ID,
+		})
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recipe_ingredients WHERE recipe_id = ?`, recipe.ID); err != nil {
+		return logger.LogErrorWithFields(err, "failed to clear recipe ingredients", map[string]interface{}{
+			"recipe_id": recipe.ID,
+		})
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recipe_steps WHERE recipe_id = ?`, recipe.ID); err != nil {
+		return logger.LogErrorWithFields(err, "failed to clear recipe steps", map[string]interface{}{
+			"recipe_id": recipe.ID,
+		})
+	}
+
+	if err := insertRecipeChildren(ctx, tx, recipe); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return logger.LogError(err, "failed to commit recipe transaction")
+	}
+	return nil
+}
+
+// insertRecipeChildren writes the ingredient and step rows of a recipe inside
+// an open transaction, stamping each row with the recipe's ID and linking
+// ingredients to their canonical entry.
+func insertRecipeChildren(ctx context.Context, tx *sqlx.Tx, recipe *models.Recipe) error {
+	ingredientQuery := `INSERT INTO recipe_ingredients(recipe_id, ingredient_id, position, quantity, unit, name, note)
+			  VALUES(:recipe_id, :ingredient_id, :position, :quantity, :unit, :name, :note)`
+	for i := range recipe.Ingredients {
+		recipe.Ingredients[i].RecipeID = recipe.ID
+
+		if recipe.Ingredients[i].CanonicalName != "" {
+			ingredientID, err := upsertIngredient(ctx, tx, recipe.Ingredients[i].CanonicalName)
+			if err != nil {
+				return err
+			}
+			recipe.Ingredients[i].IngredientID = &ingredientID
+		}
+
+		if _, err := tx.NamedExecContext(ctx, ingredientQuery, &recipe.Ingredients[i]); err != nil {
+			return logger.LogErrorWithFields(err, "failed to save recipe ingredient", map[string]interface{}{
+				"recipe_id": recipe.ID,
+				"name":      recipe.Ingredients[i].Name,
+			})
+		}
+	}
+
+	stepQuery := `INSERT INTO recipe_steps(recipe_id, step_number, instruction)
+			  VALUES(:recipe_id, :step_number, :instruction)`
+	for i := range recipe.Steps {
+		recipe.Steps[i].RecipeID = recipe.ID
+		if _, err := tx.NamedExecContext(ctx, stepQuery, &recipe.Steps[i]); err != nil {
+			return logger.LogErrorWithFields(err, "failed to save recipe step", map[string]interface{}{
+				"recipe_id":   recipe.ID,
+				"step_number": recipe.Steps[i].StepNumber,
+			})
+		}
+	}
+
+	return nil
+}
+
+func (r *recipeRepository) GetByPostID(ctx context.Context, postID int) (*models.Recipe, error) {
+	var recipe models.Recipe
+	query := `SELECT id, post_id, servings, prep_time, cook_time, total_time, difficulty, created_at, updated_at
+			  FROM recipes WHERE post_id = ?`
+
+	err := r.db.Read.GetContext(ctx, &recipe, query, postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("recipe not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve recipe", map[string]interface{}{
+			"post_id": postID,
+		})
+	}
+
+	recipes := map[int]*models.Recipe{recipe.ID: &recipe}
+	if err := r.loadChildren(ctx, recipes); err != nil {
+		return nil, err
+	}
+
+	return &recipe, nil
+}
+
+// GetByPostIDs returns the recipes attached to the given posts keyed by post ID.
+// Posts without a recipe are simply absent from the map.
+func (r *recipeRepository) GetByPostIDs(ctx context.Context, postIDs []int) (map[int]*models.Recipe, error) {
+	byPost := make(map[int]*models.Recipe)
+	if len(postIDs) == 0 {
+		return byPost, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT id, post_id, servings, prep_time, cook_time, total_time, difficulty, created_at, updated_at
+			  FROM recipes WHERE post_id IN (?)`, postIDs)
+	if err != nil {
+		return nil, logger.LogError(err, "failed to build recipe query")
+	}
+
+	var rows []models.Recipe
+	if err := r.db.Read.SelectContext(ctx, &rows, r.db.Read.Rebind(query), args...); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve recipe list")
+	}
+
+	byID := make(map[int]*models.Recipe, len(rows))
+	for i := range rows {
+		byID[rows[i].ID] = &rows[i]
+		byPost[rows[i].PostID] = &rows[i]
+	}
+
+	if err := r.loadChildren(ctx, byID); err != nil {
+		return nil, err
+	}
+
+	return byPost, nil
+}
+
+// loadChildren fills Ingredients and Steps for recipes keyed by recipe ID,
+// keeping both lists in their stored order.
+func (r *recipeRepository) loadChildren(ctx context.Context, recipes map[int]*models.Recipe) error {
+	if len(recipes) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(recipes))
+	for id, recipe := range recipes {
+		ids = append(ids, id)
+		recipe.Ingredients = make([]models.RecipeIngredient, 0)
+		recipe.Steps = make([]models.RecipeStep, 0)
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT recipe_ingredients.id, recipe_ingredients.recipe_id, recipe_ingredients.ingredient_id,
+			COALESCE(ingredients.name, '') AS canonical_name, recipe_ingredients.position,
+			recipe_ingredients.quantity, recipe_ingredients.unit, recipe_ingredients.name, recipe_ingredients.note
+		FROM recipe_ingredients
+		LEFT JOIN ingredients ON ingredients.id = recipe_ingredients.ingredient_id
+		WHERE recipe_ingredients.recipe_id IN (?)
+		ORDER BY recipe_ingredients.recipe_id, recipe_ingredients.position`, ids)
+	if err != nil {
+		return logger.LogError(err, "failed to build ingredient query")
+	}
+
+	var ingredients []models.RecipeIngredient
+	if err := r.db.Read.SelectContext(ctx, &ingredients, r.db.Read.Rebind(query), args...); err != nil {
+		return logger.LogError(err, "Failed to retrieve recipe ingredients")
+	}
+	for _, ingredient := range ingredients {
+		recipe := recipes[ingredient.RecipeID]
+		recipe.Ingredients = append(recipe.Ingredients, ingredient)
+	}
+
+	query, args, err = sqlx.In(`SELECT id, recipe_id, step_number, instruction
+			  FROM recipe_steps WHERE recipe_id IN (?) ORDER BY recipe_id, step_number`, ids)
+	if err != nil {
+		return logger.LogError(err, "failed to build step query")
+	}
+
+	var steps []models.RecipeStep
+	if err := r.db.Read.SelectContext(ctx, &steps, r.db.Read.Rebind(query), args...); err != nil {
+		return logger.LogError(err, "Failed to retrieve recipe steps")
+	}
+	for _, step := range steps {
+		recipe := recipes[step.RecipeID]
+		recipe.Steps = append(recipe.Steps, step)
+	}
+
+	return nil
+}
+
+// upsertIngredient returns the ID of the canonical ingredient, creating it if
+// needed. LAST_INSERT_ID(id) makes MySQL report the existing row's ID on a
+// duplicate key.
+func upsertIngredient(ctx context.Context, execer sqlx.ExecerContext, canonicalName string) (int, error) {
+	query := `INSERT INTO ingredients(name, created_at) VALUES(?, NOW())
+			  ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`
+	result, err := execer.ExecContext(ctx, query, canonicalName)
+	if err != nil {
+		return 0, logger.LogErrorWithFields(err, "failed to save ingredient", map[string]interface{}{
+			"name": canonicalName,
+		})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, logger.LogError(err, "failed to read ingredient id")
+	}
+	return int(id), nil
+}
+
+// GetUnlinkedIngredients returns recipe ingredient rows that have not been
+// linked to a canonical ingredient yet, e.g. rows saved before linking existed.
+func (r *recipeRepository) GetUnlinkedIngredients(ctx context.Context, limit int) ([]models.RecipeIngredient, error) {
+	ingredients := make([]models.RecipeIngredient, 0)
+	query := `SELECT id, recipe_id, position, quantity, unit, name, note
+			  FROM recipe_ingredients WHERE ingredient_id IS NULL ORDER BY id LIMIT ?`
+
+	if err := r.db.Read.SelectContext(ctx, &ingredients, query, limit); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve unlinked ingredients")
+	}
+	return ingredients, nil
+}
+
+func (r *recipeRepository) LinkIngredient(ctx context.Context, recipeIngredientID int, canonicalName string) error {
+	ingredientID, err := upsertIngredient(ctx, r.db.Write, canonicalName)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE recipe_ingredients SET ingredient_id = ? WHERE id = ?`
+	if _, err := r.db.Write.ExecContext(ctx, query, ingredientID, recipeIngredientID); err != nil {
+		return logger.LogErrorWithFields(err, "failed to link recipe ingredient", map[string]interface{}{
+			"recipe_ingredient_id": recipeIngredientID,
+		})
+	}
+	return nil
+}
+
+// MatchByIngredients ranks recipes by the share of their ingredients found in
+// have. Ingredients in ignore (pantry staples) count neither for nor against
+// a recipe, and recipes using anything in exclude are left out entirely.
+func (r *recipeRepository) MatchByIngredients(ctx context.Context, have, exclude, ignore []string, limit int, offset int) ([]models.RecipeMatch, error) {
+	matches := make([]models.RecipeMatch, 0)
+
+	query := `
+		SELECT recipes.id AS recipe_id, posts.id AS post_id, posts.title, posts.slug, posts.image, posts.created_at, users.username,
+			COUNT(DISTINCT ingredients.id) AS total_count,
+			COUNT(DISTINCT CASE WHEN ingredients.name IN (?) THEN ingredients.id END) AS matched_count,
+			COUNT(DISTINCT CASE WHEN ingredients.name IN (?) THEN ingredients.id END) / COUNT(DISTINCT ingredients.id) AS coverage
+		FROM recipes
+		JOIN posts ON posts.id = recipes.post_id
+		JOIN users ON users.id = posts.user_id
+		JOIN recipe_ingredients ON recipe_ingredients.recipe_id = recipes.id
+		JOIN ingredients ON ingredients.id = recipe_ingredients.ingredient_id
+		WHERE posts.status = 'published' AND posts.deleted_at IS NULL AND users.deleted_at IS NULL
+			AND ingredients.name NOT IN (?)`
+	args := []interface{}{have, have, ignore}
+
+	if len(exclude) > 0 {
+		query += ` AND recipes.id NOT IN (
+			SELECT recipe_ingredients.recipe_id FROM recipe_ingredients
+			JOIN ingredients ON ingredients.id = recipe_ingredients.ingredient_id
+			WHERE ingredients.name IN (?))`
+		args = append(args, exclude)
+	}
+
+	query += `
+		GROUP BY recipes.id
+		HAVING matched_count > 0
+		ORDER BY coverage DESC, matched_count DESC, posts.created_at DESC
+		LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return nil, logger.LogError(err, "failed to build recipe match query")
+	}
+
+	if err := r.db.Read.SelectContext(ctx, &matches, r.db.Read.Rebind(query), args...); err != nil {
+		return nil, logger.LogError(err, "Failed to match recipes by ingredients")
+	}
+
+	if len(matches) == 0 {
+		return matches, nil
+	}
+
+	recipeIDs := make([]int, 0, len(matches))
+	byRecipe := make(map[int]*models.RecipeMatch, len(matches))
+	for i := range matches {
+		matches[i].Missing = make([]string, 0)
+		recipeIDs = append(recipeIDs, matches[i].RecipeID)
+		byRecipe[matches[i].RecipeID] = &matches[i]
+	}
+
+	known := append(append([]string{}, have...), ignore...)
+	missingQuery, missingArgs, err := sqlx.In(`
+		SELECT recipe_ingredients.recipe_id, recipe_ingredients.name
+		FROM recipe_ingredients
+		JOIN ingredients ON ingredients.id = recipe_ingredients.ingredient_id
+		WHERE recipe_ingredients.recipe_id IN (?) AND ingredients.name NOT IN (?)
+		ORDER BY recipe_ingredients.recipe_id, recipe_ingredients.position`, recipeIDs, known)
+	if err != nil {
+		return nil, logger.LogError(err, "failed to build missing ingredient query")
+	}
+
+	var missing []struct {
+		RecipeID int    `db:"recipe_id"`
+		Name     string `db:"name"`
+	}
+	if err := r.db.Read.SelectContext(ctx, &missing, r.db.Read.Rebind(missingQuery), missingArgs...); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve missing ingredients")
+	}
+
+	for _, row := range missing {
+		match := byRecipe[row.RecipeID]
+		match.Missing = append(match.Missing, row.Name)
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type RevisionRepository interface {
+	Create(ctx context.Context, revision *models.PostRevision) error
+	Count(ctx context.Context, postID int) (int, error)
+	GetByPostID(ctx context.Context, postID int) ([]models.PostRevision, error)
+	GetByRevision(ctx context.Context, postID int, revision int) (*models.PostRevision, error)
+}
+
+type revisionRepository struct {
+	db *config.Database
+}
+
+func NewRevisionRepository(db *config.Database) RevisionRepository {
+	return &revisionRepository{db: db}
+}
+
+// Create stores a snapshot as the post's next revision number and sets it on
+// revision.
+func (r *revisionRepository) Create(ctx context.Context, revision *models.PostRevision) error {
+	tx, err := r.db.Write.BeginTxx(ctx, nil)
+	if err != nil {
+		return logger.LogError(err, "failed to start revision transaction")
+	}
+	defer tx.Rollback()
+
+	var next int
+	query := `SELECT COALESCE(MAX(revision), 0) + 1 FROM post_revisions WHERE post_id = ? FOR UPDATE`
+	if err := tx.GetContext(ctx, &next, query, revision.PostID); err != nil {
+		return logger.LogErrorWithFields(err, "failed to read next revision number", map[string]interface{}{
+			"post_id": revision.PostID,
+		})
+	}
+	revision.Revision = next
+
+	insert := `INSERT INTO post_revisions(post_id, revision, editor_id, title, content, image, created_at)
+			   VALUES(:post_id, :revision, :editor_id, :title, :content, :image, NOW())`
+	result, err := tx.NamedExecContext(ctx, insert, revision)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to save post revision into database", map[string]interface{}{
+			"post_id": revision.PostID,
+		})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new revision id")
+	}
+	revision.ID = int(id)
+
+	if err := tx.Commit(); err != nil {
+		return logger.LogError(err, "failed to commit revision transaction")
+	}
+	return nil
+}
+
+func (r *revisionRepository) Count(ctx context.Context, postID int) (int, error) {
+	var count int
+	if err := r.db.Read.GetContext(ctx, &count, `SELECT COUNT(*) FROM post_revisions WHERE post_id = ?`, postID); err != nil {
+		return 0, logger.LogErrorWithFields(err, "Failed to count post revisions", map[string]interface{}{
+			"post_id": postID,
+		})
+	}
+	return count, nil
+}
+
+// GetByPostID lists a post's revisions, newest first, without their content.
+func (r *revisionRepository) GetByPostID(ctx context.Context, postID int) ([]models.PostRevision, error) {
+	revisions := make([]models.PostRevision, 0)
+	query := `
+		SELECT post_revisions.id, post_revisions.post_id, post_revisions.revision, post_revisions.editor_id,
+			users.username AS editor, post_revisions.title, post_revisions.image, post_revisions.created_at
+		FROM post_revisions
+		LEFT JOIN users ON users.id = post_revisions.editor_id
+		WHERE post_revisions.post_id = ?
+		ORDER BY post_revisions.revision DESC`
+
+	if err := r.db.Read.SelectContext(ctx, &revisions, query, postID); err != nil {
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve post revisions", map[string]interface{}{
+			"post_id": postID,
+		})
+	}
+
+	return revisions, nil
+}
+
+func (r *revisionRepository) GetByRevision(ctx context.Context, postID int, revision int) (*models.PostRevision, error) {
+	var snapshot models.PostRevision
+	query := `
+		SELECT post_revisions.id, post_revisions.post_id, post_revisions.revision, post_revisions.editor_id,
+			users.username AS editor, post_revisions.title, post_revisions.content, post_revisions.image,
+			post_revisions.created_at
+		FROM post_revisions
+		LEFT JOIN users ON users.id = post_revisions.editor_id
+		WHERE post_revisions.post_id = ? AND post_revisions.revision = ?`
+
+	if err := r.db.Read.GetContext(ctx, &snapshot, query, postID, revision); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("revision not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve post revision", map[string]interface{}{
+			"post_id":  postID,
+			"revision": revision,
+		})
+	}
+
+	return &snapshot, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type RoleRepository interface {
+	GetAll(ctx context.Context) ([]models.Role, error)
+	SetTwoFactorRequired(ctx context.Context, role string, required bool) error
+}
+
+type roleRepository struct {
+	db *config.Database
+}
+
+func NewRoleRepository(db *config.Database) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// GetAll lists every role with its permissions.
+func (r *roleRepository) GetAll(ctx context.Context) ([]models.Role, error) {
+	roles := make([]models.Role, 0)
+	if err := r.db.Read.SelectContext(ctx, &roles, `SELECT name, description, require_2fa, created_at FROM roles ORDER BY name`); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve roles")
+	}
+
+	var grants []models.RolePermission
+	if err := r.db.Read.SelectContext(ctx, &grants, `SELECT role, permission FROM role_permissions ORDER BY permission`); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve role permissions")
+	}
+
+	byRole := make(map[string][]string)
+	for _, grant := range grants {
+		byRole[grant.Role] = append(byRole[grant.Role], grant.Permission)
+	}
+	for i := range roles {
+		roles[i].Permissions = byRole[roles[i].Name]
+		if roles[i].Permissions == nil {
+			roles[i].Permissions = make([]string, 0)
+		}
+	}
+
+	return roles, nil
+}
+
+func (r *roleRepository) SetTwoFactorRequired(ctx context.Context, role string, required bool) error {
+	result, err := r.db.Write.ExecContext(ctx, `UPDATE roles SET require_2fa = ? WHERE name = ?`, required, role)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to update role", map[string]interface{}{
+			"role": role,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		var exists bool
+		if err := r.db.Write.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM roles WHERE name = ?)`, role); err != nil {
+			return logger.LogError(err, "failed to check role")
+		}
+		if !exists {
+			return logger.ValidationError("role not found")
+		}
+	}
+	return nil
+}
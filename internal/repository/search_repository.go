@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+// SearchRepository finds posts matching a free-text query, best match first,
+// and reports the total number of matches for pagination. The MySQL
+// implementation uses FULLTEXT indexes; any other index (e.g. an in-process
+// one) only has to honour the same contract.
+type SearchRepository interface {
+	SearchPosts(ctx context.Context, query string, limit int, offset int) ([]models.SearchResult, int, error)
+}
+
+type searchRepository struct {
+	db *config.Database
+}
+
+func NewSearchRepository(db *config.Database) SearchRepository {
+	return &searchRepository{db: db}
+}
+
+// searchCondition matches live, published posts on their title and content, or on
+// the ingredient names of their structured recipe.
+const searchCondition = `
+		posts.status = 'published' AND posts.deleted_at IS NULL AND users.deleted_at IS NULL AND
+		(MATCH(posts.title, posts.content) AGAINST (? IN NATURAL LANGUAGE MODE)
+		OR posts.id IN (
+			SELECT recipes.post_id FROM recipes
+			JOIN recipe_ingredients ON recipe_ingredients.recipe_id = recipes.id
+			WHERE MATCH(recipe_ingredients.name) AGAINST (? IN NATURAL LANGUAGE MODE)))`
+
+func (r *searchRepository) SearchPosts(ctx context.Context, query string, limit int, offset int) ([]models.SearchResult, int, error) {
+	results := make([]models.SearchResult, 0)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM posts JOIN users ON posts.user_id = users.id WHERE` + searchCondition
+	if err := r.db.Read.GetContext(ctx, &total, countQuery, query, query); err != nil {
+		return nil, 0, logger.LogErrorWithFields(err, "Failed to count search results", map[string]interface{}{
+			"query": query,
+		})
+	}
+
+	if total == 0 {
+		return results, 0, nil
+	}
+
+	selectQuery := `
+		SELECT posts.id, posts.title, posts.slug, posts.content, posts.image, posts.created_at, users.username,
+			MATCH(posts.title, posts.content) AGAINST (? IN NATURAL LANGUAGE MODE)
+			+ COALESCE((
+				SELECT MAX(MATCH(recipe_ingredients.name) AGAINST (? IN NATURAL LANGUAGE MODE))
+				FROM recipes
+				JOIN recipe_ingredients ON recipe_ingredients.recipe_id = recipes.id
+				WHERE recipes.post_id = posts.id), 0) AS score
+		FROM posts
+		JOIN users ON posts.user_id = users.id
+		WHERE` + searchCondition + `
+		ORDER BY score DESC, posts.created_at DESC
+		LIMIT ? OFFSET ?`
+
+	err := r.db.Read.SelectContext(ctx, &results, selectQuery, query, query, query, query, limit, offset)
+	if err != nil {
+		return nil, 0, logger.LogErrorWithFields(err, "Failed to search posts", map[string]interface{}{
+			"query": query,
+		})
+	}
+
+	return results, total, nil
+}
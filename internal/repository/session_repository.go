@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	GetByID(ctx context.Context, id int) (*models.Session, error)
+	GetActiveByUser(ctx context.Context, userID int) ([]models.Session, error)
+	Extend(ctx context.Context, id int, expiresAt time.Time) error
+	Revoke(ctx context.Context, id int) error
+	RevokeForUser(ctx context.Context, userID int, id int) error
+	RevokeAllForUser(ctx context.Context, userID int, exceptID int) error
+	DeleteExpired(ctx context.Context, before time.Time) (int, error)
+
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id int) error
+}
+
+type sessionRepository struct {
+	db *config.Database
+}
+
+func NewSessionRepository(db *config.Database) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	query := `INSERT INTO sessions(user_id, user_agent, ip_address, created_at, last_used_at, expires_at)
+			  VALUES(:user_id, :user_agent, :ip_address, NOW(), NOW(), :expires_at)`
+	result, err := r.db.Write.NamedExecContext(ctx, query, session)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to save session into database", map[string]interface{}{
+			"user_id": session.UserID,
+		})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new session id")
+	}
+	session.ID = int(id)
+	return nil
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id int) (*models.Session, error) {
+	var session models.Session
+	query := `SELECT id, user_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at
+			  FROM sessions WHERE id = ?`
+
+	if err := r.db.Read.GetContext(ctx, &session, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("session not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve session", map[string]interface{}{
+			"session_id": id,
+		})
+	}
+
+	return &session, nil
+}
+
+// GetActiveByUser lists a user's sessions that are neither revoked nor
+// expired, most recently used first.
+func (r *sessionRepository) GetActiveByUser(ctx context.Context, userID int) ([]models.Session, error) {
+	sessions := make([]models.Session, 0)
+	query := `SELECT id, user_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at
+			  FROM sessions WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+			  ORDER BY last_used_at DESC, id DESC`
+
+	if err := r.db.Read.SelectContext(ctx, &sessions, query, userID, time.Now()); err != nil {
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve sessions", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	return sessions, nil
+}
+
+// Extend records that a session was used and moves its expiry to expiresAt.
+func (r *sessionRepository) Extend(ctx context.Context, id int, expiresAt time.Time) error {
+	query := `UPDATE sessions SET last_used_at = NOW(), expires_at = ? WHERE id = ?`
+	if _, err := r.db.Write.ExecContext(ctx, query, expiresAt, id); err != nil {
+		return logger.LogErrorWithFields(err, "failed to extend session", map[string]interface{}{
+			"session_id": id,
+		})
+	}
+	return nil
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id int) error {
+	query := `UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`
+	if _, err := r.db.Write.ExecContext(ctx, query, time.Now(), id); err != nil {
+		return logger.LogErrorWithFields(err, "failed to revoke session", map[string]interface{}{
+			"session_id": id,
+		})
+	}
+	return nil
+}
+
+// RevokeForUser revokes a session only if it belongs to the user.
+func (r *sessionRepository) RevokeForUser(ctx context.Context, userID int, id int) error {
+	query := `UPDATE sessions SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`
+
+	result, err := r.db.Write.ExecContext(ctx, query, time.Now(), id, userID)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to revoke session", map[string]interface{}{
+			"session_id": id,
+			"user_id":    userID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("session not found")
+	}
+	return nil
+}
+
+// RevokeAllForUser revokes every session of a user except exceptID, which may
+// be 0 to revoke them all.
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID int, exceptID int) error {
+	query := `UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND id <> ? AND revoked_at IS NULL`
+	if _, err := r.db.Write.ExecContext(ctx, query, time.Now(), userID, exceptID); err != nil {
+		return logger.LogErrorWithFields(err, "failed to revoke sessions", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	return nil
+}
+
+// DeleteExpired removes sessions that expired or were revoked before the
+// given time, together with their refresh tokens. Both times are written from
+// Go, like before.
+func (r *sessionRepository) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	query := `DELETE FROM sessions WHERE expires_at < ? OR revoked_at < ?`
+
+	result, err := r.db.Write.ExecContext(ctx, query, before, before)
+	if err != nil {
+		return 0, logger.LogError(err, "failed to delete expired sessions")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, logger.LogError(err, "failed to read deleted session count")
+	}
+	return int(rows), nil
+}
+
+func (r *sessionRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens(session_id, token_hash, created_at, expires_at)
+			  VALUES(:session_id, :token_hash, NOW(), :expires_at)`
+	result, err := r.db.Write.NamedExecContext(ctx, query, token)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to save refresh token into database", map[string]interface{}{
+			"session_id": token.SessionID,
+		})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new refresh token id")
+	}
+	token.ID = int(id)
+	return nil
+}
+
+func (r *sessionRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	query := `SELECT id, session_id, token_hash, created_at, expires_at, used_at FROM refresh_tokens WHERE token_hash = ?`
+
+	// Read from the writer: a token rotated a moment ago must be seen as used.
+	if err := r.db.Write.GetContext(ctx, &token, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("refresh token not found")
+		}
+		return nil, logger.LogError(err, "Failed to retrieve refresh token")
+	}
+
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed marks a token as exchanged. It fails when the token was
+// already used, so two concurrent refreshes cannot both succeed.
+func (r *sessionRepository) MarkRefreshTokenUsed(ctx context.Context, id int) error {
+	query := `UPDATE refresh_tokens SET used_at = NOW() WHERE id = ? AND used_at IS NULL`
+
+	result, err := r.db.Write.ExecContext(ctx, query, id)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to mark refresh token as used", map[string]interface{}{
+			"refresh_token_id": id,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("refresh token already used")
+	}
+	return nil
+}
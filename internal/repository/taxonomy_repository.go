@@ -0,0 +1,429 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type TaxonomyRepository interface {
+	CreateCategory(ctx context.Context, category *models.Category) error
+	UpdateCategory(ctx context.Context, category *models.Category) error
+	DeleteCategory(ctx context.Context, id int) error
+	GetCategoryByID(ctx context.Context, id int) (*models.Category, error)
+	GetCategories(ctx context.Context) ([]models.Category, error)
+
+	CreateCuisine(ctx context.Context, cuisine *models.Term) error
+	UpdateCuisine(ctx context.Context, cuisine *models.Term) error
+	DeleteCuisine(ctx context.Context, id int) error
+	GetCuisines(ctx context.Context) ([]models.Term, error)
+
+	CreateTag(ctx context.Context, tag *models.Term) error
+	UpdateTag(ctx context.Context, tag *models.Term) error
+	DeleteTag(ctx context.Context, id int) error
+	GetTags(ctx context.Context) ([]models.Term, error)
+	SearchTags(ctx context.Context, prefix string, limit int) ([]models.Term, error)
+	GetTagBySlug(ctx context.Context, slug string) (*models.Term, error)
+
+	CountExisting(ctx context.Context, table string, ids []int) (int, error)
+	SetPostCategories(ctx context.Context, postID int, categoryIDs []int) error
+	SetPostCuisines(ctx context.Context, postID int, cuisineIDs []int) error
+	SetPostTags(ctx context.Context, postID int, tagIDs []int) error
+	GetByPostIDs(ctx context.Context, postIDs []int) (map[int]*models.PostTaxonomy, error)
+}
+
+// Taxonomy table names. CountExisting accepts categories and cuisines.
+const (
+	CategoriesTable = "categories"
+	CuisinesTable   = "cuisines"
+	TagsTable       = "tags"
+)
+
+type taxonomyRepository struct {
+	db *config.Database
+}
+
+func NewTaxonomyRepository(db *config.Database) TaxonomyRepository {
+	return &taxonomyRepository{db: db}
+}
+
+// isDuplicateEntry reports whether err is MySQL's unique constraint violation.
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+func (r *taxonomyRepository) CreateCategory(ctx context.Context, category *models.Category) error {
+	query := `INSERT INTO categories(parent_id, name, slug, created_at) VALUES(:parent_id, :name, :slug, NOW())`
+	result, err := r.db.Write.NamedExecContext(ctx, query, category)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return logger.ValidationError("category already exists")
+		}
+		return logger.LogErrorWithFields(err, "failed to save category into database", map[string]interface{}{
+			"name": category.Name,
+		})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new category id")
+	}
+	category.ID = int(id)
+	return nil
+}
+
+func (r *taxonomyRepository) UpdateCategory(ctx context.Context, category *models.Category) error {
+	query := `UPDATE categories SET parent_id = :parent_id, name = :name, slug = :slug WHERE id = :id`
+	_, err := r.db.Write.NamedExecContext(ctx, query, category)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return logger.ValidationError("category already exists")
+		}
+		return logger.LogErrorWithFields(err, "failed to update category in database", map[string]interface{}{
+			"category_id": category.ID,
+		})
+	}
+	return nil
+}
+
+func (r *taxonomyRepository) DeleteCategory(ctx context.Context, id int) error {
+	return r.deleteTerm(ctx, CategoriesTable, id)
+}
+
+func (r *taxonomyRepository) GetCategoryByID(ctx context.Context, id int) (*models.Category, error) {
+	var category models.Category
+	query := `SELECT id, parent_id, name, slug, created_at FROM categories WHERE id = ?`
+
+	err := r.db.Read.GetContext(ctx, &category, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("category not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve category", map[string]interface{}{
+			"category_id": id,
+		})
+	}
+
+	return &category, nil
+}
+
+func (r *taxonomyRepository) GetCategories(ctx context.Context) ([]models.Category, error) {
+	categories := make([]models.Category, 0)
+	query := `
+		SELECT categories.id, categories.parent_id, categories.name, categories.slug, categories.created_at,
+			COUNT(posts.id) AS post_count
+		FROM categories
+		LEFT JOIN post_categories ON post_categories.category_id = categories.id
+		LEFT JOIN posts ON posts.id = post_categories.post_id AND posts.status = 'published' AND posts.deleted_at IS NULL
+		GROUP BY categories.id
+		ORDER BY categories.name`
+
+	if err := r.db.Read.SelectContext(ctx, &categories, query); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve category list")
+	}
+	return categories, nil
+}
+
+func (r *taxonomyRepository) CreateCuisine(ctx context.Context, cuisine *models.Term) error {
+	return r.createTerm(ctx, CuisinesTable, cuisine)
+}
+
+func (r *taxonomyRepository) UpdateCuisine(ctx context.Context, cuisine *models.Term) error {
+	return r.updateTerm(ctx, CuisinesTable, cuisine)
+}
+
+func (r *taxonomyRepository) DeleteCuisine(ctx context.Context, id int) error {
+	return r.deleteTerm(ctx, CuisinesTable, id)
+}
+
+func (r *taxonomyRepository) GetCuisines(ctx context.Context) ([]models.Term, error) {
+	return r.listTerms(ctx, `
+		SELECT cuisines.id, cuisines.name, cuisines.slug, cuisines.created_at,
+			COUNT(posts.id) AS post_count
+		FROM cuisines
+		LEFT JOIN post_cuisines ON post_cuisines.cuisine_id = cuisines.id
+		LEFT JOIN posts ON posts.id = post_cuisines.post_id AND posts.status = 'published' AND posts.deleted_at IS NULL
+		GROUP BY cuisines.id
+		ORDER BY cuisines.name`)
+}
+
+func (r *taxonomyRepository) CreateTag(ctx context.Context, tag *models.Term) error {
+	return r.createTerm(ctx, TagsTable, tag)
+}
+
+func (r *taxonomyRepository) UpdateTag(ctx context.Context, tag *models.Term) error {
+	return r.updateTerm(ctx, TagsTable, tag)
+}
+
+func (r *taxonomyRepository) DeleteTag(ctx context.Context, id int) error {
+	return r.deleteTerm(ctx, TagsTable, id)
+}
+
+func (r *taxonomyRepository) GetTags(ctx context.Context) ([]models.Term, error) {
+	return r.listTerms(ctx, `
+		SELECT tags.id, tags.name, tags.slug, tags.created_at,
+			COUNT(posts.id) AS post_count
+		FROM tags
+		LEFT JOIN post_tags ON post_tags.tag_id = tags.id
+		LEFT JOIN posts ON posts.id = post_tags.post_id AND posts.status = 'published' AND posts.deleted_at IS NULL
+		GROUP BY tags.id
+		ORDER BY tags.name`)
+}
+
+// SearchTags returns tags whose name or slug starts with prefix, most used first.
+func (r *taxonomyRepository) SearchTags(ctx context.Context, prefix string, limit int) ([]models.Term, error) {
+	likePrefix := prefix + "%"
+	return r.listTerms(ctx, `
+		SELECT tags.id, tags.name, tags.slug, tags.created_at,
+			COUNT(posts.id) AS post_count
+		FROM tags
+		LEFT JOIN post_tags ON post_tags.tag_id = tags.id
+		LEFT JOIN posts ON posts.id = post_tags.post_id AND posts.status = 'published' AND posts.deleted_at IS NULL
+		WHERE tags.name LIKE ? OR tags.slug LIKE ?
+		GROUP BY tags.id
+		ORDER BY post_count DESC, tags.name
+		LIMIT ?`, likePrefix, likePrefix, limit)
+}
+
+func (r *taxonomyRepository) GetTagBySlug(ctx context.Context, slug string) (*models.Term, error) {
+	var tag models.Term
+	query := `SELECT id, name, slug, created_at FROM tags WHERE slug = ?`
+
+	err := r.db.Read.GetContext(ctx, &tag, query, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("tag not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve tag", map[string]interface{}{
+			"slug": slug,
+		})
+	}
+
+	return &tag, nil
+}
+
+// CountExisting returns how many of ids exist in the given taxonomy table.
+func (r *taxonomyRepository) CountExisting(ctx context.Context, table string, ids []int) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if table != CategoriesTable && table != CuisinesTable {
+		return 0, logger.SystemError("unknown taxonomy table")
+	}
+
+	query, args, err := sqlx.In(`SELECT COUNT(*) FROM `+table+` WHERE id IN (?)`, ids)
+	if err != nil {
+		return 0, logger.LogError(err, "failed to build taxonomy query")
+	}
+
+	var count int
+	if err := r.db.Read.GetContext(ctx, &count, r.db.Read.Rebind(query), args...); err != nil {
+		return 0, logger.LogErrorWithFields(err, "Failed to verify taxonomy ids", map[string]interface{}{
+			"table": table,
+		})
+	}
+	return count, nil
+}
+
+func (r *taxonomyRepository) SetPostCategories(ctx context.Context, postID int, categoryIDs []int) error {
+	return r.setPostTerms(ctx, "post_categories", "category_id", postID, categoryIDs)
+}
+
+func (r *taxonomyRepository) SetPostCuisines(ctx context.Context, postID int, cuisineIDs []int) error {
+	return r.setPostTerms(ctx, "post_cuisines", "cuisine_id", postID, cuisineIDs)
+}
+
+func (r *taxonomyRepository) SetPostTags(ctx context.Context, postID int, tagIDs []int) error {
+	return r.setPostTerms(ctx, "post_tags", "tag_id", postID, tagIDs)
+}
+
+// GetByPostIDs loads the categories, cuisines and tags of several posts at
+// once. Every requested post gets an entry, with empty lists if untagged.
+func (r *taxonomyRepository) GetByPostIDs(ctx context.Context, postIDs []int) (map[int]*models.PostTaxonomy, error) {
+	byPost := make(map[int]*models.PostTaxonomy, len(postIDs))
+	if len(postIDs) == 0 {
+		return byPost, nil
+	}
+
+	for _, id := range postIDs {
+		byPost[id] = &models.PostTaxonomy{
+			Categories: make([]models.Category, 0),
+			Cuisines:   make([]models.Term, 0),
+			Tags:       make([]models.Term, 0),
+		}
+	}
+
+	var categories []struct {
+		PostID int `db:"post_id"`
+		models.Category
+	}
+	query, args, err := sqlx.In(`
+		SELECT post_categories.post_id, categories.id, categories.parent_id, categories.name, categories.slug, categories.created_at
+		FROM post_categories
+		JOIN categories ON categories.id = post_categories.category_id
+		WHERE post_categories.post_id IN (?)
+		ORDER BY categories.name`, postIDs)
+	if err != nil {
+		return nil, logger.LogError(err, "failed to build category query")
+	}
+	if err := r.db.Read.SelectContext(ctx, &categories, r.db.Read.Rebind(query), args...); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve post categories")
+	}
+	for _, row := range categories {
+		byPost[row.PostID].Categories = append(byPost[row.PostID].Categories, row.Category)
+	}
+
+	cuisines, err := r.termsByPost(ctx, "post_cuisines", CuisinesTable, "cuisine_id", postIDs)
+	if err != nil {
+		return nil, err
+	}
+	for postID, terms := range cuisines {
+		byPost[postID].Cuisines = terms
+	}
+
+	tags, err := r.termsByPost(ctx, "post_tags", TagsTable, "tag_id", postIDs)
+	if err != nil {
+		return nil, err
+	}
+	for postID, terms := range tags {
+		byPost[postID].Tags = terms
+	}
+
+	return byPost, nil
+}
+
+// The helpers below are shared by categories, cuisines and tags. Table and
+// column names always come from constants in this file, never from input.
+
+func (r *taxonomyRepository) createTerm(ctx context.Context, table string, term *models.Term) error {
+	query := `INSERT INTO ` + table + `(name, slug, created_at) VALUES(:name, :slug, NOW())`
+	result, err := r.db.Write.NamedExecContext(ctx, query, term)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return logger.ValidationError(term.Name + " already exists")
+		}
+		return logger.LogErrorWithFields(err, "failed to save taxonomy term into database", map[string]interface{}{
+			"table": table,
+			"name":  term.Name,
+		})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new taxonomy term id")
+	}
+	term.ID = int(id)
+	return nil
+}
+
+func (r *taxonomyRepository) updateTerm(ctx context.Context, table string, term *models.Term) error {
+	query := `UPDATE ` + table + ` SET name = :name, slug = :slug WHERE id = :id`
+	result, err := r.db.Write.NamedExecContext(ctx, query, term)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return logger.ValidationError(term.Name + " already exists")
+		}
+		return logger.LogErrorWithFields(err, "failed to update taxonomy term in database", map[string]interface{}{
+			"table":   table,
+			"term_id": term.ID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		var exists bool
+		if err := r.db.Read.GetContext(ctx, &exists, `SELECT COUNT(*) > 0 FROM `+table+` WHERE id = ?`, term.ID); err == nil && !exists {
+			return logger.ValidationError("term not found")
+		}
+	}
+	return nil
+}
+
+func (r *taxonomyRepository) deleteTerm(ctx context.Context, table string, id int) error {
+	result, err := r.db.Write.ExecContext(ctx, `DELETE FROM `+table+` WHERE id = ?`, id)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to delete taxonomy term", map[string]interface{}{
+			"table":   table,
+			"term_id": id,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("term not found")
+	}
+	return nil
+}
+
+func (r *taxonomyRepository) listTerms(ctx context.Context, query string, args ...interface{}) ([]models.Term, error) {
+	terms := make([]models.Term, 0)
+	if err := r.db.Read.SelectContext(ctx, &terms, query, args...); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve taxonomy list")
+	}
+	return terms, nil
+}
+
+func (r *taxonomyRepository) setPostTerms(ctx context.Context, joinTable, column string, postID int, ids []int) error {
+	tx, err := r.db.Write.BeginTxx(ctx, nil)
+	if err != nil {
+		return logger.LogError(err, "failed to start taxonomy transaction")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+joinTable+` WHERE post_id = ?`, postID); err != nil {
+		return logger.LogErrorWithFields(err, "failed to clear post taxonomy", map[string]interface{}{
+			"table":   joinTable,
+			"post_id": postID,
+		})
+	}
+
+	for _, id := range ids {
+		query := `INSERT IGNORE INTO ` + joinTable + `(post_id, ` + column + `) VALUES(?, ?)`
+		if _, err := tx.ExecContext(ctx, query, postID, id); err != nil {
+			return logger.LogErrorWithFields(err, "failed to assign post taxonomy", map[string]interface{}{
+				"table":   joinTable,
+				"post_id": postID,
+				column:    id,
+			})
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return logger.LogError(err, "failed to commit taxonomy transaction")
+	}
+	return nil
+}
+
+func (r *taxonomyRepository) termsByPost(ctx context.Context, joinTable, table, column string, postIDs []int) (map[int][]models.Term, error) {
+	var rows []struct {
+		PostID int `db:"post_id"`
+		models.Term
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT `+joinTable+`.post_id, `+table+`.id, `+table+`.name, `+table+`.slug, `+table+`.created_at
+		FROM `+joinTable+`
+		JOIN `+table+` ON `+table+`.id = `+joinTable+`.`+column+`
+		WHERE `+joinTable+`.post_id IN (?)
+		ORDER BY `+table+`.name`, postIDs)
+	if err != nil {
+		return nil, logger.LogError(err, "failed to build taxonomy query")
+	}
+
+	if err := r.db.Read.SelectContext(ctx, &rows, r.db.Read.Rebind(query), args...); err != nil {
+		return nil, logger.LogErrorWithFields(err, "Failed to retrieve post taxonomy", map[string]interface{}{
+			"table": table,
+		})
+	}
+
+	byPost := make(map[int][]models.Term)
+	for _, row := range rows {
+		byPost[row.PostID] = append(byPost[row.PostID], row.Term)
+	}
+	return byPost, nil
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/rafli2460/culinary-blog-api/internal/config"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type TwoFactorRepository interface {
+	Get(ctx context.Context, userID int) (*models.TwoFactor, error)
+	SetSecret(ctx context.Context, userID int, secret string) error
+	Enable(ctx context.Context, userID int, step int64, codeHashes []string) error
+	Disable(ctx context.Context, userID int) error
+	UseStep(ctx context.Context, userID int, step int64) error
+
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) error
+	CountRecoveryCodes(ctx context.Context, userID int) (int, error)
+}
+
+type twoFactorRepository struct {
+	db *config.Database
+}
+
+func NewTwoFactorRepository(db *config.Database) TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+// Get reads from the primary, since a code must be checked against the
+// latest secret and last used step.
+func (r *twoFactorRepository) Get(ctx context.Context, userID int) (*models.TwoFactor, error) {
+	var twoFactor models.TwoFactor
+	query := `SELECT id, totp_secret, totp_enabled_at, totp_last_step FROM users WHERE id = ? AND deleted_at IS NULL`
+
+	if err := r.db.Write.GetContext(ctx, &twoFactor, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, logger.ValidationError("user not found")
+		}
+		return nil, logger.LogErrorWithFields(err, "failed to retrieve two-factor settings", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	return &twoFactor, nil
+}
+
+// SetSecret starts an enrollment, replacing the secret of an earlier one that
+// was never confirmed.
+func (r *twoFactorRepository) SetSecret(ctx context.Context, userID int, secret string) error {
+	query := `UPDATE users SET totp_secret = ?, totp_last_step = NULL
+			  WHERE id = ? AND totp_enabled_at IS NULL AND deleted_at IS NULL`
+
+	result, err := r.db.Write.ExecContext(ctx, query, secret, userID)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to save two-factor secret", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("two-factor authentication is already enabled")
+	}
+	return nil
+}
+
+// Enable confirms an enrollment with the step of its first code and stores
+// the hashes of its recovery codes.
+func (r *twoFactorRepository) Enable(ctx context.Context, userID int, step int64, codeHashes []string) error {
+	tx, err := r.db.Write.BeginTxx(ctx, nil)
+	if err != nil {
+		return logger.LogError(err, "failed to start two-factor transaction")
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET totp_enabled_at = NOW(), totp_last_step = ?
+			  WHERE id = ? AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL AND deleted_at IS NULL`
+
+	result, err := tx.ExecContext(ctx, query, step, userID)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to enable two-factor authentication", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("two-factor authentication is already enabled")
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return logger.LogError(err, "failed to commit two-factor transaction")
+	}
+	return nil
+}
+
+// Disable forgets the secret and the recovery codes.
+func (r *twoFactorRepository) Disable(ctx context.Context, userID int) error {
+	tx, err := r.db.Write.BeginTxx(ctx, nil)
+	if err != nil {
+		return logger.LogError(err, "failed to start two-factor transaction")
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+		return logger.LogErrorWithFields(err, "failed to disable two-factor authentication", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return logger.LogError(err, "failed to commit two-factor transaction")
+	}
+	return nil
+}
+
+// UseStep records that the code of a time step was used. It fails when that
+// step or a later one was used already, so each code works once.
+func (r *twoFactorRepository) UseStep(ctx context.Context, userID int, step int64) error {
+	query := `UPDATE users SET totp_last_step = ?
+			  WHERE id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)`
+
+	result, err := r.db.Write.ExecContext(ctx, query, step, userID, step)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to record used two-factor code", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("code was already used")
+	}
+	return nil
+}
+
+func (r *twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := r.db.Write.BeginTxx(ctx, nil)
+	if err != nil {
+		return logger.LogError(err, "failed to start recovery code transaction")
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return logger.LogError(err, "failed to commit recovery code transaction")
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return logger.LogErrorWithFields(err, "failed to delete recovery codes", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	for _, codeHash := range codeHashes {
+		query := `INSERT INTO recovery_codes(user_id, code_hash, created_at) VALUES(?, ?, NOW())`
+		if _, err := tx.ExecContext(ctx, query, userID, codeHash); err != nil {
+			return logger.LogErrorWithFields(err, "failed to save recovery code", map[string]interface{}{
+				"user_id": userID,
+			})
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used.
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	query := `UPDATE recovery_codes SET used_at = NOW() WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`
+
+	result, err := r.db.Write.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "failed to use recovery code", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("recovery code not found")
+	}
+	return nil
+}
+
+// CountRecoveryCodes returns how many of a user's recovery codes are unused.
+func (r *twoFactorRepository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL`
+	if err := r.db.Read.GetContext(ctx, &count, query, userID); err != nil {
+		return 0, logger.LogError(err, "failed to count recovery codes")
+	}
+	return count, nil
+}
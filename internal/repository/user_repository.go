@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/rafli2460/culinary-blog-api/internal/config"
@@ -13,12 +14,22 @@ import (
 
 type UserRepository interface {
 	GetByUsername(ctx context.Context, username string) (models.User, error)
+	GetByID(ctx context.Context, userID int) (models.User, error)
+	GetByEmail(ctx context.Context, email string) (models.User, error)
+	UpdatePassword(ctx context.Context, userID int, hashedPassword string) error
 	Create(ctx context.Context, user *models.User) error
+	SetEmail(ctx context.Context, userID int, email string) error
+	MarkEmailVerified(ctx context.Context, userID int, email string) error
 
 	GetAllUsers(ctx context.Context, search string) ([]models.User, error)
 	GetStats(ctx context.Context) (models.UserStats, error)
 	UpdateRole(ctx context.Context, userID int, newRole string) error
 	Delete(ctx context.Context, userID int) error
+
+	GetTrashed(ctx context.Context) ([]models.User, error)
+	GetTrashedBefore(ctx context.Context, cutoff time.Time) ([]int, error)
+	Restore(ctx context.Context, userID int) error
+	Purge(ctx context.Context, userID int) error
 }
 
 type userRepository struct {
@@ -33,7 +44,9 @@ func NewUserRepository(db *config.Database) UserRepository {
 
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (models.User, error) {
 	var user models.User
-	query := `SELECT id, username, password, created_at FROM users WHERE username = ?`
+	query := `SELECT id, username, password, role, created_at, email, email_verified_at,
+				 totp_enabled_at IS NOT NULL AS two_factor_enabled
+			  FROM users WHERE username = ? AND deleted_at IS NULL`
 	err := r.db.Read.GetContext(ctx, &user, query, username)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -46,24 +59,128 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (mo
 	return user, nil
 }
 
+func (r *userRepository) GetByID(ctx context.Context, userID int) (models.User, error) {
+	var user models.User
+	query := `SELECT id, username, password, role, created_at, email, email_verified_at,
+				 totp_enabled_at IS NOT NULL AS two_factor_enabled
+			  FROM users WHERE id = ? AND deleted_at IS NULL`
+	err := r.db.Read.GetContext(ctx, &user, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, logger.ValidationError("user not found")
+		}
+		return user, logger.LogErrorWithFields(err, "Error Database: User not found", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	return user, nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, userID int, hashedPassword string) error {
+	query := `UPDATE users SET password = ? WHERE id = ? AND deleted_at IS NULL`
+
+	result, err := r.db.Write.ExecContext(ctx, query, hashedPassword, userID)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "error changing password", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("user not found")
+	}
+	return nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	query := `SELECT id, username, password, role, created_at, email, email_verified_at,
+				 totp_enabled_at IS NOT NULL AS two_factor_enabled
+			  FROM users WHERE email = ? AND deleted_at IS NULL`
+	err := r.db.Read.GetContext(ctx, &user, query, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, logger.ValidationError("user not found")
+		}
+		return user, logger.LogError(err, "Error Database: failed to find user by email")
+	}
+	return user, nil
+}
+
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
-	query := `INSERT INTO users(username, password, created_at) VALUES (:username, :password, NOW())`
-	_, err := r.db.Write.NamedExecContext(ctx, query, user)
+	query := `INSERT INTO users(username, password, email, created_at) VALUES (:username, :password, :email, NOW())`
+	result, err := r.db.Write.NamedExecContext(ctx, query, user)
 	if err != nil {
+		if isDuplicateEntry(err) {
+			return logger.ValidationError("username or email is already registered")
+		}
 		return logger.LogErrorWithFields(err, "Error Database: User already exists", map[string]interface{}{
 			"username": user.Username,
 		})
 	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return logger.LogError(err, "failed to read new user id")
+	}
+	user.ID = int(id)
+	return nil
+}
+
+// SetEmail changes a user's email, which then has to be verified again.
+func (r *userRepository) SetEmail(ctx context.Context, userID int, email string) error {
+	query := `UPDATE users SET email = ?, email_verified_at = NULL WHERE id = ? AND deleted_at IS NULL`
+
+	result, err := r.db.Write.ExecContext(ctx, query, email, userID)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return logger.ValidationError("email is already registered")
+		}
+		return logger.LogErrorWithFields(err, "error changing email", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("user not found")
+	}
+	return nil
+}
+
+// MarkEmailVerified verifies a user's email, as long as it is still the one
+// the verification was sent to.
+func (r *userRepository) MarkEmailVerified(ctx context.Context, userID int, email string) error {
+	query := `UPDATE users SET email_verified_at = NOW()
+			  WHERE id = ? AND email = ? AND email_verified_at IS NULL AND deleted_at IS NULL`
+
+	result, err := r.db.Write.ExecContext(ctx, query, userID, email)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "error verifying email", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		// The email may have been verified already.
+		var exists bool
+		query := `SELECT EXISTS(SELECT 1 FROM users WHERE id = ? AND email = ? AND deleted_at IS NULL)`
+		if err := r.db.Write.GetContext(ctx, &exists, query, userID, email); err != nil {
+			return logger.LogError(err, "error checking email")
+		}
+		if !exists {
+			return logger.ValidationError("user not found")
+		}
+	}
 	return nil
 }
 
 func (r *userRepository) GetAllUsers(ctx context.Context, search string) ([]models.User, error) {
 	var users []models.User
-	query := `SELECT id, username, role, created_at FROM users`
+	query := `SELECT id, username, role, created_at FROM users WHERE deleted_at IS NULL`
 	var args []interface{}
 
 	if search != "" {
-		query += ` WHERE username like ? OR role LIKE ?`
+		query += ` AND (username like ? OR role LIKE ?)`
 		likeSearch := "%" + search + "%"
 		args = append(args, likeSearch, likeSearch)
 	}
@@ -88,6 +205,7 @@ func (r *userRepository) GetStats(ctx context.Context) (models.UserStats, error)
 			COUNT(*) as total_users,
 			COALESCE(SUM(CASE WHEN role = 'admin' THEN 1 ELSE 0 END), 0) as admin_count
 		FROM users
+		WHERE deleted_at IS NULL
 	`
 
 	err := r.db.Read.GetContext(ctx, &stats, query)
@@ -99,7 +217,7 @@ func (r *userRepository) GetStats(ctx context.Context) (models.UserStats, error)
 }
 
 func (r *userRepository) UpdateRole(ctx context.Context, userID int, newRole string) error {
-	query := `UPDATE users SET role = ? WHERE id = ?`
+	query := `UPDATE users SET role = ? WHERE id = ? AND deleted_at IS NULL`
 
 	_, err := r.db.Write.ExecContext(ctx, query, newRole, userID)
 	if err != nil {
@@ -112,15 +230,78 @@ func (r *userRepository) UpdateRole(ctx context.Context, userID int, newRole str
 	return nil
 }
 
+// Delete moves a user to the trash. A trashed user cannot log in and their
+// posts are hidden until an admin restores or purges the account.
 func (r *userRepository) Delete(ctx context.Context, userID int) error {
-	query := `DELETE FROM users WHERE id = ?`
+	query := `UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`
 
-	_, err := r.db.Write.ExecContext(ctx, query, userID)
+	result, err := r.db.Write.ExecContext(ctx, query, time.Now(), userID)
 	if err != nil {
 		return logger.LogErrorWithFields(err, "error deleting user", map[string]interface{}{
 			"user_id": userID,
 		})
 	}
 
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("user not found")
+	}
+	return nil
+}
+
+func (r *userRepository) GetTrashed(ctx context.Context) ([]models.User, error) {
+	users := make([]models.User, 0)
+	query := `SELECT id, username, role, created_at, deleted_at FROM users
+			  WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+
+	if err := r.db.Read.SelectContext(ctx, &users, query); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve trashed users")
+	}
+
+	return users, nil
+}
+
+// GetTrashedBefore returns the ids of users that were trashed before cutoff.
+func (r *userRepository) GetTrashedBefore(ctx context.Context, cutoff time.Time) ([]int, error) {
+	ids := make([]int, 0)
+	query := `SELECT id FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ?`
+
+	if err := r.db.Read.SelectContext(ctx, &ids, query, cutoff); err != nil {
+		return nil, logger.LogError(err, "Failed to retrieve expired trashed users")
+	}
+
+	return ids, nil
+}
+
+func (r *userRepository) Restore(ctx context.Context, userID int) error {
+	query := `UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`
+
+	result, err := r.db.Write.ExecContext(ctx, query, userID)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "error restoring user", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("user not found in trash")
+	}
+	return nil
+}
+
+// Purge permanently deletes a trashed user. Their posts, comments and ratings
+// go with them through the foreign keys.
+func (r *userRepository) Purge(ctx context.Context, userID int) error {
+	query := `DELETE FROM users WHERE id = ? AND deleted_at IS NOT NULL`
+
+	result, err := r.db.Write.ExecContext(ctx, query, userID)
+	if err != nil {
+		return logger.LogErrorWithFields(err, "error purging user", map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return logger.ValidationError("user not found in trash")
+	}
 	return nil
 }
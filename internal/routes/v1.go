@@ -5,19 +5,53 @@ import (
 	"github.com/gofiber/fiber/v3/middleware/static"
 	"github.com/rafli2460/culinary-blog-api/internal/handlers"
 	"github.com/rafli2460/culinary-blog-api/internal/middleware"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
 )
 
 func InitRoutes(app *fiber.App,
+	blob storage.Blob,
+	apiKeys middleware.APIKeyVerifier,
+	roles middleware.PermissionLookup,
 	authHandler *handlers.AuthHandler,
 	adminHandler *handlers.AdminHandler,
-	postHandler *handlers.PostHandler) {
+	postHandler *handlers.PostHandler,
+	recipeHandler *handlers.RecipeHandler,
+	taxonomyHandler *handlers.TaxonomyHandler,
+	searchHandler *handlers.SearchHandler,
+	commentHandler *handlers.CommentHandler,
+	revisionHandler *handlers.RevisionHandler,
+	trashHandler *handlers.TrashHandler,
+	galleryHandler *handlers.GalleryHandler,
+	profileHandler *handlers.ProfileHandler,
+	accountHandler *handlers.AccountHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	twoFactorHandler *handlers.TwoFactorHandler,
+	identityHandler *handlers.IdentityHandler) {
 
-	app.Get("/uploads/*", static.New("./uploads"))
+	// Files in an object store are downloaded from the store itself.
+	if local, ok := blob.(*storage.Local); ok {
+		app.Get("/uploads/*", static.New(local.Dir))
+	}
 	api := app.Group("/v1")
 
+	api.Get("/posts/search", searchHandler.SearchPosts)
+	api.Get("/posts/by-slug/:slug", postHandler.GetPostBySlug)
+	api.Get("/posts/:id/scaled", postHandler.GetScaledPost)
+	api.Get("/posts/:id/ratings", postHandler.GetRatings)
+	api.Get("/posts/:id/comments", commentHandler.GetComments)
 	api.Get("/posts/:id", postHandler.GetPost)
 	api.Get("/posts", postHandler.GetAllPosts)
 
+	api.Post("/recipes/match", recipeHandler.MatchRecipes)
+
+	api.Get("/users/:username", profileHandler.GetProfile)
+
+	api.Get("/categories", taxonomyHandler.GetCategories)
+	api.Get("/cuisines", taxonomyHandler.GetCuisines)
+	api.Get("/tags/autocomplete", taxonomyHandler.AutocompleteTags)
+	api.Get("/tags", taxonomyHandler.GetTags)
+
 	api.Get("/health", func(c fiber.Ctx) error {
 		return c.JSON(fiber.Map{
 			"status":  fiber.StatusOK,
@@ -30,21 +64,122 @@ func InitRoutes(app *fiber.App,
 
 	auth.Post("/register", authHandler.Register)
 	auth.Post("/login", authHandler.Login)
+	auth.Post("/login/2fa", authHandler.CompleteLogin)
+	auth.Post("/login/2fa/setup", authHandler.SetupTwoFactorLogin)
+	auth.Post("/refresh", authHandler.Refresh)
 	auth.Post("/logout", authHandler.Logout)
+	auth.Get("/verify", authHandler.VerifyEmail)
+	auth.Post("/verify", authHandler.VerifyEmail)
+	auth.Post("/forgot-password", authHandler.ForgotPassword)
+	auth.Post("/reset-password", authHandler.ResetPassword)
+	auth.Get("/oidc", identityHandler.GetProviders)
+	auth.Get("/oidc/:provider", identityHandler.Login)
+	auth.Get("/oidc/:provider/callback", identityHandler.Callback)
+
+	// ME
+	me := api.Group("/me", middleware.Protected(apiKeys, roles), middleware.ReadWriteScope("account:read", "account:write"))
+
+	me.Get("/", accountHandler.GetAccount)
+	me.Delete("/", middleware.SessionOnly(), accountHandler.DeleteAccount)
+	me.Put("/password", middleware.SessionOnly(), accountHandler.ChangePassword)
+	me.Put("/email", middleware.SessionOnly(), accountHandler.ChangeEmail)
+	me.Post("/email/verify", middleware.SessionOnly(), accountHandler.ResendVerification)
+	me.Get("/export", accountHandler.ExportAccount)
+	me.Put("/profile", profileHandler.UpdateProfile)
+	me.Get("/sessions", middleware.SessionOnly(), authHandler.GetSessions)
+	me.Delete("/sessions/:id", middleware.SessionOnly(), authHandler.RevokeSession)
+	me.Get("/api-keys", middleware.SessionOnly(), apiKeyHandler.GetAPIKeys)
+	me.Post("/api-keys", middleware.SessionOnly(), apiKeyHandler.CreateAPIKey)
+	me.Delete("/api-keys/:id", middleware.SessionOnly(), apiKeyHandler.RevokeAPIKey)
+	me.Get("/2fa", middleware.SessionOnly(), twoFactorHandler.GetStatus)
+	me.Post("/2fa/setup", middleware.SessionOnly(), twoFactorHandler.Setup)
+	me.Post("/2fa/enable", middleware.SessionOnly(), twoFactorHandler.Enable)
+	me.Delete("/2fa", middleware.SessionOnly(), twoFactorHandler.Disable)
+	me.Post("/2fa/recovery-codes", middleware.SessionOnly(), twoFactorHandler.RegenerateRecoveryCodes)
+	me.Get("/identities", middleware.SessionOnly(), identityHandler.GetIdentities)
+	me.Post("/identities/:provider", middleware.SessionOnly(), identityHandler.Link)
+	me.Delete("/identities/:id", middleware.SessionOnly(), identityHandler.Unlink)
 
 	// ADMIN
-	admin := api.Group("/admin", middleware.AdminOnly())
+	admin := api.Group("/admin", middleware.Protected(apiKeys, roles), middleware.RequireScope("admin"))
+
+	manageUsers := middleware.Require(models.PermManageUsers)
+	manageTaxonomy := middleware.Require(models.PermManageTaxonomy)
+	moderateComments := middleware.Require(models.PermModerateComments)
+	manageTrash := middleware.Require(models.PermManageTrash)
+
+	admin.Get("/users/stats", manageUsers, adminHandler.GetStats)
+	admin.Get("/users", manageUsers, adminHandler.GetUsers)
+
+	admin.Put("/users/:id/role", manageUsers, adminHandler.UpdateRole)
+	admin.Delete("/users/:id", manageUsers, adminHandler.DeleteUser)
+	admin.Put("/users/:id/unlock", manageUsers, adminHandler.UnlockUser)
+	admin.Get("/login-attempts", manageUsers, adminHandler.GetLoginAttempts)
+	admin.Get("/roles", manageUsers, adminHandler.GetRoles)
+	admin.Put("/roles/:name/2fa", manageUsers, adminHandler.SetRoleTwoFactor)
 
-	admin.Get("/users/stats", adminHandler.GetStats)
-	admin.Get("/users", adminHandler.GetUsers)
+	admin.Get("/categories", manageTaxonomy, taxonomyHandler.GetCategories)
+	admin.Post("/categories", manageTaxonomy, taxonomyHandler.CreateCategory)
+	admin.Put("/categories/:id", manageTaxonomy, taxonomyHandler.UpdateCategory)
+	admin.Delete("/categories/:id", manageTaxonomy, taxonomyHandler.DeleteCategory)
 
-	admin.Put("/users/:id/role", adminHandler.UpdateRole)
-	admin.Delete("/users/:id", adminHandler.DeleteUser)
+	admin.Get("/cuisines", manageTaxonomy, taxonomyHandler.GetCuisines)
+	admin.Post("/cuisines", manageTaxonomy, taxonomyHandler.CreateCuisine)
+	admin.Put("/cuisines/:id", manageTaxonomy, taxonomyHandler.UpdateCuisine)
+	admin.Delete("/cuisines/:id", manageTaxonomy, taxonomyHandler.DeleteCuisine)
+
+	admin.Get("/tags", manageTaxonomy, taxonomyHandler.GetTags)
+	admin.Post("/tags", manageTaxonomy, taxonomyHandler.CreateTag)
+	admin.Put("/tags/:id", manageTaxonomy, taxonomyHandler.UpdateTag)
+	admin.Delete("/tags/:id", manageTaxonomy, taxonomyHandler.DeleteTag)
+
+	admin.Get("/comments", moderateComments, commentHandler.GetModerationQueue)
+	admin.Put("/comments/:id/approve", moderateComments, commentHandler.ApproveComment)
+	admin.Put("/comments/:id/hide", moderateComments, commentHandler.ModerateHideComment)
+	admin.Delete("/comments/:id", moderateComments, commentHandler.ModerateDeleteComment)
+
+	admin.Get("/trash/posts", manageTrash, trashHandler.GetTrashedPosts)
+	admin.Put("/trash/posts/:id/restore", manageTrash, trashHandler.RestorePost)
+	admin.Delete("/trash/posts/:id", manageTrash, trashHandler.PurgePost)
+	admin.Get("/trash/users", manageTrash, trashHandler.GetTrashedUsers)
+	admin.Put("/trash/users/:id/restore", manageTrash, trashHandler.RestoreUser)
+	admin.Delete("/trash/users/:id", manageTrash, trashHandler.PurgeUser)
 
 	// POST
-	posts := api.Group("/post", middleware.Protected())
+	posts := api.Group("/post", middleware.Protected(apiKeys, roles), middleware.ReadWriteScope("posts:read", "posts:write"))
+	posts.Get("/mine", postHandler.GetMyPosts)
+	posts.Get("/:id", postHandler.PreviewPost)
 	posts.Post("/", postHandler.CreatePost)
 	posts.Delete("/:id", postHandler.DeletePost)
 	posts.Put("/:id", postHandler.UpdatePost)
 
+	// RECIPE
+	posts.Get("/:id/recipe", recipeHandler.GetRecipe)
+	posts.Post("/:id/recipe", recipeHandler.CreateRecipe)
+	posts.Put("/:id/recipe", recipeHandler.UpdateRecipe)
+
+	// RATING
+	posts.Post("/:id/ratings", postHandler.RatePost)
+	posts.Put("/:id/ratings", postHandler.UpdateRating)
+	posts.Delete("/:id/ratings", postHandler.DeleteRating)
+
+	// REVISION
+	posts.Get("/:id/revisions", revisionHandler.GetRevisions)
+	posts.Get("/:id/revisions/diff", revisionHandler.DiffRevisions)
+	posts.Get("/:id/revisions/:rev", revisionHandler.GetRevision)
+	posts.Post("/:id/revisions/:rev/restore", revisionHandler.RestoreRevision)
+
+	// GALLERY
+	posts.Get("/:id/images", galleryHandler.GetImages)
+	posts.Put("/:id/images/order", galleryHandler.ReorderImages)
+	posts.Put("/:id/images/:imageId", galleryHandler.UpdateImage)
+	posts.Delete("/:id/images/:imageId", galleryHandler.DeleteImage)
+	posts.Put("/:id/images/:imageId/cover", galleryHandler.SetCover)
+
+	// COMMENT
+	posts.Post("/:id/comments", commentHandler.CreateComment)
+	posts.Put("/:id/comments/:commentId", commentHandler.UpdateComment)
+	posts.Delete("/:id/comments/:commentId", commentHandler.DeleteComment)
+	posts.Put("/:id/comments/:commentId/hide", commentHandler.HideComment)
+
 }
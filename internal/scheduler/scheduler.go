@@ -0,0 +1,75 @@
+// Package scheduler runs periodic background jobs inside the API process.
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Clock tells the scheduler the time and when to wake up. Tests inject a fake
+// clock to drive the scheduler without waiting in real time.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock backed by the real time.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Job is a unit of periodic work. It receives the scheduler's current time and
+// reports how many items it processed.
+type Job func(ctx context.Context, now time.Time) (int, error)
+
+// Scheduler runs a job at a fixed interval until its context is cancelled.
+type Scheduler struct {
+	name     string
+	job      Job
+	clock    Clock
+	interval time.Duration
+}
+
+func New(name string, job Job, clock Clock, interval time.Duration) *Scheduler {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &Scheduler{name: name, job: job, clock: clock, interval: interval}
+}
+
+// RunOnce runs the job a single time at the clock's current time.
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	return s.job(ctx, s.clock.Now())
+}
+
+// Run runs the job immediately and then after every interval, blocking until
+// ctx is cancelled. Failures are logged and retried on the next tick.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		processed, err := s.RunOnce(ctx)
+		if err != nil {
+			log.Error().Err(err).Str("job", s.name).Msg("Scheduled job failed")
+		} else if processed > 0 {
+			log.Info().Str("job", s.name).Int("processed", processed).Msg("Scheduled job finished")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(s.interval):
+		}
+	}
+}
+
+// Start runs the scheduler in its own goroutine.
+func (s *Scheduler) Start(ctx context.Context) {
+	go s.Run(ctx)
+}
@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock hands every wait it is asked for to the test, which decides when
+// it ends.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters chan chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waiters: make(chan chan time.Time)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.waiters <- ch
+	return ch
+}
+
+// tick waits for the scheduler to start waiting, moves the clock forward by
+// d and ends the wait.
+func (c *fakeClock) tick(t *testing.T, d time.Duration) {
+	t.Helper()
+	select {
+	case ch := <-c.waiters:
+		c.mu.Lock()
+		c.now = c.now.Add(d)
+		now := c.now
+		c.mu.Unlock()
+		ch <- now
+	case <-time.After(time.Second):
+		t.Fatal("scheduler never waited for the next tick")
+	}
+}
+
+func expectRun(t *testing.T, runs <-chan time.Time, want time.Time) {
+	t.Helper()
+	select {
+	case got := <-runs:
+		if !got.Equal(want) {
+			t.Errorf("job ran at %v, want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("job did not run at %v", want)
+	}
+}
+
+func TestSchedulerRunsOnEveryTick(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	runs := make(chan time.Time, 10)
+
+	calls := 0
+	job := func(ctx context.Context, now time.Time) (int, error) {
+		calls++
+		runs <- now
+		// A failing run must not stop the scheduler.
+		if calls == 2 {
+			return 0, errors.New("temporary failure")
+		}
+		return 1, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	New("test", job, clock, time.Minute).Start(ctx)
+
+	// The job runs straight away, then once per interval.
+	expectRun(t, runs, start)
+	for i := 1; i <= 3; i++ {
+		clock.tick(t, time.Minute)
+		expectRun(t, runs, start.Add(time.Duration(i)*time.Minute))
+	}
+}
+
+func TestSchedulerStopsWhenCancelled(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	runs := make(chan time.Time, 10)
+	job := func(ctx context.Context, now time.Time) (int, error) {
+		runs <- now
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		New("test", job, clock, time.Minute).Run(ctx)
+		close(done)
+	}()
+
+	<-runs
+	// Wait until the scheduler sleeps, then cancel instead of ticking.
+	<-clock.waiters
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler did not stop after its context was cancelled")
+	}
+	if len(runs) != 0 {
+		t.Errorf("job ran %d more times after cancel", len(runs))
+	}
+}
+
+func TestRunOnceUsesClock(t *testing.T) {
+	now := time.Date(2024, 6, 1, 8, 30, 0, 0, time.UTC)
+	var got time.Time
+	job := func(ctx context.Context, t time.Time) (int, error) {
+		got = t
+		return 3, nil
+	}
+
+	processed, err := New("test", job, newFakeClock(now), time.Hour).RunOnce(context.Background())
+	if err != nil || processed != 3 || !got.Equal(now) {
+		t.Errorf("RunOnce = %d, %v at %v, want 3, nil at %v", processed, err, got, now)
+	}
+}
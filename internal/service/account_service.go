@@ -0,0 +1,222 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AccountService interface {
+	GetAccount(ctx context.Context, userID int) (*models.Account, error)
+	ChangePassword(ctx context.Context, userID int, sessionID int, req models.ChangePasswordRequest) error
+	DeleteAccount(ctx context.Context, userID int, req models.DeleteAccountRequest) error
+	Export(ctx context.Context, userID int) (*models.AccountExport, error)
+	WriteExportZip(ctx context.Context, export *models.AccountExport, w io.Writer) error
+}
+
+type accountService struct {
+	userRepo       repository.UserRepository
+	postRepo       repository.PostRepository
+	commentRepo    repository.CommentRepository
+	ratingRepo     repository.RatingRepository
+	profileService ProfileService
+	postService    PostService
+	sessionService SessionService
+	blob           storage.Blob
+}
+
+func NewAccountService(userRepo repository.UserRepository, postRepo repository.PostRepository, commentRepo repository.CommentRepository, ratingRepo repository.RatingRepository, profileService ProfileService, postService PostService, sessionService SessionService, blob storage.Blob) AccountService {
+	return &accountService{
+		userRepo:       userRepo,
+		postRepo:       postRepo,
+		commentRepo:    commentRepo,
+		ratingRepo:     ratingRepo,
+		profileService: profileService,
+		postService:    postService,
+		sessionService: sessionService,
+		blob:           blob,
+	}
+}
+
+// exportImageDir is the folder of a ZIP export that holds the image files.
+const exportImageDir = "images/"
+
+func (s *accountService) GetAccount(ctx context.Context, userID int) (*models.Account, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.profileService.GetProfile(ctx, user.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Account{
+		Profile:          *profile,
+		Role:             user.Role,
+		Email:            user.Email,
+		EmailVerified:    user.EmailVerifiedAt != nil,
+		HasPassword:      user.Password != "",
+		TwoFactorEnabled: user.TwoFactorEnabled,
+	}, nil
+}
+
+// ChangePassword replaces the current user's password and logs them out of
+// every session except sessionID, the one they changed it from. Accounts
+// created through a login provider have no password to give as the old one,
+// so they set their first password without it.
+func (s *accountService) ChangePassword(ctx context.Context, userID int, sessionID int, req models.ChangePasswordRequest) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword)); err != nil {
+			return logger.ValidationError("old password is incorrect")
+		}
+	}
+
+	hashedPassword, err := hashNewPassword(req.NewPassword, req.ConfirmPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return err
+	}
+	return s.sessionService.RevokeOtherSessions(ctx, userID, sessionID)
+}
+
+// DeleteAccount moves the current user's account to the trash once they have
+// confirmed it with their password. Like an account deleted by an admin, it is
+// purged with its posts and images after the trash retention period. The last
+// admin cannot delete their account.
+func (s *accountService) DeleteAccount(ctx context.Context, userID int, req models.DeleteAccountRequest) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkPassword(user, req.Password); err != nil {
+		return err
+	}
+
+	if user.Role == models.AdminRole {
+		stats, err := s.userRepo.GetStats(ctx)
+		if err != nil {
+			return err
+		}
+		if stats.AdminCount <= 1 {
+			return logger.ValidationError("action denied: you are the only admin")
+		}
+	}
+
+	if err := s.userRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+	return s.sessionService.RevokeOtherSessions(ctx, userID, 0)
+}
+
+// Export gathers the current user's account, profile, posts in every state,
+// comments, ratings and image files.
+func (s *accountService) Export(ctx context.Context, userID int) (*models.AccountExport, error) {
+	account, err := s.GetAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.AccountExport{
+		ExportedAt: time.Now().UTC(),
+		Account:    *account,
+		Posts:      make([]models.PostDetail, 0),
+		Images:     make([]models.ExportedImage, 0),
+	}
+
+	const pageSize = 100
+	for page := 1; ; page++ {
+		posts, err := s.postService.GetMyPosts(ctx, userID, "", page, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		export.Posts = append(export.Posts, posts...)
+		if len(posts) < pageSize {
+			break
+		}
+	}
+
+	if export.Comments, err = s.commentRepo.GetByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Ratings, err = s.ratingRepo.GetByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	images, err := s.postRepo.GetImagesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		export.Images = append(export.Images, models.ExportedImage{File: exportImageDir + image, URL: s.blob.URL(image)})
+	}
+
+	return export, nil
+}
+
+// WriteExportZip writes an export as a ZIP archive holding account.json and
+// the image files. Images missing from storage are left out.
+func (s *accountService) WriteExportZip(ctx context.Context, export *models.AccountExport, w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	data, err := archive.Create("account.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(data)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return err
+	}
+
+	for _, image := range export.Images {
+		if err := s.addExportImage(ctx, archive, image.File); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func (s *accountService) addExportImage(ctx context.Context, archive *zip.Writer, file string) error {
+	key := strings.TrimPrefix(file, exportImageDir)
+
+	src, err := s.blob.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Warn().Str("file", key).Msg("Image missing from storage, left out of export")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Images are already compressed, so they are stored as they are.
+	dst, err := archive.CreateHeader(&zip.FileHeader{Name: file, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
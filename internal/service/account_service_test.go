@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// singleUser is a user repository holding one user.
+type singleUser struct {
+	repository.UserRepository
+	user    models.User
+	deleted bool
+}
+
+func (r *singleUser) GetByID(ctx context.Context, userID int) (models.User, error) {
+	return r.user, nil
+}
+
+func (r *singleUser) UpdatePassword(ctx context.Context, userID int, hashedPassword string) error {
+	r.user.Password = hashedPassword
+	return nil
+}
+
+func (r *singleUser) Delete(ctx context.Context, userID int) error {
+	r.deleted = true
+	return nil
+}
+
+type noSessions struct {
+	SessionService
+}
+
+func (s noSessions) RevokeOtherSessions(ctx context.Context, userID int, keepSessionID int) error {
+	return nil
+}
+
+func hashed(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(hash)
+}
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		name        string
+		password    string
+		oldPassword string
+		wantErr     string
+	}{
+		{"right old password", "secret1", "secret1", ""},
+		{"wrong old password", "secret1", "secret2", "old password is incorrect"},
+		{"missing old password", "secret1", "", "old password is incorrect"},
+		{"first password of a provider account", "", "", ""},
+		{"provider account ignores an old password", "", "anything", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := models.User{ID: 1, Username: "alice", Role: "user"}
+			if tt.password != "" {
+				user.Password = hashed(t, tt.password)
+			}
+			userRepo := &singleUser{user: user}
+			svc := NewAccountService(userRepo, nil, nil, nil, nil, nil, noSessions{}, nil)
+
+			err := svc.ChangePassword(context.Background(), 1, 1, models.ChangePasswordRequest{
+				OldPassword: tt.oldPassword, NewPassword: "newpass", ConfirmPassword: "newpass",
+			})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ChangePassword error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ChangePassword error = %v", err)
+			}
+			if bcrypt.CompareHashAndPassword([]byte(userRepo.user.Password), []byte("newpass")) != nil {
+				t.Error("new password not stored")
+			}
+		})
+	}
+}
+
+func TestDeleteAccountPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		given    string
+		wantErr  string
+	}{
+		{"right password", "secret1", "secret1", ""},
+		{"wrong password", "secret1", "secret2", "password is incorrect"},
+		{"provider account without a password", "", "", "your account has no password yet, set one first"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := models.User{ID: 1, Username: "alice", Role: "user"}
+			if tt.password != "" {
+				user.Password = hashed(t, tt.password)
+			}
+			userRepo := &singleUser{user: user}
+			svc := NewAccountService(userRepo, nil, nil, nil, nil, nil, noSessions{}, nil)
+
+			err := svc.DeleteAccount(context.Background(), 1, models.DeleteAccountRequest{Password: tt.given})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("DeleteAccount error = %v, want %q", err, tt.wantErr)
+				}
+				if userRepo.deleted {
+					t.Error("account deleted")
+				}
+				return
+			}
+			if err != nil || !userRepo.deleted {
+				t.Errorf("DeleteAccount error = %v, deleted = %v", err, userRepo.deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+	"github.com/rs/zerolog/log"
+)
+
+type APIKeyService interface {
+	GetAPIKeys(ctx context.Context, userID int) ([]models.APIKey, error)
+	CreateAPIKey(ctx context.Context, userID int, req models.APIKeyRequest) (*models.CreatedAPIKey, error)
+	RevokeAPIKey(ctx context.Context, userID int, keyID int) error
+	Verify(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+type apiKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+}
+
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository) APIKeyService {
+	return &apiKeyService{apiKeyRepo: apiKeyRepo}
+}
+
+const (
+	maxAPIKeysPerUser   = 25
+	maxAPIKeyNameLength = 100
+	maxAPIKeyExpiryDays = 365
+
+	// apiKeyDisplayLength is how much of a key is kept to identify it.
+	apiKeyDisplayLength = len(models.APIKeyPrefix) + 8
+)
+
+func (s *apiKeyService) GetAPIKeys(ctx context.Context, userID int) ([]models.APIKey, error) {
+	keys, err := s.apiKeyRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range keys {
+		keys[i].Scopes = strings.Split(keys[i].ScopeList, ",")
+	}
+	return keys, nil
+}
+
+// CreateAPIKey generates a new key for the user. The key itself is only in
+// the returned value; afterwards just its hash is known.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, userID int, req models.APIKeyRequest) (*models.CreatedAPIKey, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, logger.ValidationError("name is required")
+	}
+	if utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		return nil, logger.ValidationError("name cannot be longer than 100 characters")
+	}
+
+	scopes, err := apiKeyScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxAPIKeyExpiryDays {
+		return nil, logger.ValidationError("expires_in_days must be between 1 and 365, or 0 for a key that does not expire")
+	}
+
+	count, err := s.apiKeyRepo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAPIKeysPerUser {
+		return nil, logger.ValidationError("you cannot have more than 25 API keys, revoke one first")
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, logger.LogError(err, "error generating API key")
+	}
+	secret := models.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	created := &models.CreatedAPIKey{
+		APIKey: models.APIKey{
+			UserID:    userID,
+			Name:      name,
+			Prefix:    secret[:apiKeyDisplayLength],
+			KeyHash:   hashToken(secret),
+			ScopeList: strings.Join(scopes, ","),
+			Scopes:    scopes,
+			CreatedAt: time.Now(),
+		},
+		Key: secret,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		created.ExpiresAt = &expiresAt
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, &created.APIKey); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, userID int, keyID int) error {
+	return s.apiKeyRepo.Revoke(ctx, userID, keyID)
+}
+
+// Verify looks up the key presented with a request and returns it with its
+// owner's role and scopes.
+func (s *apiKeyService) Verify(ctx context.Context, key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, models.APIKeyPrefix) {
+		return nil, logger.ValidationError("invalid API key")
+	}
+
+	stored, err := s.apiKeyRepo.GetByHash(ctx, hashToken(key))
+	if err != nil {
+		if err.Error() == "API key not found" {
+			return nil, logger.ValidationError("invalid API key")
+		}
+		return nil, err
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, logger.ValidationError("API key has been revoked")
+	}
+	if stored.ExpiresAt != nil && time.Now().After(*stored.ExpiresAt) {
+		return nil, logger.ValidationError("API key has expired")
+	}
+
+	// A failed write must not lock the key out, so it is only logged.
+	if err := s.apiKeyRepo.Touch(ctx, stored.ID); err != nil {
+		log.Warn().Err(err).Int("api_key_id", stored.ID).Msg("Failed to record API key use")
+	}
+
+	stored.Scopes = strings.Split(stored.ScopeList, ",")
+	return stored, nil
+}
+
+// apiKeyScopes validates the requested scopes and returns them without
+// duplicates, in the order of models.APIKeyScopes.
+func apiKeyScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, logger.ValidationError("at least one scope is required")
+	}
+
+	for _, scope := range requested {
+		if !slices.Contains(models.APIKeyScopes, scope) {
+			return nil, logger.ValidationError("unknown scope: " + scope)
+		}
+	}
+
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range models.APIKeyScopes {
+		if slices.Contains(requested, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
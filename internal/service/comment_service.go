@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type CommentService interface {
+	GetComments(ctx context.Context, postID int) ([]models.Comment, error)
+	CreateComment(ctx context.Context, postID, currentUserID int, permissions models.Permissions, req models.CommentRequest) (*models.Comment, error)
+	UpdateComment(ctx context.Context, postID, commentID, currentUserID int, req models.CommentRequest) (*models.Comment, error)
+	DeleteComment(ctx context.Context, postID, commentID, currentUserID int, permissions models.Permissions) error
+	HideComment(ctx context.Context, postID, commentID, currentUserID int, permissions models.Permissions) error
+	GetModerationQueue(ctx context.Context, status string, page int, limit int) ([]models.Comment, int, error)
+	ModerateComment(ctx context.Context, commentID int, action string) error
+}
+
+type commentService struct {
+	commentRepo repository.CommentRepository
+	postRepo    repository.PostRepository
+}
+
+func NewCommentService(commentRepo repository.CommentRepository, postRepo repository.PostRepository) CommentService {
+	return &commentService{commentRepo: commentRepo, postRepo: postRepo}
+}
+
+const (
+	maxCommentLength  = 2000
+	commentEditWindow = 15 * time.Minute
+)
+
+// GetComments returns a post's comments as a tree of replies.
+func (s *commentService) GetComments(ctx context.Context, postID int) ([]models.Comment, error) {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil || post.Status != models.PostPublished {
+		return nil, logger.ValidationError("post not found")
+	}
+
+	comments, err := s.commentRepo.GetByPostID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCommentTree(comments), nil
+}
+
+// CreateComment adds a comment or a reply. Comments from the post's author and
+// from comment moderators are published straight away; everyone else's wait in
+// the moderation queue.
+func (s *commentService) CreateComment(ctx context.Context, postID int, currentUserID int, permissions models.Permissions, req models.CommentRequest) (*models.Comment, error) {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil || post.Status != models.PostPublished {
+		return nil, logger.ValidationError("post not found")
+	}
+
+	content, err := validateCommentContent(req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		parent, err := s.commentRepo.GetByID(ctx, *req.ParentID)
+		if err != nil || parent.PostID != postID {
+			return nil, logger.ValidationError("parent comment not found")
+		}
+		if parent.DeletedAt != nil || parent.Status != models.CommentApproved {
+			return nil, logger.ValidationError("you cannot reply to this comment")
+		}
+	}
+
+	status := models.CommentPending
+	if canModerateComments(post, currentUserID, permissions) {
+		status = models.CommentApproved
+	}
+
+	comment := &models.Comment{
+		PostID:   postID,
+		UserID:   currentUserID,
+		ParentID: req.ParentID,
+		Content:  content,
+		Status:   status,
+	}
+
+	if err := s.commentRepo.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	return s.commentRepo.GetByID(ctx, comment.ID)
+}
+
+// UpdateComment lets the author edit their comment within the edit window.
+func (s *commentService) UpdateComment(ctx context.Context, postID int, commentID int, currentUserID int, req models.CommentRequest) (*models.Comment, error) {
+	comment, err := s.getPostComment(ctx, postID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.UserID != currentUserID {
+		return nil, logger.ValidationError("access denied: you can only edit your own comments")
+	}
+
+	content, err := validateCommentContent(req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.commentRepo.UpdateContent(ctx, commentID, content, commentEditWindow); err != nil {
+		return nil, err
+	}
+
+	return s.commentRepo.GetByID(ctx, commentID)
+}
+
+func (s *commentService) DeleteComment(ctx context.Context, postID int, commentID int, currentUserID int, permissions models.Permissions) error {
+	comment, err := s.getPostComment(ctx, postID, commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != currentUserID && !permissions.Has(models.PermModerateComments) {
+		return logger.ValidationError("access denied: you do not have permission to delete this comment")
+	}
+
+	return s.commentRepo.SoftDelete(ctx, commentID)
+}
+
+// HideComment lets a post's author, or a comment moderator, hide a comment on
+// that post.
+func (s *commentService) HideComment(ctx context.Context, postID int, commentID int, currentUserID int, permissions models.Permissions) error {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return logger.ValidationError("post not found")
+	}
+
+	if !canModerateComments(post, currentUserID, permissions) {
+		return logger.ValidationError("access denied: you can only hide comments on your own posts")
+	}
+
+	if _, err := s.getPostComment(ctx, postID, commentID); err != nil {
+		return err
+	}
+
+	return s.commentRepo.UpdateStatus(ctx, commentID, models.CommentHidden)
+}
+
+func (s *commentService) GetModerationQueue(ctx context.Context, status string, page int, limit int) ([]models.Comment, int, error) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status == "" {
+		status = models.CommentPending
+	}
+	if status != models.CommentPending && status != models.CommentApproved && status != models.CommentHidden {
+		return nil, 0, logger.ValidationError("invalid status, must be 'pending', 'approved' or 'hidden'")
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	return s.commentRepo.GetByStatus(ctx, status, limit, offset)
+}
+
+// ModerateComment applies a moderation action: approve, hide or delete.
+func (s *commentService) ModerateComment(ctx context.Context, commentID int, action string) error {
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment.DeletedAt != nil {
+		return logger.ValidationError("comment has already been deleted")
+	}
+
+	switch action {
+	case "approve":
+		return s.commentRepo.UpdateStatus(ctx, commentID, models.CommentApproved)
+	case "hide":
+		return s.commentRepo.UpdateStatus(ctx, commentID, models.CommentHidden)
+	case "delete":
+		return s.commentRepo.SoftDelete(ctx, commentID)
+	default:
+		return logger.ValidationError("invalid moderation action")
+	}
+}
+
+// getPostComment loads a comment and checks that it belongs to the post in
+// the URL and has not been deleted.
+func (s *commentService) getPostComment(ctx context.Context, postID int, commentID int) (*models.Comment, error) {
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.PostID != postID || comment.DeletedAt != nil {
+		return nil, logger.ValidationError("comment not found")
+	}
+
+	return comment, nil
+}
+
+func validateCommentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", logger.ValidationError("comment cannot be empty")
+	}
+	if len(content) > maxCommentLength {
+		return "", logger.ValidationError("comment cannot exceed 2000 characters")
+	}
+	return content, nil
+}
+
+// buildCommentTree nests comments (given oldest first) under their parents.
+// Comments readers may not see are dropped, unless they have visible replies;
+// then they stay as blank "removed" placeholders so the thread keeps its shape.
+func buildCommentTree(comments []models.Comment) []models.Comment {
+	children := make(map[int][]models.Comment)
+	var roots []models.Comment
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			roots = append(roots, comment)
+		} else {
+			children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+		}
+	}
+
+	var build func(nodes []models.Comment) []models.Comment
+	build = func(nodes []models.Comment) []models.Comment {
+		tree := make([]models.Comment, 0, len(nodes))
+		for _, node := range nodes {
+			node.Replies = build(children[node.ID])
+
+			if node.DeletedAt != nil || node.Status != models.CommentApproved {
+				if len(node.Replies) == 0 {
+					continue
+				}
+				node.Removed = true
+				node.UserID = 0
+				node.Username = ""
+				node.Content = ""
+				node.EditedAt = nil
+			}
+
+			tree = append(tree, node)
+		}
+		return tree
+	}
+
+	return build(roots)
+}
+
+// canModerateComments reports whether a user may approve and hide comments on
+// a post: its author can, and so can comment moderators.
+func canModerateComments(post *models.Post, userID int, permissions models.Permissions) bool {
+	return post.UserID == userID || permissions.Has(models.PermModerateComments)
+}
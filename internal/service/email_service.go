@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/internal/mailer"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+	"github.com/rs/zerolog/log"
+)
+
+type EmailService interface {
+	SendVerification(ctx context.Context, user models.User) error
+	ResendVerification(ctx context.Context, userID int) error
+	VerifyEmail(ctx context.Context, token string) error
+	ChangeEmail(ctx context.Context, userID int, req models.ChangeEmailRequest) error
+	ForgotPassword(ctx context.Context, req models.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req models.ResetPasswordRequest) error
+	PurgeExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type emailService struct {
+	userRepo       repository.UserRepository
+	emailTokenRepo repository.EmailTokenRepository
+	sessionService SessionService
+	mailer         mailer.Mailer
+	appURL         string
+	resetURL       string
+}
+
+// NewEmailService creates the email service. Verification links point at the
+// API under appURL, and password reset links at the resetURL page, which
+// posts the token to /v1/auth/reset-password.
+func NewEmailService(userRepo repository.UserRepository, emailTokenRepo repository.EmailTokenRepository, sessionService SessionService, m mailer.Mailer, appURL string, resetURL string) EmailService {
+	return &emailService{
+		userRepo:       userRepo,
+		emailTokenRepo: emailTokenRepo,
+		sessionService: sessionService,
+		mailer:         m,
+		appURL:         appURL,
+		resetURL:       resetURL,
+	}
+}
+
+const (
+	verifyTokenTTL = 24 * time.Hour
+	resetTokenTTL  = time.Hour
+	// emailCooldown is how long a user waits between two emails of the same
+	// kind, so the endpoints cannot be used to flood a mailbox.
+	emailCooldown = time.Minute
+	// sendTimeout bounds emails sent after the request has been answered.
+	sendTimeout = 30 * time.Second
+	// maxEmailLength matches the users.email column.
+	maxEmailLength = 255
+)
+
+// normalizeEmail lowercases an email and checks that it is a bare address
+// such as "alice@example.com".
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", logger.ValidationError("Please enter email")
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > maxEmailLength {
+		return "", logger.ValidationError("email is not valid")
+	}
+	return email, nil
+}
+
+// SendVerification emails a link that verifies the user's current email.
+func (s *emailService) SendVerification(ctx context.Context, user models.User) error {
+	if user.Email == nil {
+		return logger.ValidationError("please add an email first")
+	}
+
+	token, err := s.issueToken(ctx, user.ID, models.EmailTokenVerify, *user.Email, verifyTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	link := s.appURL + "/v1/auth/verify?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      *user.Email,
+		Subject: "Verify your email",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Please verify your email by opening this link within 24 hours:\n\n" +
+			link + "\n\n" +
+			"If you did not sign up, you can ignore this email.\n",
+	})
+}
+
+// ResendVerification sends the current user a new verification link.
+func (s *emailService) ResendVerification(ctx context.Context, userID int) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == nil {
+		return logger.ValidationError("please add an email first")
+	}
+	if user.EmailVerifiedAt != nil {
+		return logger.ValidationError("email is already verified")
+	}
+
+	if err := s.checkCooldown(ctx, userID, models.EmailTokenVerify); err != nil {
+		return err
+	}
+	return s.SendVerification(ctx, user)
+}
+
+// VerifyEmail marks the email a verification token was sent to as verified.
+func (s *emailService) VerifyEmail(ctx context.Context, token string) error {
+	invalid := logger.ValidationError("verification link is invalid or has expired")
+	if token == "" {
+		return invalid
+	}
+
+	stored, err := s.emailTokenRepo.Consume(ctx, hashToken(token), models.EmailTokenVerify)
+	if err != nil {
+		if err.Error() == "email token not found" {
+			return invalid
+		}
+		return err
+	}
+
+	if err := s.userRepo.MarkEmailVerified(ctx, stored.UserID, stored.Email); err != nil {
+		if err.Error() == "user not found" {
+			return invalid
+		}
+		return err
+	}
+	return nil
+}
+
+// ChangeEmail replaces the current user's email once they have confirmed it
+// with their password, and sends a verification link to the new address.
+func (s *emailService) ChangeEmail(ctx context.Context, userID int, req models.ChangeEmailRequest) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkPassword(user, req.Password); err != nil {
+		return err
+	}
+
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return err
+	}
+	if user.Email != nil && *user.Email == email {
+		return logger.ValidationError("this is already your email")
+	}
+
+	if err := s.userRepo.SetEmail(ctx, userID, email); err != nil {
+		return err
+	}
+	if err := s.emailTokenRepo.InvalidateForUser(ctx, userID, models.EmailTokenVerify); err != nil {
+		return err
+	}
+	if err := s.emailTokenRepo.InvalidateForUser(ctx, userID, models.EmailTokenReset); err != nil {
+		return err
+	}
+
+	user.Email = &email
+	if err := s.SendVerification(ctx, user); err != nil {
+		log.Warn().Err(err).Int("user_id", userID).Msg("failed to send verification email")
+	}
+	return nil
+}
+
+// ForgotPassword emails a password reset link when the email belongs to an
+// account and has been verified. It succeeds either way and sends the email
+// in the background, so the response does not reveal whether the account
+// exists.
+func (s *emailService) ForgotPassword(ctx context.Context, req models.ForgotPasswordRequest) error {
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if err.Error() != "user not found" {
+			logger.LogError(err, "failed to look up password reset email")
+		}
+		return nil
+	}
+	if user.EmailVerifiedAt == nil {
+		return nil
+	}
+
+	go func() {
+		// The email is sent after the request has been answered, so it cannot
+		// use the request's context.
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		defer cancel()
+
+		if err := s.sendPasswordReset(ctx, user, email); err != nil {
+			log.Warn().Err(err).Int("user_id", user.ID).Msg("failed to send password reset email")
+		}
+	}()
+	return nil
+}
+
+func (s *emailService) sendPasswordReset(ctx context.Context, user models.User, email string) error {
+	if err := s.checkCooldown(ctx, user.ID, models.EmailTokenReset); err != nil {
+		return err
+	}
+
+	token, err := s.issueToken(ctx, user.ID, models.EmailTokenReset, email, resetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	separator := "?"
+	if strings.Contains(s.resetURL, "?") {
+		separator = "&"
+	}
+	link := s.resetURL + separator + "token=" + url.QueryEscape(token)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Reset your password",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Someone asked to reset the password of your account. To choose a new one, open this link within an hour:\n\n" +
+			link + "\n\n" +
+			"If it was not you, you can ignore this email and your password stays the same.\n",
+	})
+}
+
+// ResetPassword sets a new password with a token from a password reset email.
+// The user is logged out of every session.
+func (s *emailService) ResetPassword(ctx context.Context, req models.ResetPasswordRequest) error {
+	// The password is checked first, so a typo does not use up the token.
+	hashedPassword, err := hashNewPassword(req.NewPassword, req.ConfirmPassword)
+	if err != nil {
+		return err
+	}
+
+	invalid := logger.ValidationError("reset link is invalid or has expired")
+	if req.Token == "" {
+		return invalid
+	}
+
+	stored, err := s.emailTokenRepo.Consume(ctx, hashToken(req.Token), models.EmailTokenReset)
+	if err != nil {
+		if err.Error() == "email token not found" {
+			return invalid
+		}
+		return err
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, stored.UserID, hashedPassword); err != nil {
+		if err.Error() == "user not found" {
+			return invalid
+		}
+		return err
+	}
+
+	if err := s.emailTokenRepo.InvalidateForUser(ctx, stored.UserID, models.EmailTokenReset); err != nil {
+		return err
+	}
+	return s.sessionService.RevokeOtherSessions(ctx, stored.UserID, 0)
+}
+
+// PurgeExpired deletes expired email tokens and returns how many were
+// deleted. It is meant to run as a scheduler job.
+func (s *emailService) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	return s.emailTokenRepo.DeleteExpired(ctx, now)
+}
+
+func (s *emailService) checkCooldown(ctx context.Context, userID int, purpose string) error {
+	recent, err := s.emailTokenRepo.CountSince(ctx, userID, purpose, time.Now().Add(-emailCooldown))
+	if err != nil {
+		return err
+	}
+	if recent > 0 {
+		return logger.ValidationError("an email was sent recently, please wait a minute before asking for another")
+	}
+	return nil
+}
+
+// issueToken stores a new single-use token and returns it. Like refresh
+// tokens, only its hash is kept.
+//
+// The token is 32 random bytes rather than a signed token such as a JWT.
+// Single use needs a stored record to mark as used anyway, and that record
+// already binds the token to the user, purpose, email and expiry. A token
+// cannot be forged without guessing one whose hash is stored, and a leaked
+// database exposes only hashes, so a signature would add a key to manage
+// without making the token any harder to forge.
+func (s *emailService) issueToken(ctx context.Context, userID int, purpose string, email string, ttl time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", logger.LogError(err, "error generating email token")
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	stored := &models.EmailToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		Email:     email,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.emailTokenRepo.Create(ctx, stored); err != nil {
+		return "", err
+	}
+	return token, nil
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"mime/multipart"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
+	"github.com/rafli2460/culinary-blog-api/internal/upload"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type GalleryService interface {
+	GetImages(ctx context.Context, postID, currentUserID int, permissions models.Permissions) ([]models.PostImage, error)
+	UpdateImage(ctx context.Context, postID, imageID, currentUserID int, permissions models.Permissions, req models.PostImageRequest) (*models.PostImage, error)
+	ReorderImages(ctx context.Context, postID, currentUserID int, permissions models.Permissions, imageIDs []int) ([]models.PostImage, error)
+	DeleteImage(ctx context.Context, postID, imageID, currentUserID int, permissions models.Permissions) error
+	SetCover(ctx context.Context, postID, imageID, currentUserID int, permissions models.Permissions) error
+}
+
+type galleryService struct {
+	postImageRepo repository.PostImageRepository
+	postRepo      repository.PostRepository
+	recipeRepo    repository.RecipeRepository
+	revisionRepo  repository.RevisionRepository
+	blob          storage.Blob
+}
+
+func NewGalleryService(postImageRepo repository.PostImageRepository, postRepo repository.PostRepository, recipeRepo repository.RecipeRepository, revisionRepo repository.RevisionRepository, blob storage.Blob) GalleryService {
+	return &galleryService{postImageRepo: postImageRepo, postRepo: postRepo, recipeRepo: recipeRepo, revisionRepo: revisionRepo, blob: blob}
+}
+
+const (
+	maxGalleryImages  = 20
+	maxGalleryUploads = 10
+	maxCaptionLength  = 255
+)
+
+// GetImages lists a post's gallery in display order, to anyone who may edit
+// the post. Published posts include their gallery in the post details.
+func (s *galleryService) GetImages(ctx context.Context, postID int, currentUserID int, permissions models.Permissions) ([]models.PostImage, error) {
+	post, err := s.managedPost(ctx, postID, currentUserID, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := s.postImageRepo.GetByPostID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range images {
+		presentPostImage(s.blob, &images[i], post.Image)
+	}
+	return images, nil
+}
+
+func (s *galleryService) UpdateImage(ctx context.Context, postID int, imageID int, currentUserID int, permissions models.Permissions, req models.PostImageRequest) (*models.PostImage, error) {
+	post, err := s.managedPost(ctx, postID, currentUserID, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := s.galleryImage(ctx, postID, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	caption := optionalString(stringValue(req.Caption))
+	altText := optionalString(stringValue(req.AltText))
+	if utf8.RuneCountInString(stringValue(caption)) > maxCaptionLength || utf8.RuneCountInString(stringValue(altText)) > maxCaptionLength {
+		return nil, logger.ValidationError("caption and alt text cannot be longer than 255 characters")
+	}
+
+	if req.StepNumber != nil {
+		if err := s.checkStep(ctx, postID, *req.StepNumber); err != nil {
+			return nil, err
+		}
+	}
+
+	image.Caption = caption
+	image.AltText = altText
+	image.StepNumber = req.StepNumber
+
+	if err := s.postImageRepo.Update(ctx, image); err != nil {
+		return nil, err
+	}
+
+	presentPostImage(s.blob, image, post.Image)
+	return image, nil
+}
+
+// ReorderImages puts a post's gallery in the order of imageIDs, which must
+// list every image of the gallery exactly once.
+func (s *galleryService) ReorderImages(ctx context.Context, postID int, currentUserID int, permissions models.Permissions, imageIDs []int) ([]models.PostImage, error) {
+	if _, err := s.managedPost(ctx, postID, currentUserID, permissions); err != nil {
+		return nil, err
+	}
+
+	images, err := s.postImageRepo.GetByPostID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make([]int, 0, len(images))
+	for _, image := range images {
+		current = append(current, image.ID)
+	}
+	requested := slices.Clone(imageIDs)
+	slices.Sort(current)
+	slices.Sort(requested)
+	if !slices.Equal(current, requested) {
+		return nil, logger.ValidationError("image_ids must list every image of the post exactly once")
+	}
+
+	if err := s.postImageRepo.Reorder(ctx, postID, imageIDs); err != nil {
+		return nil, err
+	}
+
+	return s.GetImages(ctx, postID, currentUserID, permissions)
+}
+
+// DeleteImage removes an image from a post's gallery. Its files are deleted
+// unless the post still uses the image as its cover or in a revision.
+func (s *galleryService) DeleteImage(ctx context.Context, postID int, imageID int, currentUserID int, permissions models.Permissions) error {
+	if _, err := s.managedPost(ctx, postID, currentUserID, permissions); err != nil {
+		return err
+	}
+
+	image, err := s.galleryImage(ctx, postID, imageID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.postImageRepo.Delete(ctx, imageID); err != nil {
+		return err
+	}
+
+	inUse, err := s.postRepo.GetImages(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(inUse, image.Image) {
+		deleteImageFiles(ctx, s.blob, []string{image.Image})
+	}
+	return nil
+}
+
+// SetCover makes a gallery image the post's cover. The change is recorded as
+// a revision like any other edit of the post's image.
+func (s *galleryService) SetCover(ctx context.Context, postID int, imageID int, currentUserID int, permissions models.Permissions) error {
+	post, err := s.managedPost(ctx, postID, currentUserID, permissions)
+	if err != nil {
+		return err
+	}
+
+	image, err := s.galleryImage(ctx, postID, imageID)
+	if err != nil {
+		return err
+	}
+
+	if stringValue(post.Image) == image.Image {
+		return nil
+	}
+
+	count, err := s.revisionRepo.Count(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		if _, err := recordRevision(ctx, s.revisionRepo, post, post.UserID); err != nil {
+			return err
+		}
+	}
+
+	post.Image = &image.Image
+	if err := s.postRepo.Update(ctx, post); err != nil {
+		return err
+	}
+
+	_, err = recordRevision(ctx, s.revisionRepo, post, currentUserID)
+	return err
+}
+
+// managedPost loads a post the user is allowed to manage, with the same rule
+// as editing it.
+func (s *galleryService) managedPost(ctx context.Context, postID int, currentUserID int, permissions models.Permissions) (*models.Post, error) {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, logger.ValidationError("post not found")
+	}
+
+	if !canManagePost(post, currentUserID, permissions) {
+		return nil, logger.ValidationError("access denied: you do not have permission to edit this post's images")
+	}
+
+	return post, nil
+}
+
+// galleryImage loads an image and checks that it belongs to the post.
+func (s *galleryService) galleryImage(ctx context.Context, postID int, imageID int) (*models.PostImage, error) {
+	image, err := s.postImageRepo.GetByID(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if image.PostID != postID {
+		return nil, logger.ValidationError("image not found")
+	}
+	return image, nil
+}
+
+// checkStep verifies that the post's recipe has the given step.
+func (s *galleryService) checkStep(ctx context.Context, postID int, stepNumber int) error {
+	recipe, err := s.recipeRepo.GetByPostID(ctx, postID)
+	if err != nil {
+		if err.Error() == "recipe not found" {
+			return logger.ValidationError("step_number needs the post to have a recipe")
+		}
+		return err
+	}
+
+	for _, step := range recipe.Steps {
+		if step.StepNumber == stepNumber {
+			return nil
+		}
+	}
+	return logger.ValidationError("the recipe has no such step")
+}
+
+// appendGalleryImages adds stored images to the end of a post's gallery in
+// the order given.
+func appendGalleryImages(ctx context.Context, repo repository.PostImageRepository, postID int, keys []string) error {
+	for _, key := range keys {
+		if err := repo.Create(ctx, &models.PostImage{PostID: postID, Image: key}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeGalleryImages validates and stores the files uploaded for a gallery
+// that already holds existing images. If one file fails, the files stored
+// before it are deleted again.
+func storeGalleryImages(ctx context.Context, blob storage.Blob, validator *upload.Validator, files []*multipart.FileHeader, existing int) ([]string, error) {
+	if len(files) > maxGalleryUploads {
+		return nil, logger.ValidationError("at most 10 images can be uploaded at once")
+	}
+	if existing+len(files) > maxGalleryImages {
+		return nil, logger.ValidationError("a post cannot have more than 20 gallery images")
+	}
+
+	keys := make([]string, 0, len(files))
+	for _, file := range files {
+		key, err := storeImage(ctx, blob, validator, file)
+		if err != nil {
+			deleteImageFiles(ctx, blob, keys)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// presentPostImage fills in the URLs of a gallery image for a response and
+// marks it as the cover when the post's cover is the same file.
+func presentPostImage(blob storage.Blob, image *models.PostImage, cover *string) {
+	image.IsCover = image.Image == stringValue(cover)
+	image.Variants = imageVariantURLs(blob, &image.Image)
+	image.Image = blob.URL(image.Image)
+}
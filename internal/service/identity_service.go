@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/oidc"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+	"github.com/rs/zerolog/log"
+)
+
+type IdentityService interface {
+	Providers() []string
+	Authorize(ctx context.Context, provider string, userID int) (*models.IdentityAuthorization, error)
+	Callback(ctx context.Context, provider string, code string, state string, stateToken string, client models.ClientInfo) (*models.IdentityCallback, error)
+
+	GetIdentities(ctx context.Context, userID int) ([]models.UserIdentity, error)
+	Unlink(ctx context.Context, userID int, identityID int) error
+}
+
+type identityService struct {
+	identityRepo repository.IdentityRepository
+	userRepo     repository.UserRepository
+	userService  UserService
+	emailService EmailService
+	providers    map[string]*oidc.Provider
+	names        []string
+}
+
+// NewIdentityService creates the service for logging in with OpenID Connect
+// providers.
+func NewIdentityService(identityRepo repository.IdentityRepository, userRepo repository.UserRepository, userService UserService, emailService EmailService, providers []*oidc.Provider) IdentityService {
+	s := &identityService{
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		userService:  userService,
+		emailService: emailService,
+		providers:    make(map[string]*oidc.Provider, len(providers)),
+		names:        make([]string, 0, len(providers)),
+	}
+	for _, provider := range providers {
+		s.providers[provider.Name()] = provider
+		s.names = append(s.names, provider.Name())
+	}
+	return s
+}
+
+const (
+	// identityStateTTL is how long a user has to log in at the provider.
+	identityStateTTL = 10 * time.Minute
+	identityPurpose  = "oidc"
+	// defaultRole is the role the users table gives new users.
+	defaultRole = "user"
+)
+
+var usernameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// Providers returns the names of the providers users can log in with.
+func (s *identityService) Providers() []string {
+	return s.names
+}
+
+// Authorize starts a login with a provider, or links one to the account of
+// userID when it is not 0. The state token ties the provider's callback to
+// the browser that started it, and carries the PKCE verifier and nonce the
+// callback needs.
+func (s *identityService) Authorize(ctx context.Context, providerName string, userID int) (*models.IdentityAuthorization, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, logger.ValidationError("login provider not found")
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		return nil, logger.LogError(err, "error generating login state")
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return nil, logger.LogError(err, "error generating login nonce")
+	}
+	verifier, err := oidc.RandomString()
+	if err != nil {
+		return nil, logger.LogError(err, "error generating login verifier")
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		logger.LogError(err, "failed to start login with provider")
+		return nil, logger.ValidationError(providerName + " login is unavailable, please try again later")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(identityStateTTL)
+
+	// The purpose claim keeps the state token from being accepted as an
+	// access token; see middleware.Protected.
+	claims := jwt.MapClaims{
+		"purpose":  identityPurpose,
+		"provider": providerName,
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+		"user_id":  userID,
+		"iat":      now.Unix(),
+		"exp":      expiresAt.Unix(),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		return nil, logger.LogError(err, "error creating login state")
+	}
+
+	return &models.IdentityAuthorization{AuthorizationURL: authURL, StateToken: token, ExpiresAt: expiresAt}, nil
+}
+
+type identityState struct {
+	userID   int
+	state    string
+	nonce    string
+	verifier string
+}
+
+func (s *identityService) parseState(providerName string, state string, stateToken string) (*identityState, error) {
+	invalid := logger.ValidationError("login has expired or was started in another browser, please try again")
+
+	token, err := jwt.Parse(stateToken, func(t *jwt.Token) (any, error) {
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, invalid
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, invalid
+	}
+
+	purpose, _ := claims["purpose"].(string)
+	provider, _ := claims["provider"].(string)
+	userID, _ := claims["user_id"].(float64)
+	parsed := &identityState{userID: int(userID)}
+	parsed.state, _ = claims["state"].(string)
+	parsed.nonce, _ = claims["nonce"].(string)
+	parsed.verifier, _ = claims["verifier"].(string)
+
+	if purpose != identityPurpose || provider != providerName || parsed.state == "" ||
+		subtle.ConstantTimeCompare([]byte(parsed.state), []byte(state)) != 1 {
+		return nil, invalid
+	}
+	return parsed, nil
+}
+
+// Callback finishes a login or link once the provider sends the user back
+// with a code. A login with an identity nobody has linked creates an account,
+// unless the provider's email belongs to an existing one: that account could
+// be someone else's, so its owner has to log in and link the provider
+// themselves.
+func (s *identityService) Callback(ctx context.Context, providerName string, code string, state string, stateToken string, client models.ClientInfo) (*models.IdentityCallback, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, logger.ValidationError("login provider not found")
+	}
+
+	flow, err := s.parseState(providerName, state, stateToken)
+	if err != nil {
+		return nil, err
+	}
+	if code == "" {
+		return nil, logger.ValidationError(providerName + " login failed, please try again")
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, code, flow.verifier)
+	if err != nil {
+		logger.LogError(err, "failed to exchange login code")
+		return nil, logger.ValidationError(providerName + " login failed, please try again")
+	}
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, flow.nonce)
+	if err != nil {
+		logger.LogError(err, "rejected ID token")
+		return nil, logger.ValidationError(providerName + " login failed, please try again")
+	}
+
+	if flow.userID != 0 {
+		identity, err := s.link(ctx, flow.userID, providerName, claims)
+		if err != nil {
+			return nil, err
+		}
+		return &models.IdentityCallback{Linked: identity}, nil
+	}
+
+	user, err := s.userFor(ctx, providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.userService.LoginWithIdentity(ctx, *user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &models.IdentityCallback{Login: result}, nil
+}
+
+// userFor returns the user an identity logs in as, creating them on their
+// first login.
+func (s *identityService) userFor(ctx context.Context, providerName string, claims *oidc.Claims) (*models.User, error) {
+	identity, err := s.identityRepo.GetByProviderSubject(ctx, providerName, claims.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			if err.Error() == "user not found" {
+				return nil, logger.ValidationError("the account linked to this login has been deleted")
+			}
+			return nil, err
+		}
+		s.identityRepo.Touch(ctx, identity.ID)
+		return &user, nil
+	}
+	if err.Error() != "identity not found" {
+		return nil, err
+	}
+
+	email := providerEmail(claims)
+	if email != nil {
+		_, err := s.userRepo.GetByEmail(ctx, *email)
+		if err == nil {
+			return nil, logger.ValidationError("an account with this email already exists, log in to it and link " + providerName + " from your account")
+		} else if err.Error() != "user not found" {
+			return nil, err
+		}
+	}
+
+	username, err := s.availableUsername(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{Username: username, Email: email}
+	if email != nil && claims.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	identity = &models.UserIdentity{Provider: providerName, Subject: claims.Subject, Email: email}
+
+	if err := s.identityRepo.CreateUserWithIdentity(ctx, user, identity); err != nil {
+		return nil, err
+	}
+	user.Role = defaultRole
+
+	log.Info().Int("user_id", user.ID).Str("provider", providerName).Msg("Account created with login provider")
+
+	if email != nil && user.EmailVerifiedAt == nil {
+		if err := s.emailService.SendVerification(ctx, *user); err != nil {
+			log.Warn().Err(err).Int("user_id", user.ID).Msg("failed to send verification email")
+		}
+	}
+	return user, nil
+}
+
+// link adds an identity to the account of the user who started the link.
+func (s *identityService) link(ctx context.Context, userID int, providerName string, claims *oidc.Claims) (*models.UserIdentity, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.identityRepo.GetByProviderSubject(ctx, providerName, claims.Subject)
+	if err == nil {
+		if existing.UserID == userID {
+			return nil, logger.ValidationError("this " + providerName + " account is already linked to your account")
+		}
+		return nil, logger.ValidationError("this " + providerName + " account is linked to another user")
+	} else if err.Error() != "identity not found" {
+		return nil, err
+	}
+
+	identities, err := s.identityRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, identity := range identities {
+		if identity.Provider == providerName {
+			return nil, logger.ValidationError("you already linked a " + providerName + " account, unlink it first")
+		}
+	}
+
+	identity := &models.UserIdentity{
+		UserID:   userID,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    providerEmail(claims),
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		return nil, err
+	}
+	identity.CreatedAt = time.Now()
+	return identity, nil
+}
+
+func (s *identityService) GetIdentities(ctx context.Context, userID int) ([]models.UserIdentity, error) {
+	return s.identityRepo.GetByUser(ctx, userID)
+}
+
+// Unlink removes one of the current user's identities. An account created
+// through a provider has no password, so its last identity stays until the
+// user sets one.
+func (s *identityService) Unlink(ctx context.Context, userID int, identityID int) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	identities, err := s.identityRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, identity := range identities {
+		if identity.ID == identityID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return logger.ValidationError("identity not found")
+	}
+
+	if user.Password == "" && len(identities) == 1 {
+		return logger.ValidationError("this is the only way to log in to your account, set a password before unlinking it")
+	}
+
+	return s.identityRepo.Delete(ctx, userID, identityID)
+}
+
+// providerEmail returns the email from an ID token, or nil when there is none
+// this site can use.
+func providerEmail(claims *oidc.Claims) *string {
+	if claims.Email == "" {
+		return nil
+	}
+	email, err := normalizeEmail(claims.Email)
+	if err != nil {
+		return nil
+	}
+	return &email
+}
+
+// availableUsername picks a username for a new account from the profile the
+// provider shared, adding a number when it is taken.
+func (s *identityService) availableUsername(ctx context.Context, claims *oidc.Claims) (string, error) {
+	base := ""
+	for _, candidate := range []string{claims.PreferredUsername, strings.Split(claims.Email, "@")[0], claims.Name} {
+		base = strings.Trim(usernameUnsafe.ReplaceAllString(candidate, "_"), "_")
+		if base != "" {
+			break
+		}
+	}
+	if base == "" {
+		base = "user"
+	}
+	// Room is left for the number.
+	if len(base) > maxUsernameLength-6 {
+		base = base[:maxUsernameLength-6]
+	}
+
+	username := base
+	for attempt := 0; attempt < 10; attempt++ {
+		_, err := s.userRepo.GetByUsername(ctx, username)
+		if err != nil {
+			if err.Error() == "user not found" {
+				return username, nil
+			}
+			return "", err
+		}
+
+		n, err := rand.Int(rand.Reader, big.NewInt(100000))
+		if err != nil {
+			return "", logger.LogError(err, "error generating username")
+		}
+		username = fmt.Sprintf("%s_%d", base, n.Int64())
+	}
+	return "", logger.ValidationError("could not find a free username, please register instead")
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
+	"github.com/rafli2460/culinary-blog-api/internal/upload"
+	"github.com/rafli2460/culinary-blog-api/pkg/imaging"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+	"github.com/rs/zerolog/log"
+)
+
+// imageVariants are the sizes every uploaded image is stored in. The key of
+// the full variant is the one saved on the post; the other variants are stored
+// next to it under the same name with their own suffix.
+var imageVariants = []imaging.Variant{
+	{Name: "thumb", MaxWidth: 320, MaxHeight: 320},
+	{Name: "card", MaxWidth: 800, MaxHeight: 800},
+	{Name: fullImageVariant, MaxWidth: 2048, MaxHeight: 2048},
+}
+
+const fullImageVariant = "full"
+
+// storeImage validates an uploaded image, renders its variants without
+// metadata and saves them to blob. It returns the key of the full variant.
+func storeImage(ctx context.Context, blob storage.Blob, validator *upload.Validator, file *multipart.FileHeader) (string, error) {
+	img, err := validator.Image(file)
+	if err != nil {
+		var uploadErr *upload.Error
+		if errors.As(err, &uploadErr) {
+			return "", err
+		}
+		return "", logger.LogError(err, "error reading uploaded file")
+	}
+
+	renditions, err := imaging.Process(img.Data, imageVariants)
+	if err != nil {
+		return "", &upload.Error{Kind: upload.Invalid, Message: "file is not a valid image"}
+	}
+
+	base := strconv.FormatInt(time.Now().UnixNano(), 10)
+	stored := make([]string, 0, len(renditions))
+	for _, rendition := range renditions {
+		key := base + "-" + rendition.Name + ".jpg"
+		if err := blob.Put(ctx, key, bytes.NewReader(rendition.Data), int64(len(rendition.Data)), "image/jpeg"); err != nil {
+			for _, done := range stored {
+				blob.Delete(ctx, done)
+			}
+			return "", logger.LogErrorWithFields(err, "failed to save image file", map[string]interface{}{
+				"key": key,
+			})
+		}
+		stored = append(stored, key)
+	}
+
+	return base + "-" + fullImageVariant + ".jpg", nil
+}
+
+// imageVariantKeys returns the keys of every variant of a stored image, by
+// variant name. Images uploaded before variants existed only have themselves,
+// listed as the full variant.
+func imageVariantKeys(key string) map[string]string {
+	suffix := "-" + fullImageVariant + ".jpg"
+	if !strings.HasSuffix(key, suffix) {
+		return map[string]string{fullImageVariant: key}
+	}
+
+	base := strings.TrimSuffix(key, suffix)
+	keys := make(map[string]string, len(imageVariants))
+	for _, variant := range imageVariants {
+		keys[variant.Name] = base + "-" + variant.Name + ".jpg"
+	}
+	return keys
+}
+
+// deleteImageFiles deletes stored images, with all their variants. A file
+// that cannot be removed is logged and skipped.
+func deleteImageFiles(ctx context.Context, blob storage.Blob, images []string) {
+	for _, image := range images {
+		for _, key := range imageVariantKeys(image) {
+			if err := blob.Delete(ctx, key); err != nil {
+				log.Warn().Err(err).Str("file", key).Msg("Failed to delete image file")
+			} else {
+				log.Info().Str("file", key).Msg("Image file successfully deleted")
+			}
+		}
+	}
+}
+
+// imageURL turns a stored image key into the URL clients download it from.
+func imageURL(blob storage.Blob, image *string) *string {
+	if image == nil || *image == "" {
+		return image
+	}
+	url := blob.URL(*image)
+	return &url
+}
+
+// avatarURL returns the URL of the thumbnail of an avatar, which is the size
+// shown next to an author's name.
+func avatarURL(blob storage.Blob, avatar *string) *string {
+	if avatar == nil || *avatar == "" {
+		return avatar
+	}
+
+	keys := imageVariantKeys(*avatar)
+	key, ok := keys["thumb"]
+	if !ok {
+		key = keys[fullImageVariant]
+	}
+	url := blob.URL(key)
+	return &url
+}
+
+// imageVariantURLs returns the download URL of every variant of an image, or
+// nil when there is no image.
+func imageVariantURLs(blob storage.Blob, image *string) map[string]string {
+	if image == nil || *image == "" {
+		return nil
+	}
+
+	urls := make(map[string]string, len(imageVariants))
+	for name, key := range imageVariantKeys(*image) {
+		urls[name] = blob.URL(key)
+	}
+	return urls
+}
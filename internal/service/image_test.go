@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/rafli2460/culinary-blog-api/internal/upload"
+)
+
+// memoryBlob is a storage.Blob kept in a map. Put fails once failAfter blobs
+// are stored, when failAfter is set.
+type memoryBlob struct {
+	blobs     map[string][]byte
+	failAfter int
+}
+
+func newMemoryBlob() *memoryBlob {
+	return &memoryBlob{blobs: map[string][]byte{}}
+}
+
+func (b *memoryBlob) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if b.failAfter > 0 && len(b.blobs) >= b.failAfter {
+		return errors.New("disk full")
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	b.blobs[key] = data
+	return nil
+}
+
+func (b *memoryBlob) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := b.blobs[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (b *memoryBlob) Delete(ctx context.Context, key string) error {
+	delete(b.blobs, key)
+	return nil
+}
+
+func (b *memoryBlob) URL(key string) string {
+	return "/uploads/" + key
+}
+
+// uploadedFile returns data as a file uploaded in a multipart form.
+func uploadedFile(t *testing.T, data []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("image", "photo.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(int64(body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["image"][0]
+}
+
+func jpegFile(t *testing.T, width, height int) *multipart.FileHeader {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return uploadedFile(t, buf.Bytes())
+}
+
+func TestStoreImage(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		// The expected size of each variant, by name.
+		want map[string][2]int
+	}{
+		{"large", 3000, 2000, map[string][2]int{"thumb": {320, 213}, "card": {800, 533}, "full": {2048, 1365}}},
+		{"between variants", 500, 1000, map[string][2]int{"thumb": {160, 320}, "card": {400, 800}, "full": {500, 1000}}},
+		{"small", 200, 100, map[string][2]int{"thumb": {200, 100}, "card": {200, 100}, "full": {200, 100}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blob := newMemoryBlob()
+			key, err := storeImage(context.Background(), blob, upload.NewValidator(upload.DefaultLimits), jpegFile(t, tt.width, tt.height))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(key, "-full.jpg") {
+				t.Errorf("key = %q, want the full variant", key)
+			}
+			if len(blob.blobs) != len(imageVariants) {
+				t.Errorf("stored %d blobs, want %d", len(blob.blobs), len(imageVariants))
+			}
+
+			for name, variantKey := range imageVariantKeys(key) {
+				data, ok := blob.blobs[variantKey]
+				if !ok {
+					t.Errorf("%s variant %q was not stored", name, variantKey)
+					continue
+				}
+				config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+				if err != nil {
+					t.Errorf("%s variant is not a JPEG: %v", name, err)
+					continue
+				}
+				if size := tt.want[name]; config.Width != size[0] || config.Height != size[1] {
+					t.Errorf("%s variant is %dx%d, want %dx%d", name, config.Width, config.Height, size[0], size[1])
+				}
+			}
+		})
+	}
+}
+
+func TestStoreImageRejectsInvalidFiles(t *testing.T) {
+	blob := newMemoryBlob()
+	_, err := storeImage(context.Background(), blob, upload.NewValidator(upload.DefaultLimits), uploadedFile(t, []byte("plain text, not an image")))
+
+	var uploadErr *upload.Error
+	if !errors.As(err, &uploadErr) || uploadErr.Kind != upload.UnsupportedType {
+		t.Errorf("storeImage error = %v, want an unsupported type upload error", err)
+	}
+	if len(blob.blobs) != 0 {
+		t.Errorf("stored %d blobs for an invalid file", len(blob.blobs))
+	}
+}
+
+func TestStoreImageCleansUpOnFailure(t *testing.T) {
+	blob := newMemoryBlob()
+	blob.failAfter = 2
+
+	if _, err := storeImage(context.Background(), blob, upload.NewValidator(upload.DefaultLimits), jpegFile(t, 400, 300)); err == nil {
+		t.Fatal("storeImage succeeded although the blob store failed")
+	}
+	if len(blob.blobs) != 0 {
+		t.Errorf("%d variants were left behind", len(blob.blobs))
+	}
+}
+
+func TestImageVariantKeys(t *testing.T) {
+	keys := imageVariantKeys("1700000000-full.jpg")
+	want := map[string]string{
+		"thumb": "1700000000-thumb.jpg",
+		"card":  "1700000000-card.jpg",
+		"full":  "1700000000-full.jpg",
+	}
+	if len(keys) != len(want) {
+		t.Fatalf("imageVariantKeys = %v, want %v", keys, want)
+	}
+	for name, key := range want {
+		if keys[name] != key {
+			t.Errorf("%s key = %q, want %q", name, keys[name], key)
+		}
+	}
+
+	// Images stored before variants existed are only their own full size.
+	legacy := imageVariantKeys("1600000000.png")
+	if len(legacy) != 1 || legacy[fullImageVariant] != "1600000000.png" {
+		t.Errorf("imageVariantKeys(legacy) = %v", legacy)
+	}
+
+	avatar := want["full"]
+	if url := avatarURL(newMemoryBlob(), &avatar); *url != "/uploads/1700000000-thumb.jpg" {
+		t.Errorf("avatarURL = %q, want the thumbnail", *url)
+	}
+}
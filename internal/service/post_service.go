@@ -2,39 +2,59 @@ package service
 
 import (
 	"context"
-	"fmt"
-	"io"
 	"mime/multipart"
-	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/rafli2460/culinary-blog-api/internal/models"
 	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
+	"github.com/rafli2460/culinary-blog-api/internal/upload"
 	"github.com/rafli2460/culinary-blog-api/pkg/logger"
-	"github.com/rs/zerolog/log"
+	"github.com/rafli2460/culinary-blog-api/pkg/measure"
+	"github.com/rafli2460/culinary-blog-api/pkg/slug"
 )
 
 type PostService interface {
-	CreatePost(ctx context.Context, userID int, title, content string, file *multipart.FileHeader) error
-	DeletePost(ctx context.Context, postID, currentUserID int, currentUserRole string) error
-	UpdatePost(ctx context.Context, postID, currentUserID int, currentUserRole string, title, content string, file *multipart.FileHeader) error
+	CreatePost(ctx context.Context, userID int, req models.PostRequest, file *multipart.FileHeader, gallery []*multipart.FileHeader) error
+	DeletePost(ctx context.Context, postID, currentUserID int, permissions models.Permissions) error
+	UpdatePost(ctx context.Context, postID, currentUserID int, permissions models.Permissions, req models.PostRequest, file *multipart.FileHeader, gallery []*multipart.FileHeader) error
 	GetPost(ctx context.Context, id int) (*models.PostDetail, error)
-	GetAllPosts(ctx context.Context, page int, limit int) ([]models.PostDetail, error)
+	GetAllPosts(ctx context.Context, filter models.PostFilter, page int, limit int) ([]models.PostDetail, error)
+	GetMyPosts(ctx context.Context, userID int, status string, page int, limit int) ([]models.PostDetail, error)
+	GetAuthorPosts(ctx context.Context, authorID int, page int, limit int) ([]models.PostDetail, error)
+	PreviewPost(ctx context.Context, id, currentUserID int, permissions models.Permissions) (*models.PostDetail, error)
+	PublishDuePosts(ctx context.Context, now time.Time) (int, error)
+	GetPostBySlug(ctx context.Context, slug string) (*models.PostDetail, string, error)
+	BackfillSlugs(ctx context.Context) (int, error)
+	GetScaledPost(ctx context.Context, id int, servings int, units string) (*models.ScaledRecipe, error)
+	RatePost(ctx context.Context, postID, currentUserID int, req models.RatingRequest) (*models.Rating, error)
+	UpdateRating(ctx context.Context, postID, currentUserID int, req models.RatingRequest) (*models.Rating, error)
+	DeleteRating(ctx context.Context, postID, currentUserID int) error
+	GetRatings(ctx context.Context, postID int, sort string, page int, limit int) ([]models.Rating, int, error)
 }
 
 type postService struct {
-	postRepo repository.PostRepository
+	postRepo      repository.PostRepository
+	recipeRepo    repository.RecipeRepository
+	taxonomyRepo  repository.TaxonomyRepository
+	ratingRepo    repository.RatingRepository
+	revisionRepo  repository.RevisionRepository
+	postImageRepo repository.PostImageRepository
+	blob          storage.Blob
+	validator     *upload.Validator
 }
 
-func NewPostService(repo repository.PostRepository) PostService {
-	return &postService{postRepo: repo}
+func NewPostService(repo repository.PostRepository, recipeRepo repository.RecipeRepository, taxonomyRepo repository.TaxonomyRepository, ratingRepo repository.RatingRepository, revisionRepo repository.RevisionRepository, postImageRepo repository.PostImageRepository, blob storage.Blob, validator *upload.Validator) PostService {
+	return &postService{postRepo: repo, recipeRepo: recipeRepo, taxonomyRepo: taxonomyRepo, ratingRepo: ratingRepo, revisionRepo: revisionRepo, postImageRepo: postImageRepo, blob: blob, validator: validator}
 }
 
-func (s *postService) CreatePost(ctx context.Context, userID int, title string, content string, file *multipart.FileHeader) error {
-	title = strings.TrimSpace(title)
-	content = strings.TrimSpace(content)
+// CreatePost saves a new post with an optional cover image and gallery. When
+// no cover is uploaded, the first gallery image becomes the cover.
+func (s *postService) CreatePost(ctx context.Context, userID int, req models.PostRequest, file *multipart.FileHeader, gallery []*multipart.FileHeader) error {
+	title := strings.TrimSpace(req.Title)
+	content := strings.TrimSpace(req.Content)
 
 	if title == "" {
 		return logger.ValidationError("title is required")
@@ -44,154 +64,808 @@ func (s *postService) CreatePost(ctx context.Context, userID int, title string,
 		return logger.ValidationError("content is required")
 	}
 
-	var imageName *string
+	tagIDs, err := s.resolveTaxonomy(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	post := &models.Post{
+		UserID:  userID,
+		Title:   title,
+		Content: content,
+	}
+
+	postSlug, err := uniqueSlug(ctx, s.postRepo, title, 0)
+	if err != nil {
+		return err
+	}
+	post.Slug = &postSlug
 
+	status := req.Status
+	if status == "" {
+		status = models.PostPublished
+	}
+	if err := applyPostStatus(post, status, req.PublishAt, time.Now()); err != nil {
+		return err
+	}
+
+	var stored []string
 	if file != nil {
-		const maxFileSize = 5 * 1024 * 1024
-		if file.Size > maxFileSize {
-			return logger.ValidationError("file size cannot exceed 5MB")
+		key, err := storeImage(ctx, s.blob, s.validator, file)
+		if err != nil {
+			return err
+		}
+		post.Image = &key
+		stored = append(stored, key)
+	}
+
+	galleryKeys, err := storeGalleryImages(ctx, s.blob, s.validator, gallery, 0)
+	if err != nil {
+		deleteImageFiles(ctx, s.blob, stored)
+		return err
+	}
+	stored = append(stored, galleryKeys...)
+	if post.Image == nil && len(galleryKeys) > 0 {
+		post.Image = &galleryKeys[0]
+	}
+
+	// Nothing references the new files until the post is saved.
+	if err := s.postRepo.Create(ctx, post); err != nil {
+		deleteImageFiles(ctx, s.blob, stored)
+		return err
+	}
+
+	if _, err := recordRevision(ctx, s.revisionRepo, post, userID); err != nil {
+		return err
+	}
+
+	if err := appendGalleryImages(ctx, s.postImageRepo, post.ID, galleryKeys); err != nil {
+		return err
+	}
+
+	return s.assignTaxonomy(ctx, post.ID, req, tagIDs)
+}
+
+// canManagePost reports whether a user may edit a post: its author can, and
+// so can anyone whose role may edit any post.
+func canManagePost(post *models.Post, userID int, permissions models.Permissions) bool {
+	return post.UserID == userID || permissions.Has(models.PermEditAnyPost)
+}
+
+func (s *postService) DeletePost(ctx context.Context, postID int, currentUserID int, permissions models.Permissions) error {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return logger.ValidationError("post not found")
+	}
+
+	if post.UserID != currentUserID && !permissions.Has(models.PermDeleteAnyPost) {
+		return logger.ValidationError("access denied: you do not have permission to delete this post")
+	}
+
+	// The post only goes to the trash; its images are removed when it is purged.
+	return s.postRepo.Delete(ctx, postID)
+}
+
+// UpdatePost edits a post. A new cover replaces the current one, and uploaded
+// gallery images are added after the existing ones.
+func (s *postService) UpdatePost(ctx context.Context, postID int, currentUserID int, permissions models.Permissions, req models.PostRequest, file *multipart.FileHeader, gallery []*multipart.FileHeader) error {
+	existingPost, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return logger.ValidationError("post not found")
+	}
+
+	if !canManagePost(existingPost, currentUserID, permissions) {
+		return logger.ValidationError("access denied: you do not have permission to edit this post")
+	}
+
+	title := strings.TrimSpace(req.Title)
+	content := strings.TrimSpace(req.Content)
+	if title == "" || content == "" {
+		return logger.ValidationError("title and content cannot be empty")
+	}
+
+	tagIDs, err := s.resolveTaxonomy(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	status := req.Status
+	if status == "" && req.PublishAt != nil && existingPost.Status == models.PostScheduled {
+		status = models.PostScheduled
+	}
+	if status != "" {
+		if err := applyPostStatus(existingPost, status, req.PublishAt, time.Now()); err != nil {
+			return err
 		}
+	}
 
-		ext := strings.ToLower(filepath.Ext(file.Filename))
-		allowedExts := map[string]bool{
-			".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true,
+	// Posts written before revisions existed get their current state saved
+	// first, so the edit below can still be undone.
+	count, err := s.revisionRepo.Count(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		if _, err := recordRevision(ctx, s.revisionRepo, existingPost, existingPost.UserID); err != nil {
+			return err
 		}
-		if !allowedExts[ext] {
-			return logger.ValidationError("file format is invalid (only JPG, PNG, GIF, WEBP)")
+	}
+
+	var galleryKeys []string
+	if len(gallery) > 0 {
+		existing, err := s.postImageRepo.Count(ctx, postID)
+		if err != nil {
+			return err
 		}
+		if galleryKeys, err = storeGalleryImages(ctx, s.blob, s.validator, gallery, existing); err != nil {
+			return err
+		}
+	}
 
-		uploadDir := "./uploads"
-		if err := os.MkdirAll(uploadDir, 0755); err != nil {
-			return logger.LogError(err, "error creating upload directory")
+	stored := galleryKeys
+	if file != nil {
+		key, err := storeImage(ctx, s.blob, s.validator, file)
+		if err != nil {
+			deleteImageFiles(ctx, s.blob, stored)
+			return err
 		}
+		// The old image is kept: earlier revisions still point to it.
+		existingPost.Image = &key
+		stored = append(stored, key)
+	}
+
+	existingPost.Title = title
+	existingPost.Content = content
+
+	// Nothing references the new files until the post is saved.
+	if err := s.postRepo.Update(ctx, existingPost); err != nil {
+		deleteImageFiles(ctx, s.blob, stored)
+		return err
+	}
+
+	if _, err := recordRevision(ctx, s.revisionRepo, existingPost, currentUserID); err != nil {
+		return err
+	}
 
-		newFileName := fmt.Sprintf("%d%s", time.Now().UnixNano(), ext)
-		dstPath := filepath.Join(uploadDir, newFileName)
+	if err := syncSlug(ctx, s.postRepo, existingPost); err != nil {
+		return err
+	}
+
+	if err := appendGalleryImages(ctx, s.postImageRepo, postID, galleryKeys); err != nil {
+		return err
+	}
+
+	return s.assignTaxonomy(ctx, postID, req, tagIDs)
+}
 
-		src, err := file.Open()
+// GetPost returns a published post. Drafts, scheduled and archived posts are
+// reported as not found.
+func (s *postService) GetPost(ctx context.Context, id int) (*models.PostDetail, error) {
+	post, err := s.postRepo.GetPostDetailByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if post.Status != models.PostPublished {
+		return nil, logger.ValidationError("post not found")
+	}
+
+	posts := []models.PostDetail{*post}
+	if err := s.attachDetails(ctx, posts); err != nil {
+		return nil, err
+	}
+
+	return &posts[0], nil
+}
+
+func (s *postService) GetAllPosts(ctx context.Context, filter models.PostFilter, page int, limit int) ([]models.PostDetail, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	filter.Category = slug.Make(filter.Category)
+	filter.Cuisine = slug.Make(filter.Cuisine)
+	filter.Tag = slug.Make(filter.Tag)
+	filter.Status = models.PostPublished
+	filter.AuthorID = 0
+
+	posts, err := s.postRepo.GetAll(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachDetails(ctx, posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// GetPostBySlug returns a published post by its slug. When the slug is one
+// the post used before a title change, the post is not returned; instead the
+// second value holds its current slug so the caller can redirect.
+func (s *postService) GetPostBySlug(ctx context.Context, postSlug string) (*models.PostDetail, string, error) {
+	postSlug = strings.ToLower(strings.TrimSpace(postSlug))
+	if postSlug == "" {
+		return nil, "", logger.ValidationError("post not found")
+	}
+
+	post, err := s.postRepo.GetPostDetailBySlug(ctx, postSlug)
+	if err != nil {
+		if err.Error() != "post not found" {
+			return nil, "", err
+		}
+
+		current, err := s.postRepo.GetCurrentSlug(ctx, postSlug)
 		if err != nil {
-			return logger.LogError(err, "error reading uploaded file")
+			return nil, "", err
 		}
-		defer src.Close()
+		return nil, current, nil
+	}
+
+	if post.Status != models.PostPublished {
+		return nil, "", logger.ValidationError("post not found")
+	}
+
+	posts := []models.PostDetail{*post}
+	if err := s.attachDetails(ctx, posts); err != nil {
+		return nil, "", err
+	}
+
+	return &posts[0], "", nil
+}
 
-		dst, err := os.Create(dstPath)
+const slugBackfillBatch = 200
+
+// BackfillSlugs gives every post without a slug one generated from its title
+// and returns how many posts were updated.
+func (s *postService) BackfillSlugs(ctx context.Context) (int, error) {
+	updated := 0
+	for {
+		posts, err := s.postRepo.GetWithoutSlug(ctx, slugBackfillBatch)
 		if err != nil {
-			return logger.LogError(err, "failed to create file")
+			return updated, err
+		}
+		if len(posts) == 0 {
+			return updated, nil
 		}
-		defer dst.Close()
 
-		if _, err := io.Copy(dst, src); err != nil {
-			return logger.LogError(err, "failed to save image file")
+		for i := range posts {
+			if err := syncSlug(ctx, s.postRepo, &posts[i]); err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+}
+
+// maxSlugAttempts bounds the numbered suffixes tried for a colliding slug
+// before falling back to the post ID.
+const maxSlugAttempts = 50
+
+// uniqueSlug derives a slug from title that no other post uses or has used,
+// adding -2, -3, ... on collision.
+func uniqueSlug(ctx context.Context, postRepo repository.PostRepository, title string, postID int) (string, error) {
+	base := titleSlug(title)
+
+	for n := 1; n <= maxSlugAttempts; n++ {
+		candidate := base
+		if n > 1 {
+			candidate = slug.WithSuffix(base, n)
+		}
+
+		taken, err := postRepo.SlugTaken(ctx, candidate, postID)
+		if err != nil {
+			return "", err
 		}
+		if !taken {
+			return candidate, nil
+		}
+	}
 
-		imageName = &newFileName
+	return slug.WithSuffix(base, int(time.Now().UnixNano()%1_000_000)), nil
+}
+
+// titleSlug is the slug a title asks for, before any collision suffix.
+func titleSlug(title string) string {
+	if base := slug.Make(title); base != "" {
+		return base
 	}
+	return "post"
+}
 
-	post := &models.Post{
-		UserID:  userID,
-		Title:   title,
-		Content: content,
-		Image:   imageName,
+// syncSlug gives a post a new slug when its title no longer matches the
+// current one, keeping the old slug in the history for redirects. A slug that
+// already matches the title, with or without a numeric suffix, is kept.
+func syncSlug(ctx context.Context, postRepo repository.PostRepository, post *models.Post) error {
+	if post.Slug != nil && slugMatches(*post.Slug, titleSlug(post.Title)) {
+		return nil
+	}
+
+	newSlug, err := uniqueSlug(ctx, postRepo, post.Title, post.ID)
+	if err != nil {
+		return err
 	}
 
-	return s.postRepo.Create(ctx, post)
+	if err := postRepo.UpdateSlug(ctx, post.ID, newSlug, post.Slug); err != nil {
+		return err
+	}
+	post.Slug = &newSlug
+	return nil
 }
 
-func (s *postService) DeletePost(ctx context.Context, postID int, currentUserID int, currentUserRole string) error {
-	post, err := s.postRepo.GetByID(ctx, postID)
+// slugMatches reports whether current is base or base with a numeric suffix.
+func slugMatches(current string, base string) bool {
+	if current == base {
+		return true
+	}
+
+	idx := strings.LastIndexByte(current, '-')
+	if idx < 0 {
+		return false
+	}
+	n, err := strconv.Atoi(current[idx+1:])
+	if err != nil || n < 2 {
+		return false
+	}
+	return slug.WithSuffix(base, n) == current
+}
+
+// GetMyPosts lists the user's own posts in every state, optionally narrowed to
+// one status.
+func (s *postService) GetMyPosts(ctx context.Context, userID int, status string, page int, limit int) ([]models.PostDetail, error) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status != "" && !postStatuses[status] {
+		return nil, logger.ValidationError("invalid status, must be 'draft', 'published', 'scheduled' or 'archived'")
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	posts, err := s.postRepo.GetAll(ctx, models.PostFilter{Status: status, AuthorID: userID}, limit, offset)
 	if err != nil {
-		return logger.ValidationError("post not found")
+		return nil, err
 	}
 
-	if post.UserID != currentUserID && currentUserRole != "admin" {
-		return logger.ValidationError("access denied: you do not have permission to delete this post")
+	if err := s.attachDetails(ctx, posts); err != nil {
+		return nil, err
 	}
 
-	if post.Image != nil && *post.Image != "" {
-		imagePath := filepath.Join("uploads", *post.Image)
+	return posts, nil
+}
+
+// GetAuthorPosts lists the published posts of one author, newest first.
+func (s *postService) GetAuthorPosts(ctx context.Context, authorID int, page int, limit int) ([]models.PostDetail, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
 
-		if err := os.Remove(imagePath); err != nil {
-			log.Warn().Err(err).Str("file", imagePath).Msg("Failed to delete physical image file, it might not exist")
-		} else {
-			log.Info().Str("file", imagePath).Msg("Physical image file successfully deleted")
+	offset := (page - 1) * limit
+
+	posts, err := s.postRepo.GetAll(ctx, models.PostFilter{Status: models.PostPublished, AuthorID: authorID}, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachDetails(ctx, posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// PreviewPost returns a post in any state to anyone who may edit it.
+func (s *postService) PreviewPost(ctx context.Context, id int, currentUserID int, permissions models.Permissions) (*models.PostDetail, error) {
+	post, err := s.postRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, logger.ValidationError("post not found")
+	}
+
+	if !canManagePost(post, currentUserID, permissions) {
+		return nil, logger.ValidationError("access denied: you do not have permission to view this post")
+	}
+
+	detail, err := s.postRepo.GetPostDetailByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := []models.PostDetail{*detail}
+	if err := s.attachDetails(ctx, posts); err != nil {
+		return nil, err
+	}
+
+	return &posts[0], nil
+}
+
+// PublishDuePosts publishes scheduled posts whose time has come. It is run
+// periodically by the scheduler.
+func (s *postService) PublishDuePosts(ctx context.Context, now time.Time) (int, error) {
+	return s.postRepo.PublishScheduled(ctx, now)
+}
+
+var postStatuses = map[string]bool{
+	models.PostDraft:     true,
+	models.PostPublished: true,
+	models.PostScheduled: true,
+	models.PostArchived:  true,
+}
+
+// applyPostStatus moves a post to status and sets its publish time to match:
+// drafts have none, publishing stamps now unless the post was already live,
+// scheduling needs a future publishAt, and archiving keeps the original date.
+func applyPostStatus(post *models.Post, status string, publishAt *time.Time, now time.Time) error {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if !postStatuses[status] {
+		return logger.ValidationError("invalid status, must be 'draft', 'published', 'scheduled' or 'archived'")
+	}
+
+	switch status {
+	case models.PostDraft:
+		post.PublishedAt = nil
+	case models.PostPublished:
+		if post.Status != models.PostPublished && post.Status != models.PostArchived || post.PublishedAt == nil {
+			post.PublishedAt = &now
+		}
+	case models.PostScheduled:
+		if publishAt == nil {
+			return logger.ValidationError("published_at is required to schedule a post")
+		}
+		if !publishAt.After(now) {
+			return logger.ValidationError("published_at must be in the future to schedule a post")
+		}
+		post.PublishedAt = publishAt
+	case models.PostArchived:
+		if post.Status != models.PostPublished && post.Status != models.PostArchived {
+			return logger.ValidationError("only published posts can be archived")
 		}
 	}
 
-	return s.postRepo.Delete(ctx, postID)
+	post.Status = status
+	return nil
 }
 
-func (s *postService) UpdatePost(ctx context.Context, postID int, currentUserID int, currentUserRole string, title, content string, file *multipart.FileHeader) error {
-	existingPost, err := s.postRepo.GetByID(ctx, postID)
+// attachDetails loads the structured recipes and taxonomy for a page of posts
+// in one pass and sets them on each post.
+func (s *postService) attachDetails(ctx context.Context, posts []models.PostDetail) error {
+	ids := make([]int, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+
+	recipes, err := s.recipeRepo.GetByPostIDs(ctx, ids)
 	if err != nil {
-		return logger.ValidationError("post not found")
+		return err
 	}
 
-	if existingPost.UserID != currentUserID && currentUserRole != "admin" {
-		return logger.ValidationError("access denied: you do not have permission to edit this post")
+	taxonomies, err := s.taxonomyRepo.GetByPostIDs(ctx, ids)
+	if err != nil {
+		return err
 	}
 
-	title = strings.TrimSpace(title)
-	content = strings.TrimSpace(content)
-	if title == "" || content == "" {
-		return logger.ValidationError("title and content cannot be empty")
+	galleries, err := s.postImageRepo.GetByPostIDs(ctx, ids)
+	if err != nil {
+		return err
 	}
 
-	finalImageName := existingPost.Image
+	for i := range posts {
+		posts[i].Images = galleries[posts[i].ID]
+		if posts[i].Images == nil {
+			posts[i].Images = make([]models.PostImage, 0)
+		}
+		for j := range posts[i].Images {
+			presentPostImage(s.blob, &posts[i].Images[j], posts[i].Image)
+		}
 
-	if file != nil {
-		if file.Size > 5*1024*1024 {
-			return logger.ValidationError("file size exceeds maximum limit of 5MB")
+		posts[i].AuthorAvatar = avatarURL(s.blob, posts[i].AuthorAvatar)
+		posts[i].ImageVariants = imageVariantURLs(s.blob, posts[i].Image)
+		posts[i].Image = imageURL(s.blob, posts[i].Image)
+		posts[i].Recipe = recipes[posts[i].ID]
+		if taxonomy, ok := taxonomies[posts[i].ID]; ok {
+			posts[i].Categories = taxonomy.Categories
+			posts[i].Cuisines = taxonomy.Cuisines
+			posts[i].Tags = taxonomy.Tags
+		}
+	}
+
+	return nil
+}
+
+// resolveTaxonomy checks that the requested categories and cuisines exist and
+// turns tag names into tag IDs, creating tags that are new. It runs before the
+// post is written so a bad reference does not leave a half-saved post.
+func (s *postService) resolveTaxonomy(ctx context.Context, req models.PostRequest) ([]int, error) {
+	if ids := uniqueInts(req.CategoryIDs); len(ids) > 0 {
+		count, err := s.taxonomyRepo.CountExisting(ctx, repository.CategoriesTable, ids)
+		if err != nil {
+			return nil, err
+		}
+		if count != len(ids) {
+			return nil, logger.ValidationError("one or more categories do not exist")
 		}
+	}
 
-		ext := strings.ToLower(filepath.Ext(file.Filename))
-		allowedExts := map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true}
-		if !allowedExts[ext] {
-			return logger.ValidationError("invalid file format")
+	if ids := uniqueInts(req.CuisineIDs); len(ids) > 0 {
+		count, err := s.taxonomyRepo.CountExisting(ctx, repository.CuisinesTable, ids)
+		if err != nil {
+			return nil, err
+		}
+		if count != len(ids) {
+			return nil, logger.ValidationError("one or more cuisines do not exist")
 		}
+	}
 
-		newFileName := fmt.Sprintf("%d%s", time.Now().UnixNano(), ext)
-		uploadDir := "./uploads"
-		dstPath := filepath.Join(uploadDir, newFileName)
+	if req.Tags == nil {
+		return nil, nil
+	}
 
-		src, err := file.Open()
+	tagIDs := make([]int, 0, len(req.Tags))
+	seen := make(map[int]bool)
+	for _, name := range req.Tags {
+		term, err := buildTerm(0, models.TermRequest{Name: name})
 		if err != nil {
-			return logger.LogError(err, "failed to read new image file")
+			return nil, logger.ValidationError("invalid tag: " + err.Error())
 		}
-		defer src.Close()
 
-		dst, err := os.Create(dstPath)
+		tag, err := s.taxonomyRepo.GetTagBySlug(ctx, term.Slug)
 		if err != nil {
-			return logger.LogError(err, "failed to create file on server")
+			if err.Error() != "tag not found" {
+				return nil, err
+			}
+			if err := s.taxonomyRepo.CreateTag(ctx, term); err != nil {
+				return nil, err
+			}
+			tag = term
+		}
+
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			tagIDs = append(tagIDs, tag.ID)
+		}
+	}
+
+	return tagIDs, nil
+}
+
+// assignTaxonomy replaces the post's categories, cuisines and tags. Lists that
+// were not sent (nil) are left untouched.
+func (s *postService) assignTaxonomy(ctx context.Context, postID int, req models.PostRequest, tagIDs []int) error {
+	if req.CategoryIDs != nil {
+		if err := s.taxonomyRepo.SetPostCategories(ctx, postID, uniqueInts(req.CategoryIDs)); err != nil {
+			return err
+		}
+	}
+
+	if req.CuisineIDs != nil {
+		if err := s.taxonomyRepo.SetPostCuisines(ctx, postID, uniqueInts(req.CuisineIDs)); err != nil {
+			return err
+		}
+	}
+
+	if req.Tags != nil {
+		if err := s.taxonomyRepo.SetPostTags(ctx, postID, tagIDs); err != nil {
+			return err
 		}
-		defer dst.Close()
+	}
+
+	return nil
+}
 
-		if _, err := io.Copy(dst, src); err != nil {
-			return logger.LogError(err, "failed to save new image file")
+func uniqueInts(values []int) []int {
+	seen := make(map[int]bool, len(values))
+	unique := make([]int, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
 		}
+	}
+	return unique
+}
+
+// GetScaledPost rescales a post's ingredients to the requested servings and
+// converts them to the requested unit system. The structured recipe is used
+// when the post has one; otherwise ingredient lines are parsed out of the
+// free-text content. A servings value of 0 keeps the original yield.
+func (s *postService) GetScaledPost(ctx context.Context, id int, servings int, units string) (*models.ScaledRecipe, error) {
+	system := measure.System(strings.ToLower(strings.TrimSpace(units)))
+	if system != measure.Original && system != measure.Metric && system != measure.Imperial {
+		return nil, logger.ValidationError("invalid units, must be 'metric' or 'imperial'")
+	}
+
+	if servings < 0 || servings > 1000 {
+		return nil, logger.ValidationError("servings must be between 1 and 1000, or 0 to keep the original yield")
+	}
+
+	post, err := s.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var ingredients []measure.Ingredient
+	var originalServings int
+	if post.Recipe != nil {
+		ingredients = recipeIngredients(post.Recipe)
+		originalServings = post.Recipe.Servings
+	} else {
+		ingredients = measure.ExtractIngredients(post.Content)
+		originalServings, _ = measure.ParseServings(post.Content)
+	}
+
+	// Without a known yield we cannot scale, only convert.
+	if originalServings == 0 {
+		originalServings = servings
+	}
+	if servings == 0 {
+		servings = originalServings
+	}
+
+	factor := 1.0
+	if originalServings > 0 {
+		factor = float64(servings) / float64(originalServings)
+	}
+
+	scaled := &models.ScaledRecipe{
+		PostID:           post.ID,
+		Title:            post.Title,
+		OriginalServings: originalServings,
+		Servings:         servings,
+		Units:            string(system),
+		Ingredients:      make([]models.ScaledIngredient, 0, len(ingredients)),
+		Content:          measure.ConvertTemperatures(post.Content, system),
+	}
 
-		if existingPost.Image != nil && *existingPost.Image != "" {
-			oldImagePath := filepath.Join(uploadDir, *existingPost.Image)
-			if err := os.Remove(oldImagePath); err != nil {
-				log.Warn().Err(err).Str("file", oldImagePath).Msg("Failed to delete old image")
+	for _, ingredient := range ingredients {
+		scaled.Ingredients = append(scaled.Ingredients, scaleIngredient(ingredient, factor, system))
+	}
+
+	return scaled, nil
+}
+
+// recipeIngredients turns structured recipe rows into measure ingredients.
+// Units the measure package does not know (cloves, pinches) are kept as part
+// of the name so they still scale as counts.
+func recipeIngredients(recipe *models.Recipe) []measure.Ingredient {
+	ingredients := make([]measure.Ingredient, 0, len(recipe.Ingredients))
+	for _, item := range recipe.Ingredients {
+		ingredient := measure.Ingredient{Original: item.Name, Name: item.Name}
+		if item.Quantity != nil {
+			ingredient.Quantity = *item.Quantity
+		}
+
+		if item.Unit != nil {
+			if unit, ok := measure.LookupUnit(*item.Unit); ok {
+				ingredient.Unit = &unit
+			} else {
+				ingredient.Name = *item.Unit + " " + item.Name
 			}
 		}
 
-		finalImageName = &newFileName
+		ingredient.Original = measure.Format(ingredient)
+		if item.Quantity == nil {
+			ingredient.Original = item.Name
+		}
+		ingredients = append(ingredients, ingredient)
 	}
+	return ingredients
+}
 
-	existingPost.Title = title
-	existingPost.Content = content
-	existingPost.Image = finalImageName
+func scaleIngredient(ingredient measure.Ingredient, factor float64, system measure.System) models.ScaledIngredient {
+	result := models.ScaledIngredient{
+		Original: ingredient.Original,
+		Name:     ingredient.Name,
+		Display:  ingredient.Original,
+	}
+
+	// Ingredients without an amount ("salt to taste") pass through unchanged.
+	if ingredient.Quantity == 0 {
+		return result
+	}
+
+	converted := ingredient.Scale(factor)
+	if system == measure.Original {
+		converted = measure.Normalize(converted)
+	} else {
+		converted = measure.Convert(converted, system)
+	}
 
-	return s.postRepo.Update(ctx, existingPost)
+	quantity := measure.RoundQuantity(converted.Quantity, converted.Unit)
+	result.Quantity = &quantity
+	if converted.QuantityMax > 0 {
+		quantityMax := measure.RoundQuantity(converted.QuantityMax, converted.Unit)
+		result.QuantityMax = &quantityMax
+	}
+	if converted.Unit != nil {
+		result.Unit = &converted.Unit.Name
+	}
+	result.Display = measure.Format(converted)
+
+	return result
 }
 
-func (s *postService) GetPost(ctx context.Context, id int) (*models.PostDetail, error) {
-	return s.postRepo.GetPostDetailByID(ctx, id)
+const maxReviewLength = 5000
+
+// RatePost records the user's rating of a post. Each user rates a post once
+// and authors cannot rate their own posts.
+func (s *postService) RatePost(ctx context.Context, postID int, currentUserID int, req models.RatingRequest) (*models.Rating, error) {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, logger.ValidationError("post not found")
+	}
+
+	if post.Status != models.PostPublished {
+		return nil, logger.ValidationError("post not found")
+	}
+
+	if post.UserID == currentUserID {
+		return nil, logger.ValidationError("access denied: you cannot rate your own post")
+	}
+
+	rating, err := buildRating(req)
+	if err != nil {
+		return nil, err
+	}
+	rating.PostID = postID
+	rating.UserID = currentUserID
+
+	if err := s.ratingRepo.Create(ctx, rating); err != nil {
+		return nil, err
+	}
+
+	return s.ratingRepo.GetByPostAndUser(ctx, postID, currentUserID)
+}
+
+func (s *postService) UpdateRating(ctx context.Context, postID int, currentUserID int, req models.RatingRequest) (*models.Rating, error) {
+	existing, err := s.ratingRepo.GetByPostAndUser(ctx, postID, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	rating, err := buildRating(req)
+	if err != nil {
+		return nil, err
+	}
+	rating.ID = existing.ID
+
+	if err := s.ratingRepo.Update(ctx, rating); err != nil {
+		return nil, err
+	}
+
+	return s.ratingRepo.GetByPostAndUser(ctx, postID, currentUserID)
+}
+
+func (s *postService) DeleteRating(ctx context.Context, postID int, currentUserID int) error {
+	existing, err := s.ratingRepo.GetByPostAndUser(ctx, postID, currentUserID)
+	if err != nil {
+		return err
+	}
+
+	return s.ratingRepo.Delete(ctx, existing.ID)
 }
 
-func (s *postService) GetAllPosts(ctx context.Context, page int, limit int) ([]models.PostDetail, error) {
-	
+func (s *postService) GetRatings(ctx context.Context, postID int, sort string, page int, limit int) ([]models.Rating, int, error) {
+	sort = strings.ToLower(strings.TrimSpace(sort))
+	if sort == "" {
+		sort = "newest"
+	}
+	if _, ok := repository.RatingSorts[sort]; !ok {
+		return nil, 0, logger.ValidationError("invalid sort, must be 'newest', 'oldest', 'highest' or 'lowest'")
+	}
+
 	if page < 1 {
 		page = 1
 	}
@@ -199,7 +873,25 @@ func (s *postService) GetAllPosts(ctx context.Context, page int, limit int) ([]m
 		limit = 10
 	}
 
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil || post.Status != models.PostPublished {
+		return nil, 0, logger.ValidationError("post not found")
+	}
+
 	offset := (page - 1) * limit
 
-	return s.postRepo.GetAll(ctx, limit, offset)
+	return s.ratingRepo.GetByPostID(ctx, postID, sort, limit, offset)
+}
+
+func buildRating(req models.RatingRequest) (*models.Rating, error) {
+	if req.Rating < 1 || req.Rating > 5 {
+		return nil, logger.ValidationError("rating must be between 1 and 5")
+	}
+
+	review := optionalString(req.Review)
+	if review != nil && len(*review) > maxReviewLength {
+		return nil, logger.ValidationError("review cannot exceed 5000 characters")
+	}
+
+	return &models.Rating{Rating: req.Rating, Review: review}, nil
 }
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+)
+
+func TestApplyPostStatus(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	earlier := now.Add(-24 * time.Hour)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		post        models.Post
+		status      string
+		publishAt   *time.Time
+		wantErr     string
+		wantStatus  string
+		wantPublish *time.Time
+	}{
+		{"draft clears publish time", models.Post{Status: models.PostPublished, PublishedAt: &earlier}, "draft", nil, "", models.PostDraft, nil},
+		{"publishing a draft stamps now", models.Post{Status: models.PostDraft}, "published", nil, "", models.PostPublished, &now},
+		{"publishing a scheduled post stamps now", models.Post{Status: models.PostScheduled, PublishedAt: &later}, "published", nil, "", models.PostPublished, &now},
+		{"republishing keeps the date", models.Post{Status: models.PostPublished, PublishedAt: &earlier}, "published", nil, "", models.PostPublished, &earlier},
+		{"unarchiving keeps the date", models.Post{Status: models.PostArchived, PublishedAt: &earlier}, "published", nil, "", models.PostPublished, &earlier},
+		{"scheduling in the future", models.Post{Status: models.PostDraft}, "scheduled", &later, "", models.PostScheduled, &later},
+		{"scheduling is case insensitive", models.Post{Status: models.PostDraft}, " Scheduled ", &later, "", models.PostScheduled, &later},
+		{"scheduling for now is already due", models.Post{Status: models.PostDraft}, "scheduled", &now, "published_at must be in the future to schedule a post", models.PostDraft, nil},
+		{"scheduling in the past", models.Post{Status: models.PostDraft}, "scheduled", &earlier, "published_at must be in the future to schedule a post", models.PostDraft, nil},
+		{"scheduling needs a time", models.Post{Status: models.PostDraft}, "scheduled", nil, "published_at is required to schedule a post", models.PostDraft, nil},
+		{"archiving keeps the date", models.Post{Status: models.PostPublished, PublishedAt: &earlier}, "archived", nil, "", models.PostArchived, &earlier},
+		{"drafts cannot be archived", models.Post{Status: models.PostDraft}, "archived", nil, "only published posts can be archived", models.PostDraft, nil},
+		{"unknown status", models.Post{Status: models.PostDraft}, "hidden", nil, "invalid status, must be 'draft', 'published', 'scheduled' or 'archived'", models.PostDraft, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := tt.post
+			err := applyPostStatus(&post, tt.status, tt.publishAt, now)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("applyPostStatus error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("applyPostStatus error = %v", err)
+			}
+
+			if post.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", post.Status, tt.wantStatus)
+			}
+			switch {
+			case tt.wantPublish == nil && post.PublishedAt != nil:
+				t.Errorf("published_at = %v, want none", *post.PublishedAt)
+			case tt.wantPublish != nil && (post.PublishedAt == nil || !post.PublishedAt.Equal(*tt.wantPublish)):
+				t.Errorf("published_at = %v, want %v", post.PublishedAt, *tt.wantPublish)
+			}
+		})
+	}
+}
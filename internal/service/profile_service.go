@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"mime/multipart"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
+	"github.com/rafli2460/culinary-blog-api/internal/upload"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type ProfileService interface {
+	GetProfile(ctx context.Context, username string) (*models.Profile, error)
+	UpdateProfile(ctx context.Context, userID int, req models.ProfileRequest, avatar *multipart.FileHeader) (*models.Profile, error)
+}
+
+type profileService struct {
+	profileRepo repository.ProfileRepository
+	blob        storage.Blob
+	validator   *upload.Validator
+}
+
+func NewProfileService(profileRepo repository.ProfileRepository, blob storage.Blob, validator *upload.Validator) ProfileService {
+	return &profileService{profileRepo: profileRepo, blob: blob, validator: validator}
+}
+
+const (
+	maxDisplayNameLength = 100
+	maxBioLength         = 1000
+	maxLocationLength    = 100
+	maxSocialURLLength   = 255
+)
+
+func (s *profileService) GetProfile(ctx context.Context, username string) (*models.Profile, error) {
+	profile, err := s.profileRepo.GetByUsername(ctx, strings.TrimSpace(username))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.present(ctx, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// UpdateProfile replaces the current user's profile. A new avatar goes through
+// the same validation and resizing as post images, and the old avatar's files
+// are deleted once the new profile is saved.
+func (s *profileService) UpdateProfile(ctx context.Context, userID int, req models.ProfileRequest, avatar *multipart.FileHeader) (*models.Profile, error) {
+	profile, err := s.profileRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	displayName := optionalString(req.DisplayName)
+	bio := optionalString(req.Bio)
+	location := optionalString(req.Location)
+	if utf8.RuneCountInString(stringValue(displayName)) > maxDisplayNameLength {
+		return nil, logger.ValidationError("display name cannot be longer than 100 characters")
+	}
+	if utf8.RuneCountInString(stringValue(bio)) > maxBioLength {
+		return nil, logger.ValidationError("bio cannot be longer than 1000 characters")
+	}
+	if utf8.RuneCountInString(stringValue(location)) > maxLocationLength {
+		return nil, logger.ValidationError("location cannot be longer than 100 characters")
+	}
+
+	links, err := socialLinks(req.SocialLinks)
+	if err != nil {
+		return nil, err
+	}
+
+	oldAvatar := profile.Avatar
+	if req.RemoveAvatar {
+		profile.Avatar = nil
+	}
+	if avatar != nil {
+		key, err := storeImage(ctx, s.blob, s.validator, avatar)
+		if err != nil {
+			return nil, err
+		}
+		profile.Avatar = &key
+	}
+
+	profile.DisplayName = displayName
+	profile.Bio = bio
+	profile.Location = location
+
+	if err := s.profileRepo.Update(ctx, profile); err != nil {
+		if avatar != nil {
+			deleteImageFiles(ctx, s.blob, []string{*profile.Avatar})
+		}
+		return nil, err
+	}
+
+	if oldAvatar != nil && stringValue(oldAvatar) != stringValue(profile.Avatar) {
+		deleteImageFiles(ctx, s.blob, []string{*oldAvatar})
+	}
+
+	if err := s.profileRepo.ReplaceSocialLinks(ctx, userID, links); err != nil {
+		return nil, err
+	}
+
+	if err := s.present(ctx, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// present loads a profile's social links and turns its avatar into URLs.
+func (s *profileService) present(ctx context.Context, profile *models.Profile) error {
+	links, err := s.profileRepo.GetSocialLinks(ctx, profile.ID)
+	if err != nil {
+		return err
+	}
+	profile.SocialLinks = links
+
+	profile.AvatarVariants = imageVariantURLs(s.blob, profile.Avatar)
+	profile.Avatar = imageURL(s.blob, profile.Avatar)
+	return nil
+}
+
+// socialLinks validates the submitted links, keyed by platform, and returns
+// the non-empty ones in the platforms' display order.
+func socialLinks(submitted map[string]string) ([]models.SocialLink, error) {
+	for platform := range submitted {
+		if !slices.Contains(models.SocialPlatforms, platform) {
+			return nil, logger.ValidationError("unknown social platform: " + platform)
+		}
+	}
+
+	links := make([]models.SocialLink, 0, len(submitted))
+	for _, platform := range models.SocialPlatforms {
+		link := strings.TrimSpace(submitted[platform])
+		if link == "" {
+			continue
+		}
+
+		parsed, err := url.Parse(link)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, logger.ValidationError(platform + " must be an http or https URL")
+		}
+		if len(link) > maxSocialURLLength {
+			return nil, logger.ValidationError(platform + " cannot be longer than 255 characters")
+		}
+
+		links = append(links, models.SocialLink{Platform: platform, URL: link})
+	}
+	return links, nil
+}
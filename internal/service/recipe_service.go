@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
+	"github.com/rafli2460/culinary-blog-api/pkg/ingredient"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type RecipeService interface {
+	CreateRecipe(ctx context.Context, postID, currentUserID int, permissions models.Permissions, req models.RecipeRequest) (*models.Recipe, error)
+	UpdateRecipe(ctx context.Context, postID, currentUserID int, permissions models.Permissions, req models.RecipeRequest) (*models.Recipe, error)
+	GetRecipe(ctx context.Context, postID, currentUserID int, permissions models.Permissions) (*models.Recipe, error)
+	MatchRecipes(ctx context.Context, req models.RecipeMatchRequest) ([]models.RecipeMatch, error)
+	BackfillIngredients(ctx context.Context) (int, error)
+}
+
+type recipeService struct {
+	recipeRepo repository.RecipeRepository
+	postRepo   repository.PostRepository
+	blob       storage.Blob
+}
+
+func NewRecipeService(recipeRepo repository.RecipeRepository, postRepo repository.PostRepository, blob storage.Blob) RecipeService {
+	return &recipeService{recipeRepo: recipeRepo, postRepo: postRepo, blob: blob}
+}
+
+var recipeDifficulties = map[string]bool{"easy": true, "medium": true, "hard": true}
+
+func (s *recipeService) CreateRecipe(ctx context.Context, postID int, currentUserID int, permissions models.Permissions, req models.RecipeRequest) (*models.Recipe, error) {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, logger.ValidationError("post not found")
+	}
+
+	if !canManagePost(post, currentUserID, permissions) {
+		return nil, logger.ValidationError("access denied: you do not have permission to add a recipe to this post")
+	}
+
+	if _, err := s.recipeRepo.GetByPostID(ctx, postID); err == nil {
+		return nil, logger.ValidationError("this post already has a recipe, update it instead")
+	}
+
+	recipe, err := buildRecipe(req)
+	if err != nil {
+		return nil, err
+	}
+	recipe.PostID = postID
+
+	if err := s.recipeRepo.Create(ctx, recipe); err != nil {
+		return nil, err
+	}
+
+	return s.recipeRepo.GetByPostID(ctx, postID)
+}
+
+func (s *recipeService) UpdateRecipe(ctx context.Context, postID int, currentUserID int, permissions models.Permissions, req models.RecipeRequest) (*models.Recipe, error) {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, logger.ValidationError("post not found")
+	}
+
+	if !canManagePost(post, currentUserID, permissions) {
+		return nil, logger.ValidationError("access denied: you do not have permission to edit this recipe")
+	}
+
+	existing, err := s.recipeRepo.GetByPostID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipe, err := buildRecipe(req)
+	if err != nil {
+		return nil, err
+	}
+	recipe.ID = existing.ID
+	recipe.PostID = postID
+
+	if err := s.recipeRepo.Update(ctx, recipe); err != nil {
+		return nil, err
+	}
+
+	return s.recipeRepo.GetByPostID(ctx, postID)
+}
+
+// GetRecipe returns the recipe of a published post. Drafts, scheduled and
+// archived posts only show their recipe to the people who can manage them.
+func (s *recipeService) GetRecipe(ctx context.Context, postID int, currentUserID int, permissions models.Permissions) (*models.Recipe, error) {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, logger.ValidationError("post not found")
+	}
+
+	if post.Status != models.PostPublished && !canManagePost(post, currentUserID, permissions) {
+		return nil, logger.ValidationError("post not found")
+	}
+
+	return s.recipeRepo.GetByPostID(ctx, postID)
+}
+
+const maxMatchIngredients = 50
+
+// MatchRecipes finds recipes that can be cooked with the given ingredients,
+// best coverage first. Pantry staples such as salt and water are assumed to be
+// available and never reported as missing.
+func (s *recipeService) MatchRecipes(ctx context.Context, req models.RecipeMatchRequest) ([]models.RecipeMatch, error) {
+	have := normalizeIngredientList(req.Ingredients)
+	if len(have) == 0 {
+		return nil, logger.ValidationError("please enter at least one ingredient")
+	}
+
+	exclude := normalizeIngredientList(req.Exclude)
+	if len(have) > maxMatchIngredients || len(exclude) > maxMatchIngredients {
+		return nil, logger.ValidationError("too many ingredients, the maximum is 50")
+	}
+
+	page, limit := req.Page, req.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	matches, err := s.recipeRepo.MatchByIngredients(ctx, have, exclude, ingredient.Staples(), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range matches {
+		matches[i].Image = imageURL(s.blob, matches[i].Image)
+	}
+
+	return matches, nil
+}
+
+const ingredientBackfillBatch = 500
+
+// BackfillIngredients links recipe ingredients saved without a canonical
+// ingredient and returns how many rows were linked.
+func (s *recipeService) BackfillIngredients(ctx context.Context) (int, error) {
+	linked := 0
+	skipped := make(map[int]bool)
+
+	for {
+		rows, err := s.recipeRepo.GetUnlinkedIngredients(ctx, ingredientBackfillBatch+len(skipped))
+		if err != nil {
+			return linked, err
+		}
+
+		progressed := false
+		for _, row := range rows {
+			if skipped[row.ID] {
+				continue
+			}
+
+			canonical := ingredient.Normalize(row.Name)
+			if canonical == "" {
+				skipped[row.ID] = true
+				continue
+			}
+
+			if err := s.recipeRepo.LinkIngredient(ctx, row.ID, canonical); err != nil {
+				return linked, err
+			}
+			linked++
+			progressed = true
+		}
+
+		if !progressed {
+			return linked, nil
+		}
+	}
+}
+
+// normalizeIngredientList canonicalizes and de-duplicates ingredient names,
+// dropping entries that normalize to nothing.
+func normalizeIngredientList(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		canonical := ingredient.Normalize(name)
+		if canonical == "" || seen[canonical] {
+			continue
+		}
+		seen[canonical] = true
+		normalized = append(normalized, canonical)
+	}
+	return normalized
+}
+
+// buildRecipe validates a recipe request and turns it into a recipe with
+// positions and step numbers assigned from the order they were sent in.
+func buildRecipe(req models.RecipeRequest) (*models.Recipe, error) {
+	if req.Servings < 1 {
+		return nil, logger.ValidationError("servings must be at least 1")
+	}
+
+	if req.PrepTime < 0 || req.CookTime < 0 || req.TotalTime < 0 {
+		return nil, logger.ValidationError("recipe times cannot be negative")
+	}
+
+	totalTime := req.TotalTime
+	if totalTime == 0 {
+		totalTime = req.PrepTime + req.CookTime
+	}
+
+	difficulty := strings.ToLower(strings.TrimSpace(req.Difficulty))
+	if difficulty == "" {
+		difficulty = "easy"
+	}
+	if !recipeDifficulties[difficulty] {
+		return nil, logger.ValidationError("invalid difficulty, must be 'easy', 'medium' or 'hard'")
+	}
+
+	if len(req.Ingredients) == 0 {
+		return nil, logger.ValidationError("recipe must have at least one ingredient")
+	}
+
+	if len(req.Steps) == 0 {
+		return nil, logger.ValidationError("recipe must have at least one step")
+	}
+
+	recipe := &models.Recipe{
+		Servings:    req.Servings,
+		PrepTime:    req.PrepTime,
+		CookTime:    req.CookTime,
+		TotalTime:   totalTime,
+		Difficulty:  difficulty,
+		Ingredients: make([]models.RecipeIngredient, 0, len(req.Ingredients)),
+		Steps:       make([]models.RecipeStep, 0, len(req.Steps)),
+	}
+
+	for i, item := range req.Ingredients {
+		name := strings.TrimSpace(item.Name)
+		if name == "" {
+			return nil, logger.ValidationError("ingredient name is required")
+		}
+
+		if item.Quantity != nil && *item.Quantity < 0 {
+			return nil, logger.ValidationError("ingredient quantity cannot be negative")
+		}
+
+		recipe.Ingredients = append(recipe.Ingredients, models.RecipeIngredient{
+			CanonicalName: ingredient.Normalize(name),
+			Position:      i + 1,
+			Quantity:      item.Quantity,
+			Unit:          optionalString(item.Unit),
+			Name:          name,
+			Note:          optionalString(item.Note),
+		})
+	}
+
+	for i, instruction := range req.Steps {
+		instruction = strings.TrimSpace(instruction)
+		if instruction == "" {
+			return nil, logger.ValidationError("recipe step cannot be empty")
+		}
+
+		recipe.Steps = append(recipe.Steps, models.RecipeStep{
+			StepNumber:  i + 1,
+			Instruction: instruction,
+		})
+	}
+
+	return recipe, nil
+}
+
+func optionalString(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
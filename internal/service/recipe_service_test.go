@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+)
+
+// postsByID serves GetByID from a map; like the real repository, trashed
+// posts are simply not in it.
+type postsByID struct {
+	repository.PostRepository
+	posts map[int]*models.Post
+}
+
+func (r *postsByID) GetByID(ctx context.Context, id int) (*models.Post, error) {
+	post, ok := r.posts[id]
+	if !ok {
+		return nil, errors.New("post not found")
+	}
+	return post, nil
+}
+
+type recipesByPostID struct {
+	repository.RecipeRepository
+}
+
+func (r *recipesByPostID) GetByPostID(ctx context.Context, postID int) (*models.Recipe, error) {
+	return &models.Recipe{PostID: postID}, nil
+}
+
+func TestGetRecipe(t *testing.T) {
+	const author, reader = 1, 2
+	postRepo := &postsByID{posts: map[int]*models.Post{
+		10: {ID: 10, UserID: author, Status: models.PostPublished},
+		11: {ID: 11, UserID: author, Status: models.PostDraft},
+		12: {ID: 12, UserID: author, Status: models.PostScheduled},
+		13: {ID: 13, UserID: author, Status: models.PostArchived},
+	}}
+	svc := NewRecipeService(&recipesByPostID{}, postRepo, nil)
+	editor := models.Permissions{models.PermEditAnyPost}
+
+	tests := []struct {
+		name        string
+		postID      int
+		userID      int
+		permissions models.Permissions
+		ok          bool
+	}{
+		{"published, anyone", 10, reader, nil, true},
+		{"published, anonymous", 10, 0, nil, true},
+		{"draft, reader", 11, reader, nil, false},
+		{"draft, author", 11, author, nil, true},
+		{"draft, editor", 11, reader, editor, true},
+		{"scheduled, reader", 12, reader, nil, false},
+		{"scheduled, author", 12, author, nil, true},
+		{"archived, reader", 13, reader, nil, false},
+		{"trashed or missing", 14, author, editor, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipe, err := svc.GetRecipe(context.Background(), tt.postID, tt.userID, tt.permissions)
+			if tt.ok {
+				if err != nil || recipe.PostID != tt.postID {
+					t.Errorf("GetRecipe = %+v, %v", recipe, err)
+				}
+			} else if err == nil || err.Error() != "post not found" {
+				t.Errorf("GetRecipe error = %v, want post not found", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
+	"github.com/rafli2460/culinary-blog-api/pkg/diff"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type RevisionService interface {
+	GetRevisions(ctx context.Context, postID, currentUserID int, permissions models.Permissions) ([]models.PostRevision, error)
+	GetRevision(ctx context.Context, postID, revision, currentUserID int, permissions models.Permissions) (*models.PostRevision, error)
+	DiffRevisions(ctx context.Context, postID, from, to, currentUserID int, permissions models.Permissions) (*models.RevisionDiff, error)
+	RestoreRevision(ctx context.Context, postID, revision, currentUserID int, permissions models.Permissions) (*models.PostRevision, error)
+}
+
+type revisionService struct {
+	revisionRepo repository.RevisionRepository
+	postRepo     repository.PostRepository
+	blob         storage.Blob
+}
+
+func NewRevisionService(revisionRepo repository.RevisionRepository, postRepo repository.PostRepository, blob storage.Blob) RevisionService {
+	return &revisionService{revisionRepo: revisionRepo, postRepo: postRepo, blob: blob}
+}
+
+func (s *revisionService) GetRevisions(ctx context.Context, postID int, currentUserID int, permissions models.Permissions) ([]models.PostRevision, error) {
+	if _, err := s.managedPost(ctx, postID, currentUserID, permissions); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.revisionRepo.GetByPostID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range revisions {
+		revisions[i].Image = imageURL(s.blob, revisions[i].Image)
+	}
+
+	return revisions, nil
+}
+
+func (s *revisionService) GetRevision(ctx context.Context, postID int, revision int, currentUserID int, permissions models.Permissions) (*models.PostRevision, error) {
+	if _, err := s.managedPost(ctx, postID, currentUserID, permissions); err != nil {
+		return nil, err
+	}
+
+	return s.revision(ctx, postID, revision)
+}
+
+// DiffRevisions compares two revisions of a post line by line, from the older
+// state to the newer one as given.
+func (s *revisionService) DiffRevisions(ctx context.Context, postID int, from int, to int, currentUserID int, permissions models.Permissions) (*models.RevisionDiff, error) {
+	if from < 1 || to < 1 {
+		return nil, logger.ValidationError("from and to must be revision numbers")
+	}
+
+	if _, err := s.managedPost(ctx, postID, currentUserID, permissions); err != nil {
+		return nil, err
+	}
+
+	oldRevision, err := s.revisionRepo.GetByRevision(ctx, postID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	newRevision, err := s.revisionRepo.GetByRevision(ctx, postID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RevisionDiff{
+		PostID:       postID,
+		From:         from,
+		To:           to,
+		Title:        diff.Lines(oldRevision.Title, newRevision.Title),
+		Content:      diff.Lines(oldRevision.Content, newRevision.Content),
+		ImageChanged: stringValue(oldRevision.Image) != stringValue(newRevision.Image),
+	}, nil
+}
+
+// RestoreRevision puts a post's title, content and image back to an earlier
+// revision. The restore is itself recorded as a new revision, so it can be
+// undone like any other edit.
+func (s *revisionService) RestoreRevision(ctx context.Context, postID int, revision int, currentUserID int, permissions models.Permissions) (*models.PostRevision, error) {
+	post, err := s.managedPost(ctx, postID, currentUserID, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.revisionRepo.GetByRevision(ctx, postID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	post.Title = snapshot.Title
+	post.Content = snapshot.Content
+	post.Image = snapshot.Image
+
+	if err := s.postRepo.Update(ctx, post); err != nil {
+		return nil, err
+	}
+
+	if err := syncSlug(ctx, s.postRepo, post); err != nil {
+		return nil, err
+	}
+
+	restored, err := recordRevision(ctx, s.revisionRepo, post, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.revision(ctx, postID, restored.Revision)
+}
+
+// revision loads one revision with its image as a URL.
+func (s *revisionService) revision(ctx context.Context, postID int, revision int) (*models.PostRevision, error) {
+	snapshot, err := s.revisionRepo.GetByRevision(ctx, postID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Image = imageURL(s.blob, snapshot.Image)
+	return snapshot, nil
+}
+
+// managedPost loads a post the user is allowed to manage, with the same rule
+// as editing it.
+func (s *revisionService) managedPost(ctx context.Context, postID int, currentUserID int, permissions models.Permissions) (*models.Post, error) {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, logger.ValidationError("post not found")
+	}
+
+	if !canManagePost(post, currentUserID, permissions) {
+		return nil, logger.ValidationError("access denied: you do not have permission to view this post's revisions")
+	}
+
+	return post, nil
+}
+
+// recordRevision saves the post's current title, content and image as its
+// next revision.
+func recordRevision(ctx context.Context, repo repository.RevisionRepository, post *models.Post, editorID int) (*models.PostRevision, error) {
+	revision := &models.PostRevision{
+		PostID:  post.ID,
+		Title:   post.Title,
+		Content: post.Content,
+		Image:   post.Image,
+	}
+	if editorID != 0 {
+		revision.EditorID = &editorID
+	}
+
+	if err := repo.Create(ctx, revision); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+)
+
+type RoleService interface {
+	GetRoles(ctx context.Context) ([]models.Role, error)
+	Permissions(ctx context.Context, role string) (models.Permissions, error)
+	Exists(ctx context.Context, role string) (bool, error)
+	RequiresTwoFactor(ctx context.Context, role string) (bool, error)
+	SetTwoFactorRequired(ctx context.Context, role string, required bool) error
+}
+
+type roleService struct {
+	roleRepo repository.RoleRepository
+	ttl      time.Duration
+
+	mu       sync.Mutex
+	roles    map[string]models.Role
+	loadedAt time.Time
+}
+
+// NewRoleService creates a role service that keeps the roles and their
+// permissions in memory for ttl, since every authenticated request needs
+// them. Changes made in the database are picked up once the cache expires.
+func NewRoleService(roleRepo repository.RoleRepository, ttl time.Duration) RoleService {
+	return &roleService{roleRepo: roleRepo, ttl: ttl}
+}
+
+func (s *roleService) GetRoles(ctx context.Context) ([]models.Role, error) {
+	return s.roleRepo.GetAll(ctx)
+}
+
+// Permissions returns what a role may do. Unknown roles have no permissions.
+func (s *roleService) Permissions(ctx context.Context, role string) (models.Permissions, error) {
+	roles, err := s.cached(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return roles[role].Permissions, nil
+}
+
+func (s *roleService) Exists(ctx context.Context, role string) (bool, error) {
+	roles, err := s.cached(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, ok := roles[role]
+	return ok, nil
+}
+
+// RequiresTwoFactor reports whether users with a role must use two-factor
+// authentication.
+func (s *roleService) RequiresTwoFactor(ctx context.Context, role string) (bool, error) {
+	roles, err := s.cached(ctx)
+	if err != nil {
+		return false, err
+	}
+	return roles[role].RequireTwoFactor, nil
+}
+
+// SetTwoFactorRequired makes two-factor authentication required or optional
+// for a role. Users of the role who have not set it up are asked to when they
+// next log in, and their sessions end at the next token refresh.
+func (s *roleService) SetTwoFactorRequired(ctx context.Context, role string, required bool) error {
+	role = strings.ToLower(strings.TrimSpace(role))
+	if err := s.roleRepo.SetTwoFactorRequired(ctx, role, required); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.roles = nil
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *roleService) cached(ctx context.Context) (map[string]models.Role, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.roles != nil && time.Since(s.loadedAt) < s.ttl {
+		return s.roles, nil
+	}
+
+	all, err := s.roleRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make(map[string]models.Role, len(all))
+	for _, role := range all {
+		roles[role.Name] = role
+	}
+	s.roles = roles
+	s.loadedAt = time.Now()
+	return roles, nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+)
+
+type SearchService interface {
+	SearchPosts(ctx context.Context, query string, page int, limit int) ([]models.SearchResult, int, error)
+}
+
+type searchService struct {
+	searchRepo repository.SearchRepository
+	blob       storage.Blob
+}
+
+func NewSearchService(repo repository.SearchRepository, blob storage.Blob) SearchService {
+	return &searchService{searchRepo: repo, blob: blob}
+}
+
+const (
+	maxSearchQueryLength = 200
+	snippetRadius        = 80
+)
+
+func (s *searchService) SearchPosts(ctx context.Context, query string, page int, limit int) ([]models.SearchResult, int, error) {
+	query = strings.Join(strings.Fields(query), " ")
+	if query == "" {
+		return nil, 0, logger.ValidationError("search query is required")
+	}
+	if len(query) > maxSearchQueryLength {
+		return nil, 0, logger.ValidationError("search query is too long")
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	results, total, err := s.searchRepo.SearchPosts(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pattern := searchTermPattern(query)
+	for i := range results {
+		results[i].Image = imageURL(s.blob, results[i].Image)
+		results[i].TitleHighlight = highlightTerms(results[i].Title, pattern)
+		results[i].Snippet = buildSnippet(results[i].Content, pattern, snippetRadius)
+	}
+
+	return results, total, nil
+}
+
+// searchTermPattern builds a case-insensitive pattern matching any word of
+// the query. It returns nil when the query has no usable words.
+func searchTermPattern(query string) *regexp.Regexp {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool)
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.ToLower(word)
+		if len(word) < 2 || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, regexp.QuoteMeta(word))
+	}
+
+	if len(terms) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)` + strings.Join(terms, "|"))
+}
+
+// highlightTerms HTML-escapes text and wraps every match of pattern in <mark>.
+func highlightTerms(text string, pattern *regexp.Regexp) string {
+	if pattern == nil {
+		return html.EscapeString(text)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[loc[0]:loc[1]]))
+		b.WriteString("</mark>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+
+	return b.String()
+}
+
+// buildSnippet cuts a window of roughly radius bytes either side of the first
+// match in content, trimmed to whole words, and highlights it. Content with
+// no match in its text (e.g. a post found through its ingredients) gets its
+// opening instead.
+func buildSnippet(content string, pattern *regexp.Regexp, radius int) string {
+	content = strings.Join(strings.Fields(content), " ")
+
+	matchStart, matchEnd := 0, 0
+	if pattern != nil {
+		if loc := pattern.FindStringIndex(content); loc != nil {
+			matchStart, matchEnd = loc[0], loc[1]
+		}
+	}
+
+	start := max(matchStart-radius, 0)
+	end := min(matchEnd+radius, len(content))
+	if matchEnd == 0 {
+		end = min(2*radius, len(content))
+	}
+
+	if start > 0 {
+		if idx := strings.IndexByte(content[start:matchStart], ' '); idx >= 0 {
+			start += idx + 1
+		}
+		for start < len(content) && !utf8.RuneStart(content[start]) {
+			start++
+		}
+	}
+
+	if end < len(content) {
+		if idx := strings.LastIndexByte(content[matchEnd:end], ' '); idx >= 0 {
+			end = matchEnd + idx
+		}
+		for end > start && !utf8.RuneStart(content[end]) {
+			end--
+		}
+	}
+
+	snippet := highlightTerms(content[start:end], pattern)
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(content) {
+		snippet += "…"
+	}
+	return snippet
+}
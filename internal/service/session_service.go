@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+	"github.com/rs/zerolog/log"
+)
+
+type SessionService interface {
+	Create(ctx context.Context, user models.User, client models.ClientInfo) (*models.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	GetSessions(ctx context.Context, userID int, currentSessionID int) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID int, sessionID int) error
+	RevokeOtherSessions(ctx context.Context, userID int, keepSessionID int) error
+	PurgeExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type sessionService struct {
+	sessionRepo repository.SessionRepository
+	userRepo    repository.UserRepository
+	roleService RoleService
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+}
+
+// NewSessionService creates a session service that hands out access tokens
+// valid for accessTTL and refresh tokens that keep a session alive as long as
+// one is used within refreshTTL.
+func NewSessionService(sessionRepo repository.SessionRepository, userRepo repository.UserRepository, roleService RoleService, accessTTL time.Duration, refreshTTL time.Duration) SessionService {
+	return &sessionService{sessionRepo: sessionRepo, userRepo: userRepo, roleService: roleService, accessTTL: accessTTL, refreshTTL: refreshTTL}
+}
+
+// maxUserAgentLength matches the sessions.user_agent column.
+const maxUserAgentLength = 255
+
+// Create starts a new session for a user who just proved who they are.
+func (s *sessionService) Create(ctx context.Context, user models.User, client models.ClientInfo) (*models.TokenPair, error) {
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	session := &models.Session{
+		UserID:    user.ID,
+		UserAgent: optionalString(userAgent),
+		IPAddress: optionalString(client.IPAddress),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user, session.ID)
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token. Each
+// refresh token works once: presenting a used one means it was copied, so the
+// whole session is revoked and both the thief and the user have to log in
+// again. Sessions of users whose role has come to require two-factor
+// authentication end until they log in again and set it up.
+func (s *sessionService) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	if refreshToken == "" {
+		return nil, logger.ValidationError("refresh token is required")
+	}
+
+	stored, err := s.sessionRepo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		if err.Error() == "refresh token not found" {
+			return nil, logger.ValidationError("invalid refresh token")
+		}
+		return nil, err
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, stored.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.UsedAt != nil {
+		return nil, s.revokeReused(ctx, session)
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil || now.After(stored.ExpiresAt) || now.After(session.ExpiresAt) {
+		return nil, logger.ValidationError("session has ended, please log in again")
+	}
+
+	if err := s.sessionRepo.MarkRefreshTokenUsed(ctx, stored.ID); err != nil {
+		if err.Error() == "refresh token already used" {
+			return nil, s.revokeReused(ctx, session)
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			s.sessionRepo.Revoke(ctx, session.ID)
+			return nil, logger.ValidationError("session has ended, please log in again")
+		}
+		return nil, err
+	}
+
+	if !user.TwoFactorEnabled {
+		required, err := s.roleService.RequiresTwoFactor(ctx, user.Role)
+		if err != nil {
+			return nil, err
+		}
+		if required {
+			s.sessionRepo.Revoke(ctx, session.ID)
+			return nil, logger.ValidationError("your role requires two-factor authentication, please log in again to set it up")
+		}
+	}
+
+	return s.issueTokens(ctx, user, session.ID)
+}
+
+// revokeReused ends a session whose refresh token was presented twice.
+func (s *sessionService) revokeReused(ctx context.Context, session *models.Session) error {
+	log.Warn().Int("session_id", session.ID).Int("user_id", session.UserID).Msg("Refresh token reuse detected, revoking session")
+
+	if err := s.sessionRepo.Revoke(ctx, session.ID); err != nil {
+		return err
+	}
+	return logger.ValidationError("refresh token was already used, please log in again")
+}
+
+// Logout revokes the session a refresh token belongs to. Unknown tokens are
+// ignored, since there is nothing left to log out of.
+func (s *sessionService) Logout(ctx context.Context, refreshToken string) error {
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := s.sessionRepo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		if err.Error() == "refresh token not found" {
+			return nil
+		}
+		return err
+	}
+	return s.sessionRepo.Revoke(ctx, stored.SessionID)
+}
+
+// GetSessions lists a user's active sessions and marks the one the request
+// came from.
+func (s *sessionService) GetSessions(ctx context.Context, userID int, currentSessionID int) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.GetActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, userID int, sessionID int) error {
+	return s.sessionRepo.RevokeForUser(ctx, userID, sessionID)
+}
+
+// RevokeOtherSessions logs a user out everywhere except keepSessionID; pass 0
+// to end every session.
+func (s *sessionService) RevokeOtherSessions(ctx context.Context, userID int, keepSessionID int) error {
+	return s.sessionRepo.RevokeAllForUser(ctx, userID, keepSessionID)
+}
+
+// PurgeExpired deletes sessions that expired or were revoked and returns how
+// many were deleted. It is meant to run as a scheduler job.
+func (s *sessionService) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	return s.sessionRepo.DeleteExpired(ctx, now)
+}
+
+// issueTokens signs a new access token for the session and stores a new
+// refresh token for it, extending the session.
+func (s *sessionService) issueTokens(ctx context.Context, user models.User, sessionID int) (*models.TokenPair, error) {
+	now := time.Now()
+	pair := &models.TokenPair{
+		AccessExpiresAt:  now.Add(s.accessTTL),
+		RefreshExpiresAt: now.Add(s.refreshTTL),
+	}
+
+	claims := jwt.MapClaims{
+		"user_id": user.ID,
+		"role":    user.Role,
+		"sid":     sessionID,
+		"iat":     now.Unix(),
+		"exp":     pair.AccessExpiresAt.Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	secretKey := os.Getenv("JWT_SECRET")
+	accessToken, err := token.SignedString([]byte(secretKey))
+	if err != nil {
+		return nil, logger.LogError(err, "error creating authentication token")
+	}
+	pair.AccessToken = accessToken
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, logger.LogError(err, "error generating refresh token")
+	}
+	pair.RefreshToken = base64.RawURLEncoding.EncodeToString(raw)
+
+	refresh := &models.RefreshToken{
+		SessionID: sessionID,
+		TokenHash: hashToken(pair.RefreshToken),
+		ExpiresAt: pair.RefreshExpiresAt,
+	}
+	if err := s.sessionRepo.CreateRefreshToken(ctx, refresh); err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.Extend(ctx, sessionID, pair.RefreshExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// hashToken returns the SHA-256 of a token as hex, which is how tokens are
+// stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
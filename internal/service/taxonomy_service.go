@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+	"github.com/rafli2460/culinary-blog-api/pkg/slug"
+)
+
+type TaxonomyService interface {
+	GetCategoryTree(ctx context.Context) ([]models.Category, error)
+	CreateCategory(ctx context.Context, req models.CategoryRequest) (*models.Category, error)
+	UpdateCategory(ctx context.Context, id int, req models.CategoryRequest) (*models.Category, error)
+	DeleteCategory(ctx context.Context, id int) error
+
+	GetCuisines(ctx context.Context) ([]models.Term, error)
+	CreateCuisine(ctx context.Context, req models.TermRequest) (*models.Term, error)
+	UpdateCuisine(ctx context.Context, id int, req models.TermRequest) (*models.Term, error)
+	DeleteCuisine(ctx context.Context, id int) error
+
+	GetTags(ctx context.Context) ([]models.Term, error)
+	AutocompleteTags(ctx context.Context, prefix string) ([]models.Term, error)
+	CreateTag(ctx context.Context, req models.TermRequest) (*models.Term, error)
+	UpdateTag(ctx context.Context, id int, req models.TermRequest) (*models.Term, error)
+	DeleteTag(ctx context.Context, id int) error
+}
+
+type taxonomyService struct {
+	taxonomyRepo repository.TaxonomyRepository
+}
+
+func NewTaxonomyService(repo repository.TaxonomyRepository) TaxonomyService {
+	return &taxonomyService{taxonomyRepo: repo}
+}
+
+const tagAutocompleteLimit = 10
+
+// GetCategoryTree returns top-level categories with their subcategories
+// nested under Children.
+func (s *taxonomyService) GetCategoryTree(ctx context.Context) ([]models.Category, error) {
+	categories, err := s.taxonomyRepo.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]models.Category)
+	roots := make([]models.Category, 0)
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	var attach func(nodes []models.Category) []models.Category
+	attach = func(nodes []models.Category) []models.Category {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+
+	return attach(roots), nil
+}
+
+func (s *taxonomyService) CreateCategory(ctx context.Context, req models.CategoryRequest) (*models.Category, error) {
+	name, categorySlug, err := normalizeTermName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		if _, err := s.taxonomyRepo.GetCategoryByID(ctx, *req.ParentID); err != nil {
+			return nil, logger.ValidationError("parent category not found")
+		}
+	}
+
+	category := &models.Category{
+		ParentID: req.ParentID,
+		Name:     name,
+		Slug:     categorySlug,
+	}
+
+	if err := s.taxonomyRepo.CreateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (s *taxonomyService) UpdateCategory(ctx context.Context, id int, req models.CategoryRequest) (*models.Category, error) {
+	category, err := s.taxonomyRepo.GetCategoryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name, categorySlug, err := normalizeTermName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		if err := s.checkCategoryParent(ctx, id, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	category.ParentID = req.ParentID
+	category.Name = name
+	category.Slug = categorySlug
+
+	if err := s.taxonomyRepo.UpdateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// checkCategoryParent walks up from the proposed parent to make sure the
+// category would not become its own ancestor.
+func (s *taxonomyService) checkCategoryParent(ctx context.Context, id int, parentID int) error {
+	seen := make(map[int]bool)
+	current := &parentID
+	for current != nil {
+		if *current == id {
+			return logger.ValidationError("a category cannot be nested under itself or its subcategories")
+		}
+		if seen[*current] {
+			break
+		}
+		seen[*current] = true
+
+		parent, err := s.taxonomyRepo.GetCategoryByID(ctx, *current)
+		if err != nil {
+			return logger.ValidationError("parent category not found")
+		}
+		current = parent.ParentID
+	}
+	return nil
+}
+
+func (s *taxonomyService) DeleteCategory(ctx context.Context, id int) error {
+	return s.taxonomyRepo.DeleteCategory(ctx, id)
+}
+
+func (s *taxonomyService) GetCuisines(ctx context.Context) ([]models.Term, error) {
+	return s.taxonomyRepo.GetCuisines(ctx)
+}
+
+func (s *taxonomyService) CreateCuisine(ctx context.Context, req models.TermRequest) (*models.Term, error) {
+	cuisine, err := buildTerm(0, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.taxonomyRepo.CreateCuisine(ctx, cuisine); err != nil {
+		return nil, err
+	}
+	return cuisine, nil
+}
+
+func (s *taxonomyService) UpdateCuisine(ctx context.Context, id int, req models.TermRequest) (*models.Term, error) {
+	cuisine, err := buildTerm(id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.taxonomyRepo.UpdateCuisine(ctx, cuisine); err != nil {
+		return nil, err
+	}
+	return cuisine, nil
+}
+
+func (s *taxonomyService) DeleteCuisine(ctx context.Context, id int) error {
+	return s.taxonomyRepo.DeleteCuisine(ctx, id)
+}
+
+func (s *taxonomyService) GetTags(ctx context.Context) ([]models.Term, error) {
+	return s.taxonomyRepo.GetTags(ctx)
+}
+
+func (s *taxonomyService) AutocompleteTags(ctx context.Context, prefix string) ([]models.Term, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return make([]models.Term, 0), nil
+	}
+
+	// Escape LIKE wildcards so they are matched literally.
+	prefix = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix)
+
+	return s.taxonomyRepo.SearchTags(ctx, prefix, tagAutocompleteLimit)
+}
+
+func (s *taxonomyService) CreateTag(ctx context.Context, req models.TermRequest) (*models.Term, error) {
+	tag, err := buildTerm(0, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.taxonomyRepo.CreateTag(ctx, tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *taxonomyService) UpdateTag(ctx context.Context, id int, req models.TermRequest) (*models.Term, error) {
+	tag, err := buildTerm(id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.taxonomyRepo.UpdateTag(ctx, tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *taxonomyService) DeleteTag(ctx context.Context, id int) error {
+	return s.taxonomyRepo.DeleteTag(ctx, id)
+}
+
+func buildTerm(id int, req models.TermRequest) (*models.Term, error) {
+	name, termSlug, err := normalizeTermName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Term{ID: id, Name: name, Slug: termSlug}, nil
+}
+
+// normalizeTermName trims a taxonomy name and derives its slug.
+func normalizeTermName(name string) (string, string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", "", logger.ValidationError("name is required")
+	}
+	if len(name) > 100 {
+		return "", "", logger.ValidationError("name cannot be longer than 100 characters")
+	}
+
+	termSlug := slug.Make(name)
+	if termSlug == "" {
+		return "", "", logger.ValidationError("name must contain at least one letter or number")
+	}
+
+	return name, termSlug, nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
+)
+
+type TrashService interface {
+	GetTrashedPosts(ctx context.Context, page int, limit int) ([]models.TrashedPost, int, error)
+	RestorePost(ctx context.Context, postID int) error
+	PurgePost(ctx context.Context, postID int) error
+
+	GetTrashedUsers(ctx context.Context) ([]models.User, error)
+	RestoreUser(ctx context.Context, userID int) error
+	PurgeUser(ctx context.Context, userID int) error
+
+	PurgeExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type trashService struct {
+	postRepo  repository.PostRepository
+	userRepo  repository.UserRepository
+	blob      storage.Blob
+	retention time.Duration
+}
+
+// NewTrashService creates a trash service that permanently deletes posts and
+// users once they have been in the trash longer than retention.
+func NewTrashService(postRepo repository.PostRepository, userRepo repository.UserRepository, blob storage.Blob, retention time.Duration) TrashService {
+	return &trashService{postRepo: postRepo, userRepo: userRepo, blob: blob, retention: retention}
+}
+
+func (s *trashService) GetTrashedPosts(ctx context.Context, page int, limit int) ([]models.TrashedPost, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	return s.postRepo.GetTrashed(ctx, limit, offset)
+}
+
+func (s *trashService) RestorePost(ctx context.Context, postID int) error {
+	return s.postRepo.Restore(ctx, postID)
+}
+
+// PurgePost permanently deletes a trashed post and the image files of all of
+// its revisions.
+func (s *trashService) PurgePost(ctx context.Context, postID int) error {
+	images, err := s.postRepo.GetImages(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.postRepo.Purge(ctx, postID); err != nil {
+		return err
+	}
+
+	deleteImageFiles(ctx, s.blob, images)
+	return nil
+}
+
+func (s *trashService) GetTrashedUsers(ctx context.Context) ([]models.User, error) {
+	return s.userRepo.GetTrashed(ctx)
+}
+
+func (s *trashService) RestoreUser(ctx context.Context, userID int) error {
+	return s.userRepo.Restore(ctx, userID)
+}
+
+// PurgeUser permanently deletes a trashed user. Their posts are deleted with
+// them, so the images of those posts are removed as well.
+func (s *trashService) PurgeUser(ctx context.Context, userID int) error {
+	images, err := s.postRepo.GetImagesByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Purge(ctx, userID); err != nil {
+		return err
+	}
+
+	deleteImageFiles(ctx, s.blob, images)
+	return nil
+}
+
+// PurgeExpired permanently deletes every post and user that was trashed more
+// than the retention period before now and returns how many were purged. It
+// is meant to run as a scheduler job.
+func (s *trashService) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	cutoff := now.Add(-s.retention)
+	purged := 0
+
+	userIDs, err := s.userRepo.GetTrashedBefore(ctx, cutoff)
+	if err != nil {
+		return purged, err
+	}
+	for _, id := range userIDs {
+		if err := s.PurgeUser(ctx, id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	postIDs, err := s.postRepo.GetTrashedBefore(ctx, cutoff)
+	if err != nil {
+		return purged, err
+	}
+	for _, id := range postIDs {
+		if err := s.PurgePost(ctx, id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+	"github.com/rafli2460/culinary-blog-api/pkg/totp"
+)
+
+type TwoFactorService interface {
+	GetStatus(ctx context.Context, userID int) (*models.TwoFactorStatus, error)
+	Setup(ctx context.Context, userID int) (*models.TwoFactorSetup, error)
+	Enable(ctx context.Context, userID int, code string) ([]string, error)
+	Disable(ctx context.Context, userID int, req models.ConfirmTwoFactorRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int, req models.ConfirmTwoFactorRequest) ([]string, error)
+	Verify(ctx context.Context, userID int, code string) error
+
+	Challenge(ctx context.Context, user models.User) (*models.LoginChallenge, error)
+	ParseChallenge(token string) (userID int, setup bool, err error)
+}
+
+type twoFactorService struct {
+	twoFactorRepo repository.TwoFactorRepository
+	userRepo      repository.UserRepository
+	roleService   RoleService
+	issuer        string
+}
+
+// NewTwoFactorService creates the two-factor service. issuer names the site
+// in authenticator apps.
+func NewTwoFactorService(twoFactorRepo repository.TwoFactorRepository, userRepo repository.UserRepository, roleService RoleService, issuer string) TwoFactorService {
+	return &twoFactorService{twoFactorRepo: twoFactorRepo, userRepo: userRepo, roleService: roleService, issuer: issuer}
+}
+
+const (
+	// challengeTTL is how long a user has to enter their code after giving
+	// the right password.
+	challengeTTL = 5 * time.Minute
+	// totpSkew accepts the code of the step before and after the current
+	// one, for authenticators whose clock is a little off.
+	totpSkew = 1
+	// recoveryCodeCount is how many recovery codes a user gets at a time.
+	recoveryCodeCount = 10
+
+	challengePurpose      = "2fa"
+	setupChallengePurpose = "2fa_setup"
+)
+
+// invalidTwoFactorCode is the error for a wrong, expired or reused code.
+const invalidTwoFactorCode = "invalid two-factor code"
+
+func (s *twoFactorService) GetStatus(ctx context.Context, userID int) (*models.TwoFactorStatus, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := s.roleService.RequiresTwoFactor(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.TwoFactorStatus{Enabled: user.TwoFactorEnabled, Required: required}
+	if user.TwoFactorEnabled {
+		status.RecoveryCodesLeft, err = s.twoFactorRepo.CountRecoveryCodes(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// Setup starts enrolling a user with a new secret. Two-factor authentication
+// is only enabled once Enable receives a code generated from it, so a secret
+// that never made it into an authenticator cannot lock the user out.
+func (s *twoFactorService) Setup(ctx context.Context, userID int) (*models.TwoFactorSetup, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, logger.ValidationError("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, logger.LogError(err, "error generating two-factor secret")
+	}
+
+	if err := s.twoFactorRepo.SetSecret(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorSetup{Secret: secret, URI: totp.URI(s.issuer, user.Username, secret)}, nil
+}
+
+// Enable confirms an enrollment with the first code from the authenticator
+// and returns the user's recovery codes, which are only shown this once.
+func (s *twoFactorService) Enable(ctx context.Context, userID int, code string) ([]string, error) {
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.EnabledAt != nil {
+		return nil, logger.ValidationError("two-factor authentication is already enabled")
+	}
+	if twoFactor.Secret == nil {
+		return nil, logger.ValidationError("two-factor authentication has not been set up")
+	}
+
+	step, ok := totp.Validate(*twoFactor.Secret, normalizeCode(code), time.Now(), totpSkew)
+	if !ok {
+		return nil, logger.ValidationError(invalidTwoFactorCode)
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorRepo.Enable(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off once the user has confirmed it
+// with their password and a code. Users whose role requires it cannot.
+func (s *twoFactorService) Disable(ctx context.Context, userID int, req models.ConfirmTwoFactorRequest) error {
+	user, err := s.confirm(ctx, userID, req)
+	if err != nil {
+		return err
+	}
+
+	required, err := s.roleService.RequiresTwoFactor(ctx, user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return logger.ValidationError("action denied: your role requires two-factor authentication")
+	}
+
+	return s.twoFactorRepo.Disable(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces a user's recovery codes, for when they ran
+// out or fear they leaked.
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID int, req models.ConfirmTwoFactorRequest) ([]string, error) {
+	if _, err := s.confirm(ctx, userID, req); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// confirm checks the password and code that guard changes to a user's
+// two-factor settings.
+func (s *twoFactorService) confirm(ctx context.Context, userID int, req models.ConfirmTwoFactorRequest) (models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return user, err
+	}
+
+	if err := checkPassword(user, req.Password); err != nil {
+		return user, err
+	}
+
+	return user, s.Verify(ctx, userID, req.Code)
+}
+
+// Verify checks a code from the user's authenticator or one of their
+// recovery codes. Either works only once.
+func (s *twoFactorService) Verify(ctx context.Context, userID int, code string) error {
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if twoFactor.EnabledAt == nil || twoFactor.Secret == nil {
+		return logger.ValidationError("two-factor authentication is not enabled")
+	}
+
+	code = normalizeCode(code)
+	if code == "" {
+		return logger.ValidationError("please enter a two-factor code")
+	}
+
+	if isTOTPCode(code) {
+		step, ok := totp.Validate(*twoFactor.Secret, code, time.Now(), totpSkew)
+		if !ok {
+			return logger.ValidationError(invalidTwoFactorCode)
+		}
+		if err := s.twoFactorRepo.UseStep(ctx, userID, step); err != nil {
+			if err.Error() == "code was already used" {
+				return logger.ValidationError(invalidTwoFactorCode)
+			}
+			return err
+		}
+		return nil
+	}
+
+	if err := s.twoFactorRepo.UseRecoveryCode(ctx, userID, hashToken(code)); err != nil {
+		if err.Error() == "recovery code not found" {
+			return logger.ValidationError(invalidTwoFactorCode)
+		}
+		return err
+	}
+	return nil
+}
+
+// Challenge returns the challenge for the second step of a user's login, or
+// nil when their password is enough.
+func (s *twoFactorService) Challenge(ctx context.Context, user models.User) (*models.LoginChallenge, error) {
+	purpose := challengePurpose
+	if !user.TwoFactorEnabled {
+		required, err := s.roleService.RequiresTwoFactor(ctx, user.Role)
+		if err != nil {
+			return nil, err
+		}
+		if !required {
+			return nil, nil
+		}
+		purpose = setupChallengePurpose
+	}
+
+	now := time.Now()
+	challenge := &models.LoginChallenge{
+		TwoFactorRequired: true,
+		SetupRequired:     purpose == setupChallengePurpose,
+		ExpiresAt:         now.Add(challengeTTL),
+	}
+
+	// The purpose claim keeps a challenge token from being accepted as an
+	// access token; see middleware.Protected.
+	claims := jwt.MapClaims{
+		"user_id": user.ID,
+		"purpose": purpose,
+		"iat":     now.Unix(),
+		"exp":     challenge.ExpiresAt.Unix(),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		return nil, logger.LogError(err, "error creating login challenge")
+	}
+	challenge.ChallengeToken = token
+
+	return challenge, nil
+}
+
+// ParseChallenge returns the user a challenge token was issued to and whether
+// they still have to set up two-factor authentication.
+func (s *twoFactorService) ParseChallenge(tokenString string) (int, bool, error) {
+	invalid := logger.ValidationError("login challenge is invalid or has expired, please log in again")
+
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return 0, false, invalid
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, false, invalid
+	}
+
+	purpose, _ := claims["purpose"].(string)
+	userID, ok := claims["user_id"].(float64)
+	if !ok || (purpose != challengePurpose && purpose != setupChallengePurpose) {
+		return 0, false, invalid
+	}
+
+	return int(userID), purpose == setupChallengePurpose, nil
+}
+
+// normalizeCode removes the spaces and hyphens users type or paste along with
+// a code.
+func normalizeCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCodes returns new recovery codes, formatted like
+// "k3x9m-q2w7p" for the user, with the hashes to store for them.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, logger.LogError(err, "error generating recovery codes")
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw)[:10])
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashToken(code))
+	}
+	return codes, hashes, nil
+}
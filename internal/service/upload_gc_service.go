@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rafli2460/culinary-blog-api/internal/models"
+	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrListingUnsupported is returned when the storage backend cannot list its
+// files, so stored uploads cannot be reconciled.
+var ErrListingUnsupported = errors.New("storage backend cannot list files")
+
+type UploadGCService interface {
+	Reconcile(ctx context.Context, now time.Time, remove bool) (*models.UploadReport, error)
+	CollectOrphans(ctx context.Context, now time.Time) (int, error)
+}
+
+type uploadGCService struct {
+	postRepo repository.PostRepository
+	blob     storage.Blob
+	grace    time.Duration
+	remove   bool
+}
+
+// NewUploadGCService creates a service that finds stored files no row refers
+// to. Files younger than grace are left alone, since the post they belong to
+// may not be saved yet. remove decides whether the scheduled CollectOrphans
+// deletes orphans or only reports them.
+func NewUploadGCService(postRepo repository.PostRepository, blob storage.Blob, grace time.Duration, remove bool) UploadGCService {
+	return &uploadGCService{postRepo: postRepo, blob: blob, grace: grace, remove: remove}
+}
+
+// Reconcile compares the stored files with the images referenced by posts,
+// revisions, galleries and avatars. Unreferenced files older than the grace
+// period are reported as orphans and deleted when remove is set; references
+// to files that do not exist are reported as missing.
+func (s *uploadGCService) Reconcile(ctx context.Context, now time.Time, remove bool) (*models.UploadReport, error) {
+	lister, ok := s.blob.(storage.Lister)
+	if !ok {
+		return nil, ErrListingUnsupported
+	}
+
+	// Read the references before listing, so a file saved in between is at
+	// worst seen as recent rather than as an orphan.
+	references, err := s.postRepo.GetImageReferences(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool)
+	for _, reference := range references {
+		for _, key := range imageVariantKeys(reference.Image) {
+			referenced[key] = true
+		}
+	}
+
+	report := &models.UploadReport{
+		Orphans: make([]models.OrphanedUpload, 0),
+		Missing: make([]models.ImageReference, 0),
+	}
+	stored := make(map[string]bool)
+	cutoff := now.Add(-s.grace)
+
+	err = lister.List(ctx, func(object storage.Object) error {
+		report.Scanned++
+		stored[object.Key] = true
+
+		if referenced[object.Key] {
+			return nil
+		}
+		if object.ModTime.After(cutoff) {
+			report.Recent++
+			return nil
+		}
+		report.Orphans = append(report.Orphans, models.OrphanedUpload{Key: object.Key, Size: object.Size, ModTime: object.ModTime})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reference := range references {
+		if !stored[reference.Image] {
+			log.Warn().Str("source", reference.Source).Int("owner_id", reference.OwnerID).Str("image", reference.Image).Msg("Referenced image file is missing")
+			report.Missing = append(report.Missing, reference)
+		}
+	}
+
+	for _, orphan := range report.Orphans {
+		if !remove {
+			log.Info().Str("file", orphan.Key).Time("modified_at", orphan.ModTime).Msg("Orphaned upload found")
+			continue
+		}
+		if err := s.blob.Delete(ctx, orphan.Key); err != nil {
+			log.Warn().Err(err).Str("file", orphan.Key).Msg("Failed to delete orphaned upload")
+			continue
+		}
+		log.Info().Str("file", orphan.Key).Msg("Orphaned upload deleted")
+		report.Deleted++
+	}
+
+	return report, nil
+}
+
+// CollectOrphans runs Reconcile as a scheduler job and returns how many
+// orphans were deleted, or found when deleting is disabled.
+func (s *uploadGCService) CollectOrphans(ctx context.Context, now time.Time) (int, error) {
+	report, err := s.Reconcile(ctx, now, s.remove)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.remove {
+		return report.Deleted, nil
+	}
+	return len(report.Orphans), nil
+}
@@ -2,36 +2,61 @@ package service
 
 import (
 	"context"
-	"os"
+	"errors"
 	"regexp"
 	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/rafli2460/culinary-blog-api/internal/models"
 	"github.com/rafli2460/culinary-blog-api/internal/repository"
+	"github.com/rafli2460/culinary-blog-api/internal/throttle"
 	"github.com/rafli2460/culinary-blog-api/pkg/logger"
+	"github.com/rs/zerolog/log"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
 	Register(ctx context.Context, req models.RegisterRequest) error
-	Login(ctx context.Context, req models.LoginRequest) (string, error)
+	Login(ctx context.Context, req models.LoginRequest, client models.ClientInfo) (*models.LoginResult, error)
+	CompleteLogin(ctx context.Context, req models.TwoFactorLoginRequest, client models.ClientInfo) (*models.LoginResult, error)
+	LoginWithIdentity(ctx context.Context, user models.User, client models.ClientInfo) (*models.LoginResult, error)
 
 	GetAllUsers(ctx context.Context, search string) ([]models.User, error)
 	GetStats(ctx context.Context) (models.UserStats, error)
 	UpdateRole(ctx context.Context, targetUserID int, currentAdminID int, newRole string) error
 	DeleteUser(ctx context.Context, targetUserID int, currentAdminID int) error
+	UnlockUser(ctx context.Context, targetUserID int) error
+	GetLoginAttempts(ctx context.Context, username string, ipAddress string, page int, limit int) ([]models.LoginAttempt, int, error)
+	PruneLoginRecords(ctx context.Context, now time.Time) (int, error)
 }
 
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo         repository.UserRepository
+	loginAttemptRepo repository.LoginAttemptRepository
+	sessionService   SessionService
+	roleService      RoleService
+	twoFactorService TwoFactorService
+	emailService     EmailService
+	guard            *throttle.Guard
+	attemptRetention time.Duration
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{userRepo: repo}
+// NewUserService creates the user service. guard throttles failed logins, and
+// login attempts are kept for attemptRetention.
+func NewUserService(repo repository.UserRepository, loginAttemptRepo repository.LoginAttemptRepository, sessionService SessionService, roleService RoleService, twoFactorService TwoFactorService, emailService EmailService, guard *throttle.Guard, attemptRetention time.Duration) UserService {
+	return &userService{
+		userRepo:         repo,
+		loginAttemptRepo: loginAttemptRepo,
+		sessionService:   sessionService,
+		roleService:      roleService,
+		twoFactorService: twoFactorService,
+		emailService:     emailService,
+		guard:            guard,
+		attemptRetention: attemptRetention,
+	}
 }
 
+// Register creates an account and emails a link to verify its email.
 func (s *userService) Register(ctx context.Context, req models.RegisterRequest) error {
 	req.Username = strings.TrimSpace(req.Username)
 	if req.Username == "" {
@@ -42,69 +67,285 @@ func (s *userService) Register(ctx context.Context, req models.RegisterRequest)
 		return logger.ValidationError("username can only contain letters, numbers, and underscores")
 	}
 
-	_, err := s.userRepo.GetByUsername(ctx, req.Username)
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.userRepo.GetByUsername(ctx, req.Username)
 	if err == nil {
 		return logger.ValidationError("username is already taken")
 	} else if err.Error() != "user not found" {
 		return logger.LogError(err, "internal server error")
 	}
 
-	req.Password = strings.TrimSpace(req.Password)
-	if req.Password == "" {
-		return logger.ValidationError("Please enter password")
-	}
-	if len(req.Password) < 6 {
-		return logger.ValidationError("Password must be 6 characters long")
-	}
-	if req.Password != strings.TrimSpace(req.ConfirmPassword) {
-		return logger.ValidationError("passwords do not match")
+	_, err = s.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		return logger.ValidationError("email is already registered")
+	} else if err.Error() != "user not found" {
+		return logger.LogError(err, "internal server error")
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := hashNewPassword(req.Password, req.ConfirmPassword)
 	if err != nil {
-		return logger.LogError(err, "error generating password")
+		return err
 	}
 
 	newUser := &models.User{
 		Username: req.Username,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
+		Email:    &email,
 	}
 
-	return s.userRepo.Create(ctx, newUser)
+	if err := s.userRepo.Create(ctx, newUser); err != nil {
+		return err
+	}
+
+	// The account works without a verified email, so a mail outage does not
+	// fail the registration; the user can ask for another link.
+	if err := s.emailService.SendVerification(ctx, *newUser); err != nil {
+		log.Warn().Err(err).Int("user_id", newUser.ID).Msg("failed to send verification email")
+	}
+	return nil
+}
+
+// hashNewPassword checks a password chosen by a user against its
+// confirmation and the password rules, and returns its bcrypt hash.
+func hashNewPassword(password string, confirmPassword string) (string, error) {
+	password = strings.TrimSpace(password)
+	if password == "" {
+		return "", logger.ValidationError("Please enter password")
+	}
+	if len(password) < 6 {
+		return "", logger.ValidationError("Password must be 6 characters long")
+	}
+	if password != strings.TrimSpace(confirmPassword) {
+		return "", logger.ValidationError("passwords do not match")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", logger.LogError(err, "error generating password")
+	}
+	return string(hashedPassword), nil
+}
+
+// checkPassword checks the password a user entered to confirm a change to
+// their account. Accounts created through a login provider have no password
+// and are asked to set one first.
+func checkPassword(user models.User, password string) error {
+	if user.Password == "" {
+		return logger.ValidationError("your account has no password yet, set one first")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return logger.ValidationError("password is incorrect")
+	}
+	return nil
 }
 
-func (s *userService) Login(ctx context.Context, req models.LoginRequest) (string, error) {
+// Login checks a user's credentials and starts a new session for the client
+// they log in from. Usernames and IPs that fail too often are locked out for
+// a while, in which case a *throttle.LockedError is returned. Users with
+// two-factor authentication, or whose role requires it, get a challenge
+// instead, which CompleteLogin turns into a session.
+func (s *userService) Login(ctx context.Context, req models.LoginRequest, client models.ClientInfo) (*models.LoginResult, error) {
 	req.Username = strings.TrimSpace(req.Username)
 
 	if req.Username == "" || req.Password == "" {
-		return "", logger.ValidationError("username and password cannot be empty")
+		return nil, logger.ValidationError("username and password cannot be empty")
+	}
+
+	if err := s.checkThrottle(ctx, req.Username, nil, client); err != nil {
+		return nil, err
 	}
 
 	user, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
-		return "", logger.ValidationError("invalid username or password")
+		return nil, s.loginFailed(ctx, req.Username, nil, client)
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		return "", logger.ValidationError("invalid username or password")
+		return nil, s.loginFailed(ctx, req.Username, &user.ID, client)
+	}
+
+	// The failures are only forgotten once the second step succeeds, so the
+	// password cannot be used to keep guessing codes.
+	challenge, err := s.twoFactorService.Challenge(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return &models.LoginResult{Challenge: challenge}, nil
+	}
+
+	return s.startSession(ctx, user, client)
+}
+
+// CompleteLogin is the second step of a login: it checks the code for a
+// challenge and starts the session. For a user who had to set up two-factor
+// authentication, the code confirms the enrollment and the result carries
+// their recovery codes. Wrong codes count as failed logins.
+func (s *userService) CompleteLogin(ctx context.Context, req models.TwoFactorLoginRequest, client models.ClientInfo) (*models.LoginResult, error) {
+	userID, setup, err := s.twoFactorService.ParseChallenge(req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, logger.ValidationError("login challenge is invalid or has expired, please log in again")
+		}
+		return nil, err
+	}
+
+	if err := s.checkThrottle(ctx, user.Username, &user.ID, client); err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	if setup {
+		recoveryCodes, err = s.twoFactorService.Enable(ctx, user.ID, req.Code)
+	} else {
+		err = s.twoFactorService.Verify(ctx, user.ID, req.Code)
+	}
+	if err != nil {
+		if err.Error() == invalidTwoFactorCode {
+			s.countFailure(ctx, user.Username, &user.ID, client)
+		}
+		return nil, err
+	}
+
+	result, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	result.RecoveryCodes = recoveryCodes
+	return result, nil
+}
+
+// LoginWithIdentity logs in a user whom an OpenID Connect provider vouched
+// for, in place of their password. Two-factor authentication still applies.
+func (s *userService) LoginWithIdentity(ctx context.Context, user models.User, client models.ClientInfo) (*models.LoginResult, error) {
+	challenge, err := s.twoFactorService.Challenge(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return &models.LoginResult{Challenge: challenge}, nil
+	}
+
+	return s.startSession(ctx, user, client)
+}
+
+// checkThrottle refuses a login when the username or IP is locked out.
+// Failing to check fails closed, so an outage of the store cannot be used to
+// guess passwords unthrottled.
+func (s *userService) checkThrottle(ctx context.Context, username string, userID *int, client models.ClientInfo) error {
+	if err := s.guard.Check(ctx, username, client.IPAddress, time.Now()); err != nil {
+		var locked *throttle.LockedError
+		if !errors.As(err, &locked) {
+			logger.LogError(err, "failed to check login throttling")
+			return logger.ValidationError("login is unavailable, please try again later")
+		}
+		s.recordLoginAttempt(ctx, username, userID, client, models.LoginLocked)
+		return err
+	}
+	return nil
+}
+
+// startSession completes a login.
+func (s *userService) startSession(ctx context.Context, user models.User, client models.ClientInfo) (*models.LoginResult, error) {
+	if err := s.guard.Succeed(ctx, user.Username); err != nil {
+		logger.LogError(err, "failed to reset login failures")
+	}
+	s.recordLoginAttempt(ctx, user.Username, &user.ID, client, models.LoginSucceeded)
+
+	pair, err := s.sessionService.Create(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &models.LoginResult{Tokens: pair}, nil
+}
+
+// maxUsernameLength matches the users.username column.
+const maxUsernameLength = 50
+
+// loginFailed counts a failed login and returns the error for it.
+func (s *userService) loginFailed(ctx context.Context, username string, userID *int, client models.ClientInfo) error {
+	s.countFailure(ctx, username, userID, client)
+	return logger.ValidationError("invalid username or password")
+}
+
+func (s *userService) countFailure(ctx context.Context, username string, userID *int, client models.ClientInfo) {
+	if err := s.guard.Fail(ctx, username, client.IPAddress, time.Now()); err != nil {
+		logger.LogError(err, "failed to record login failure")
+	}
+	s.recordLoginAttempt(ctx, username, userID, client, models.LoginFailed)
+}
+
+// recordLoginAttempt keeps a login attempt for admins to review. Failing to
+// record one does not fail the login; the error is logged by the repository.
+func (s *userService) recordLoginAttempt(ctx context.Context, username string, userID *int, client models.ClientInfo, outcome string) {
+	if len(username) > maxUsernameLength {
+		username = username[:maxUsernameLength]
+	}
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	s.loginAttemptRepo.Create(ctx, &models.LoginAttempt{
+		Username:  username,
+		UserID:    userID,
+		IPAddress: client.IPAddress,
+		UserAgent: optionalString(userAgent),
+		Outcome:   outcome,
+	})
+}
+
+// UnlockUser lifts a lockout on a user's username and forgets its failed
+// logins.
+func (s *userService) UnlockUser(ctx context.Context, targetUserID int) error {
+	user, err := s.userRepo.GetByID(ctx, targetUserID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.guard.Unlock(ctx, user.Username); err != nil {
+		return logger.LogError(err, "failed to unlock user")
 	}
+	return nil
+}
 
-	claims := jwt.MapClaims{
-		"user_id": user.ID,
-		"role":    user.Role,
-		"exp":     time.Now().Add(time.Hour * 12).Unix(),
+func (s *userService) GetLoginAttempts(ctx context.Context, username string, ipAddress string, page int, limit int) ([]models.LoginAttempt, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	offset := (page - 1) * limit
 
-	secretKey := os.Getenv("JWT_SECRET")
-	tokenString, err := token.SignedString([]byte(secretKey))
+	return s.loginAttemptRepo.GetAll(ctx, strings.TrimSpace(username), strings.TrimSpace(ipAddress), limit, offset)
+}
+
+// PruneLoginRecords forgets failed logins that no longer count towards a
+// lockout and deletes login attempts older than the retention period. It is
+// meant to run as a scheduler job.
+func (s *userService) PruneLoginRecords(ctx context.Context, now time.Time) (int, error) {
+	pruned, err := s.guard.Prune(ctx, now)
 	if err != nil {
-		return "", logger.LogError(err, "error creating authentication token")
+		return 0, logger.LogError(err, "failed to prune login throttling")
 	}
 
-	return tokenString, nil
+	deleted, err := s.loginAttemptRepo.DeleteBefore(ctx, now.Add(-s.attemptRetention))
+	if err != nil {
+		return pruned, err
+	}
+	return pruned + deleted, nil
 }
 
 func (s *userService) GetAllUsers(ctx context.Context, search string) ([]models.User, error) {
@@ -123,14 +364,34 @@ func (s *userService) GetStats(ctx context.Context) (models.UserStats, error) {
 	return stats, nil
 }
 
+// UpdateRole gives a user one of the roles in the roles table. The change
+// reaches the user's sessions when their access token is next refreshed.
 func (s *userService) UpdateRole(ctx context.Context, targetUserID int, currentAdminID int, newRole string) error {
 	if targetUserID == currentAdminID {
 		return logger.ValidationError("action denied: you can't change your own role")
 	}
 
 	newRole = strings.ToLower(strings.TrimSpace(newRole))
-	if newRole != "admin" && newRole != "user" {
-		return logger.ValidationError("invalid role, must be 'admin' or 'user'")
+	exists, err := s.roleService.Exists(ctx, newRole)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return logger.ValidationError("invalid role: " + newRole)
+	}
+
+	target, err := s.userRepo.GetByID(ctx, targetUserID)
+	if err != nil {
+		return err
+	}
+	if target.Role == models.AdminRole && newRole != models.AdminRole {
+		stats, err := s.userRepo.GetStats(ctx)
+		if err != nil {
+			return err
+		}
+		if stats.AdminCount <= 1 {
+			return logger.ValidationError("action denied: this user is the only admin")
+		}
 	}
 
 	return s.userRepo.UpdateRole(ctx, targetUserID, newRole)
@@ -141,5 +402,8 @@ func (s *userService) DeleteUser(ctx context.Context, targetUserID int, currentA
 		return logger.ValidationError("action denied: you can't delete your own account")
 	}
 
-	return s.userRepo.Delete(ctx, targetUserID)
+	if err := s.userRepo.Delete(ctx, targetUserID); err != nil {
+		return err
+	}
+	return s.sessionService.RevokeOtherSessions(ctx, targetUserID, 0)
 }
@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config describes an S3-compatible bucket. Endpoint defaults to AWS for
+// Region; set it, usually with PathStyle, to use MinIO or another compatible
+// server. PublicURL overrides the address used in download URLs, e.g. a CDN.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PublicURL string
+	PathStyle bool
+}
+
+// S3 stores blobs as objects in an S3-compatible bucket. Requests are signed
+// with AWS Signature Version 4.
+type S3 struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, errors.New("s3 storage needs a bucket and a region")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("s3 storage needs an access key and a secret key")
+	}
+
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", cfg.Endpoint)
+	}
+	cfg.PublicURL = strings.TrimSuffix(cfg.PublicURL, "/")
+
+	return &S3{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: time.Minute},
+		now:      time.Now,
+	}, nil
+}
+
+// unsignedPayload lets uploads stream without hashing the body first.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// emptyPayloadHash is the SHA-256 of an empty body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, "put", key)
+	}
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, responseError(resp, "get", key)
+	}
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return responseError(resp, "delete", key)
+	}
+}
+
+func (s *S3) URL(key string) string {
+	if s.cfg.PublicURL != "" {
+		return s.cfg.PublicURL + "/" + uriEncode(key, false)
+	}
+	return s.objectURL(key)
+}
+
+// listBucketResult is the part of a ListObjectsV2 response List reads.
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through the bucket with ListObjectsV2.
+func (s *S3) List(ctx context.Context, fn func(Object) error) error {
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.bucketURL()+"/?"+canonicalQuery(query), nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := s.do(req, emptyPayloadHash)
+		if err != nil {
+			return err
+		}
+
+		var result listBucketResult
+		if resp.StatusCode != http.StatusOK {
+			err = responseError(resp, "list", s.cfg.Bucket)
+		} else {
+			err = xml.NewDecoder(resp.Body).Decode(&result)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, object := range result.Contents {
+			if err := fn(Object{Key: object.Key, Size: object.Size, ModTime: object.LastModified}); err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// objectURL addresses an object either as endpoint/bucket/key (path style) or
+// as bucket.endpoint/key (virtual-hosted style).
+func (s *S3) objectURL(key string) string {
+	return s.bucketURL() + "/" + uriEncode(key, false)
+}
+
+func (s *S3) bucketURL() string {
+	host := s.endpoint.Host
+	path := s.endpoint.EscapedPath()
+	if s.cfg.PathStyle {
+		path += "/" + uriEncode(s.cfg.Bucket, true)
+	} else {
+		host = s.cfg.Bucket + "." + host
+	}
+	return s.endpoint.Scheme + "://" + host + path
+}
+
+func (s *S3) newRequest(ctx context.Context, method string, key string, body io.Reader) (*http.Request, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	return http.NewRequestWithContext(ctx, method, s.objectURL(key), body)
+}
+
+func (s *S3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, s.now())
+	return s.client.Do(req)
+}
+
+// sign adds the SigV4 Authorization header to req, covering the host and
+// every header already set on it.
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashHex(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), day)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.cfg.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func canonicalQuery(values url.Values) string {
+	pairs := make([]string, 0, len(values))
+	for name, list := range values {
+		for _, value := range list {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything except unreserved characters, as SigV4
+// requires. Slashes are kept unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hashHex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func responseError(resp *http.Response, action string, key string) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s %q failed with status %d: %s", action, key, resp.StatusCode, strings.TrimSpace(string(body)))
+}
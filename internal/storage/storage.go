@@ -0,0 +1,57 @@
+// Package storage stores uploaded files such as post images behind a small
+// blob interface, so the same code can write to the local disk or to an
+// S3-compatible object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Get when no blob exists under the key.
+var ErrNotFound = errors.New("blob not found")
+
+// Blob is a flat store of files addressed by key.
+type Blob interface {
+	// Put stores size bytes read from r under key, replacing any existing blob.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob stored under key. The caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL clients use to download the blob.
+	URL(key string) string
+}
+
+// Object describes a stored blob.
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Lister is implemented by stores that can enumerate their blobs. It is kept
+// apart from Blob because only maintenance tasks need it.
+type Lister interface {
+	// List calls fn for every blob in the store, in no particular order. It
+	// stops at the first error fn returns.
+	List(ctx context.Context, fn func(Object) error) error
+}
+
+// validKey rejects keys that are empty or could escape the store, such as
+// absolute paths and ".." segments.
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return errors.New("invalid blob key: " + key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return errors.New("invalid blob key: " + key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory keeps entries in the process. They are lost on restart and not
+// shared between instances, so it suits a single server.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]Entry)}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) (Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.entries[key], nil
+}
+
+func (m *Memory) RecordFailure(ctx context.Context, key string, now time.Time, forgetBefore time.Time) (Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok || entry.quietSince().Before(forgetBefore) {
+		entry = Entry{}
+	}
+	entry.Failures++
+	entry.LastFailure = now
+
+	m.entries[key] = entry
+	return entry, nil
+}
+
+func (m *Memory) Lock(ctx context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.entries[key]
+	if until.After(entry.LockedUntil) {
+		entry.LockedUntil = until
+	}
+	m.entries[key] = entry
+	return nil
+}
+
+func (m *Memory) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *Memory) Prune(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pruned := 0
+	for key, entry := range m.entries {
+		if entry.quietSince().Before(before) {
+			delete(m.entries, key)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
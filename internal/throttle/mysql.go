@@ -0,0 +1,95 @@
+package throttle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MySQL keeps entries in the login_throttles table, so lockouts survive
+// restarts and are shared by every instance of the API.
+type MySQL struct {
+	db *sqlx.DB
+}
+
+// NewMySQL uses db for reads and writes alike: a lockout must be seen as soon
+// as it is written, which a read replica does not promise.
+func NewMySQL(db *sqlx.DB) *MySQL {
+	return &MySQL{db: db}
+}
+
+type mysqlEntry struct {
+	Failures    int        `db:"failures"`
+	LastFailure time.Time  `db:"last_failure_at"`
+	LockedUntil *time.Time `db:"locked_until"`
+}
+
+func (e mysqlEntry) entry() Entry {
+	entry := Entry{Failures: e.Failures, LastFailure: e.LastFailure}
+	if e.LockedUntil != nil {
+		entry.LockedUntil = *e.LockedUntil
+	}
+	return entry
+}
+
+func (m *MySQL) Get(ctx context.Context, key string) (Entry, error) {
+	var row mysqlEntry
+	query := `SELECT failures, last_failure_at, locked_until FROM login_throttles WHERE throttle_key = ?`
+
+	if err := m.db.GetContext(ctx, &row, query, key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Entry{}, nil
+		}
+		return Entry{}, err
+	}
+	return row.entry(), nil
+}
+
+// RecordFailure increments the count in a single statement, so concurrent
+// failures are all counted. MySQL applies the assignments in order, so the
+// lockout is cleared only when the count started over, and the quiet check
+// still sees the previous failure time.
+func (m *MySQL) RecordFailure(ctx context.Context, key string, now time.Time, forgetBefore time.Time) (Entry, error) {
+	query := `INSERT INTO login_throttles (throttle_key, failures, last_failure_at)
+			  VALUES (?, 1, ?)
+			  ON DUPLICATE KEY UPDATE
+				failures = IF(GREATEST(last_failure_at, COALESCE(locked_until, last_failure_at)) < ?, 1, failures + 1),
+				locked_until = IF(failures = 1, NULL, locked_until),
+				last_failure_at = VALUES(last_failure_at)`
+
+	if _, err := m.db.ExecContext(ctx, query, key, now, forgetBefore); err != nil {
+		return Entry{}, err
+	}
+	return m.Get(ctx, key)
+}
+
+func (m *MySQL) Lock(ctx context.Context, key string, until time.Time) error {
+	query := `UPDATE login_throttles SET locked_until = ?
+			  WHERE throttle_key = ? AND (locked_until IS NULL OR locked_until < ?)`
+	_, err := m.db.ExecContext(ctx, query, until, key, until)
+	return err
+}
+
+func (m *MySQL) Reset(ctx context.Context, key string) error {
+	_, err := m.db.ExecContext(ctx, `DELETE FROM login_throttles WHERE throttle_key = ?`, key)
+	return err
+}
+
+func (m *MySQL) Prune(ctx context.Context, before time.Time) (int, error) {
+	query := `DELETE FROM login_throttles
+			  WHERE GREATEST(last_failure_at, COALESCE(locked_until, last_failure_at)) < ?`
+
+	result, err := m.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rows), nil
+}
@@ -0,0 +1,160 @@
+// Package throttle slows down password guessing. It counts failed logins per
+// key, such as a username or a client IP, and locks a key out for a while
+// once it has failed too often, doubling the lockout with every further
+// failure.
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Entry is what a store remembers about one key.
+type Entry struct {
+	Failures    int
+	LastFailure time.Time
+	// LockedUntil is zero when the key has never been locked.
+	LockedUntil time.Time
+}
+
+// quietSince is when the key last failed or its lockout ended, whichever is
+// later.
+func (e Entry) quietSince() time.Time {
+	if e.LockedUntil.After(e.LastFailure) {
+		return e.LockedUntil
+	}
+	return e.LastFailure
+}
+
+// Store keeps failure counts and lockouts.
+type Store interface {
+	// Get returns the entry for key, or a zero Entry if there is none.
+	Get(ctx context.Context, key string) (Entry, error)
+	// RecordFailure counts a failure at now and returns the updated entry.
+	// An entry that has been quiet since before forgetBefore starts over.
+	RecordFailure(ctx context.Context, key string, now time.Time, forgetBefore time.Time) (Entry, error)
+	// Lock locks key out until the given time, unless it already is for
+	// longer.
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Reset forgets key.
+	Reset(ctx context.Context, key string) error
+	// Prune forgets the entries that have been quiet since before the given
+	// time and returns how many there were.
+	Prune(ctx context.Context, before time.Time) (int, error)
+}
+
+// Policy sets when a key is locked out and for how long.
+type Policy struct {
+	// MaxFailures is how many failures are allowed before a lockout.
+	MaxFailures int
+	// Window is how long a key must stay quiet before its failures are
+	// forgotten.
+	Window time.Duration
+	// BaseLockout is the first lockout; each further failure doubles it.
+	BaseLockout time.Duration
+	// MaxLockout caps the lockout.
+	MaxLockout time.Duration
+}
+
+// lockout returns how long a key with the given number of failures is locked
+// out, or 0 when it is not.
+func (p Policy) lockout(failures int) time.Duration {
+	if failures < p.MaxFailures {
+		return 0
+	}
+
+	exponent := failures - p.MaxFailures
+	if float64(p.BaseLockout)*math.Pow(2, float64(exponent)) >= float64(p.MaxLockout) {
+		return p.MaxLockout
+	}
+	return p.BaseLockout << exponent
+}
+
+// LockedError is returned when a login is refused because of a lockout.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Guard applies one policy to usernames and another to client IPs.
+type Guard struct {
+	store      Store
+	userPolicy Policy
+	ipPolicy   Policy
+}
+
+func NewGuard(store Store, userPolicy Policy, ipPolicy Policy) *Guard {
+	return &Guard{store: store, userPolicy: userPolicy, ipPolicy: ipPolicy}
+}
+
+func userKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns a *LockedError when the username or the IP is locked out.
+func (g *Guard) Check(ctx context.Context, username string, ip string, now time.Time) error {
+	var retryAfter time.Duration
+	for _, key := range []string{userKey(username), ipKey(ip)} {
+		entry, err := g.store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if wait := entry.LockedUntil.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Fail records a failed login for the username and the IP and locks out
+// whichever has failed too often.
+func (g *Guard) Fail(ctx context.Context, username string, ip string, now time.Time) error {
+	if err := g.fail(ctx, userKey(username), g.userPolicy, now); err != nil {
+		return err
+	}
+	return g.fail(ctx, ipKey(ip), g.ipPolicy, now)
+}
+
+func (g *Guard) fail(ctx context.Context, key string, policy Policy, now time.Time) error {
+	entry, err := g.store.RecordFailure(ctx, key, now, now.Add(-policy.Window))
+	if err != nil {
+		return err
+	}
+
+	if lockout := policy.lockout(entry.Failures); lockout > 0 {
+		return g.store.Lock(ctx, key, now.Add(lockout))
+	}
+	return nil
+}
+
+// Succeed forgets the username's failures after a successful login. The IP's
+// are kept, so one known password cannot be used to keep guessing others.
+func (g *Guard) Succeed(ctx context.Context, username string) error {
+	return g.store.Reset(ctx, userKey(username))
+}
+
+// Unlock lifts a username's lockout and forgets its failures.
+func (g *Guard) Unlock(ctx context.Context, username string) error {
+	return g.store.Reset(ctx, userKey(username))
+}
+
+// Prune forgets keys that both policies would have forgotten. It is meant to
+// run as a scheduler job.
+func (g *Guard) Prune(ctx context.Context, now time.Time) (int, error) {
+	window := max(g.userPolicy.Window, g.ipPolicy.Window)
+	return g.store.Prune(ctx, now.Add(-window))
+}
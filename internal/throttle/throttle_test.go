@@ -0,0 +1,193 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	testUserPolicy = Policy{MaxFailures: 3, Window: time.Hour, BaseLockout: time.Minute, MaxLockout: 10 * time.Minute}
+	testIPPolicy   = Policy{MaxFailures: 5, Window: time.Hour, BaseLockout: time.Minute, MaxLockout: 10 * time.Minute}
+)
+
+func TestPolicyLockout(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Minute},
+		{4, 2 * time.Minute},
+		{5, 4 * time.Minute},
+		{6, 8 * time.Minute},
+		{7, 10 * time.Minute},
+		{8, 10 * time.Minute},
+		// Far past the cap the shift would overflow; the cap still holds.
+		{100, 10 * time.Minute},
+		{1000, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := testUserPolicy.lockout(tt.failures); got != tt.want {
+			t.Errorf("lockout(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// retryAfter returns how long Check says to wait, or 0 when it lets the login
+// through.
+func retryAfter(t *testing.T, g *Guard, username, ip string, now time.Time) time.Duration {
+	t.Helper()
+	err := g.Check(context.Background(), username, ip, now)
+	if err == nil {
+		return 0
+	}
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("Check error = %v, want a *LockedError", err)
+	}
+	return locked.RetryAfter
+}
+
+func fail(t *testing.T, g *Guard, username, ip string, now time.Time) {
+	t.Helper()
+	if err := g.Fail(context.Background(), username, ip, now); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGuardLocksAndDoubles(t *testing.T) {
+	g := NewGuard(NewMemory(), testUserPolicy, testIPPolicy)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Below the threshold nothing is locked.
+	fail(t, g, "alice", "10.0.0.1", start)
+	fail(t, g, "Alice", "10.0.0.1", start.Add(time.Second))
+	if wait := retryAfter(t, g, "alice", "10.0.0.1", start.Add(2*time.Second)); wait != 0 {
+		t.Fatalf("locked after 2 failures for %v", wait)
+	}
+
+	// The third failure, counted case-insensitively, locks the username.
+	now := start.Add(2 * time.Second)
+	fail(t, g, "ALICE", "10.0.0.2", now)
+	if wait := retryAfter(t, g, "alice", "10.0.0.3", now); wait != time.Minute {
+		t.Errorf("first lockout = %v, want 1m", wait)
+	}
+	// Other usernames from the same IPs are not affected.
+	if wait := retryAfter(t, g, "bob", "10.0.0.1", now); wait != 0 {
+		t.Errorf("bob locked out for %v", wait)
+	}
+
+	// Each further failure after the lockout doubles it, up to the cap.
+	for _, want := range []time.Duration{2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute} {
+		now = now.Add(retryAfter(t, g, "alice", "10.0.0.9", now))
+		if wait := retryAfter(t, g, "alice", "10.0.0.9", now); wait != 0 {
+			t.Fatalf("still locked for %v when the lockout should have ended", wait)
+		}
+		fail(t, g, "alice", "10.0.0.9", now)
+		if wait := retryAfter(t, g, "alice", "10.0.0.9", now); wait != want {
+			t.Errorf("lockout = %v, want %v", wait, want)
+		}
+	}
+}
+
+func TestGuardForgetsAfterWindow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		// How long after the lockout ends the next failure comes.
+		quiet time.Duration
+		// The lockout that failure causes.
+		want time.Duration
+	}{
+		{"within the window the count goes on", time.Hour - time.Second, 2 * time.Minute},
+		{"after the window the count starts over", time.Hour + time.Second, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemory()
+			g := NewGuard(store, testUserPolicy, testIPPolicy)
+			for i := range 3 {
+				fail(t, g, "alice", "10.0.0.1", start.Add(time.Duration(i)*time.Second))
+			}
+
+			// The window counts from the end of the lockout, not from the
+			// failure that caused it.
+			lockedUntil := start.Add(2*time.Second + time.Minute)
+			now := lockedUntil.Add(tt.quiet)
+			fail(t, g, "alice", "10.0.0.2", now)
+
+			if wait := retryAfter(t, g, "alice", "10.0.0.2", now); wait != tt.want {
+				t.Errorf("lockout = %v, want %v", wait, tt.want)
+			}
+			entry, _ := store.Get(context.Background(), userKey("alice"))
+			if tt.want == 0 && (entry.Failures != 1 || !entry.LockedUntil.IsZero()) {
+				t.Errorf("entry = %+v, want one failure and no lockout", entry)
+			}
+		})
+	}
+}
+
+func TestGuardSucceedKeepsIPFailures(t *testing.T) {
+	g := NewGuard(NewMemory(), testUserPolicy, testIPPolicy)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	// Two failures for alice and a successful login clear her username...
+	fail(t, g, "alice", "10.0.0.1", now)
+	fail(t, g, "alice", "10.0.0.1", now)
+	if err := g.Succeed(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	fail(t, g, "alice", "10.0.0.1", now)
+	if wait := retryAfter(t, g, "alice", "10.0.0.2", now); wait != 0 {
+		t.Errorf("alice locked out for %v after a successful login", wait)
+	}
+
+	// ...but the IP keeps all three, so two more guesses at other accounts
+	// lock the IP for everyone using it.
+	fail(t, g, "bob", "10.0.0.1", now)
+	if wait := retryAfter(t, g, "carol", "10.0.0.1", now); wait != 0 {
+		t.Fatalf("IP locked after 4 failures for %v", wait)
+	}
+	fail(t, g, "carol", "10.0.0.1", now)
+	if wait := retryAfter(t, g, "dave", "10.0.0.1", now); wait != time.Minute {
+		t.Errorf("IP lockout = %v, want 1m", wait)
+	}
+	if wait := retryAfter(t, g, "dave", "10.0.0.2", now); wait != 0 {
+		t.Errorf("another IP locked out for %v", wait)
+	}
+}
+
+func TestGuardUnlockAndPrune(t *testing.T) {
+	store := NewMemory()
+	g := NewGuard(store, testUserPolicy, testIPPolicy)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	for range 3 {
+		fail(t, g, "alice", "10.0.0.1", now)
+	}
+	if err := g.Unlock(ctx, "Alice"); err != nil {
+		t.Fatal(err)
+	}
+	if wait := retryAfter(t, g, "alice", "10.0.0.2", now); wait != 0 {
+		t.Errorf("alice still locked for %v after Unlock", wait)
+	}
+
+	// The IP entry stays until it has been quiet for a full window.
+	if pruned, _ := g.Prune(ctx, now.Add(time.Hour)); pruned != 0 {
+		t.Errorf("pruned %d entries within the window", pruned)
+	}
+	if pruned, _ := g.Prune(ctx, now.Add(time.Hour+time.Second)); pruned != 1 {
+		t.Errorf("pruned %d entries after the window, want 1", pruned)
+	}
+	if entry, _ := store.Get(ctx, ipKey("10.0.0.1")); entry.Failures != 0 {
+		t.Errorf("IP entry %+v survived Prune", entry)
+	}
+}
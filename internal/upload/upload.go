@@ -0,0 +1,166 @@
+// Package upload checks uploaded files before anything else touches them. The
+// file type is taken from the file's magic bytes rather than its name, and
+// image dimensions are read from the header so oversized images and
+// decompression bombs are rejected without being decoded.
+package upload
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/rs/zerolog/log"
+	_ "golang.org/x/image/webp"
+)
+
+// Kind classifies why an upload was rejected.
+type Kind string
+
+const (
+	TooLarge        Kind = "too_large"
+	UnsupportedType Kind = "unsupported_type"
+	TooManyPixels   Kind = "too_many_pixels"
+	Invalid         Kind = "invalid"
+)
+
+// Error is returned for an upload that fails validation. Its message is meant
+// for the client.
+type Error struct {
+	Kind    Kind
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(kind Kind, message string) error {
+	log.Warn().Str("kind", string(kind)).Msg(message)
+	return &Error{Kind: kind, Message: message}
+}
+
+// Limits bounds what an upload may be.
+type Limits struct {
+	// MaxBytes is the largest accepted file size.
+	MaxBytes int64
+	// MaxDimension is the largest accepted width or height in pixels.
+	MaxDimension int
+	// MaxPixels is the largest accepted width times height.
+	MaxPixels int
+}
+
+// DefaultLimits allow files of up to 5MB and images of up to 8000 pixels on a
+// side and 40 megapixels in total.
+var DefaultLimits = Limits{
+	MaxBytes:     5 * 1024 * 1024,
+	MaxDimension: 8000,
+	MaxPixels:    40_000_000,
+}
+
+// imageTypes maps the sniffed content types that are accepted as images to
+// the format name image.DecodeConfig reports for them.
+var imageTypes = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// Image is an uploaded image that passed validation.
+type Image struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+type Validator struct {
+	limits Limits
+}
+
+func NewValidator(limits Limits) *Validator {
+	return &Validator{limits: limits}
+}
+
+// Limits returns the limits the validator enforces.
+func (v *Validator) Limits() Limits {
+	return v.limits
+}
+
+// Image reads an uploaded file and checks that it is a JPEG, PNG, GIF or WebP
+// image within the limits. Failures are reported as *Error.
+func (v *Validator) Image(file *multipart.FileHeader) (*Image, error) {
+	if file.Size > v.limits.MaxBytes {
+		return nil, newError(TooLarge, fmt.Sprintf("file size cannot exceed %s", formatBytes(v.limits.MaxBytes)))
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	return v.ImageFrom(src)
+}
+
+// ImageFrom is Image for a file that is already open.
+func (v *Validator) ImageFrom(r io.Reader) (*Image, error) {
+	// Read one byte past the limit to notice files whose header lied about
+	// their size.
+	data, err := io.ReadAll(io.LimitReader(r, v.limits.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > v.limits.MaxBytes {
+		return nil, newError(TooLarge, fmt.Sprintf("file size cannot exceed %s", formatBytes(v.limits.MaxBytes)))
+	}
+	if len(data) == 0 {
+		return nil, newError(Invalid, "file is empty")
+	}
+
+	contentType := http.DetectContentType(data)
+	format, ok := imageTypes[contentType]
+	if !ok {
+		return nil, newError(UnsupportedType, "file format is invalid (only JPG, PNG, GIF, WEBP)")
+	}
+
+	config, decoded, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decoded != format {
+		return nil, newError(Invalid, "file is not a valid image")
+	}
+
+	if config.Width < 1 || config.Height < 1 {
+		return nil, newError(Invalid, "file is not a valid image")
+	}
+	if config.Width > v.limits.MaxDimension || config.Height > v.limits.MaxDimension {
+		return nil, newError(TooManyPixels, fmt.Sprintf("image cannot be larger than %dx%d pixels", v.limits.MaxDimension, v.limits.MaxDimension))
+	}
+	if config.Width*config.Height > v.limits.MaxPixels {
+		return nil, newError(TooManyPixels, fmt.Sprintf("image cannot have more than %d megapixels", v.limits.MaxPixels/1_000_000))
+	}
+
+	return &Image{
+		Data:        data,
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+	}, nil
+}
+
+func formatBytes(n int64) string {
+	const mb = 1024 * 1024
+	if n%mb == 0 {
+		return fmt.Sprintf("%dMB", n/mb)
+	}
+	if n >= mb {
+		return fmt.Sprintf("%.1fMB", float64(n)/mb)
+	}
+	return fmt.Sprintf("%dKB", (n+1023)/1024)
+}
@@ -0,0 +1,126 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"testing"
+)
+
+// pngOf encodes a small PNG, then rewrites its IHDR chunk to claim the given
+// dimensions. Only the header is read before the limits are checked, so the
+// pixel data does not have to match.
+func pngOf(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// 8 byte signature, then the IHDR chunk: length, type, 13 bytes of data
+	// starting with width and height, and a CRC over type and data.
+	binary.BigEndian.PutUint32(data[16:20], width)
+	binary.BigEndian.PutUint32(data[20:24], height)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+// fileHeader uploads data as a multipart file called filename, the way a
+// handler receives it.
+func fileHeader(t *testing.T, filename string, data []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("image", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	w.Close()
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(int64(body.Len()) + 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return form.File["image"][0]
+}
+
+func TestValidatorImage(t *testing.T) {
+	small := Limits{MaxBytes: 1024, MaxDimension: 8000, MaxPixels: 40_000_000}
+
+	tests := []struct {
+		name     string
+		filename string
+		data     []byte
+		// size overrides the size the multipart header reports when set.
+		size     int64
+		limits   Limits
+		wantKind Kind
+	}{
+		{"png", "photo.png", pngOf(t, 2, 2), 0, DefaultLimits, ""},
+		{"type comes from the bytes, not the name", "photo.jpg", pngOf(t, 2, 2), 0, DefaultLimits, ""},
+		{"text renamed to .jpg", "photo.jpg", []byte("just some text, not an image"), 0, DefaultLimits, UnsupportedType},
+		{"html renamed to .png", "photo.png", []byte("<html><script>alert(1)</script></html>"), 0, DefaultLimits, UnsupportedType},
+		{"empty", "photo.png", nil, 0, DefaultLimits, Invalid},
+		{"decompression bomb", "bomb.png", pngOf(t, 100000, 100000), 0, DefaultLimits, TooManyPixels},
+		{"too wide", "wide.png", pngOf(t, 8001, 10), 0, DefaultLimits, TooManyPixels},
+		{"largest dimension", "square.png", pngOf(t, 8000, 5000), 0, DefaultLimits, ""},
+		{"within dimensions but over the megapixels", "square.png", pngOf(t, 7999, 7999), 0, DefaultLimits, TooManyPixels},
+		{"zero width", "empty.png", pngOf(t, 0, 10), 0, DefaultLimits, Invalid},
+		{"header reports too large", "photo.png", pngOf(t, 2, 2), 2048, small, TooLarge},
+		{"header lied about the size", "photo.png", append(pngOf(t, 2, 2), make([]byte, 2048)...), 100, small, TooLarge},
+		{"webp not decodable as webp", "photo.webp", []byte("RIFF\x24\x00\x00\x00WEBPVPX \x10\x00\x00\x00garbage garbage!"), 0, DefaultLimits, Invalid},
+		{"truncated webp", "photo.webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 \x10\x00\x00\x00"), 0, DefaultLimits, Invalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := fileHeader(t, tt.filename, tt.data)
+			if tt.size != 0 {
+				file.Size = tt.size
+			}
+
+			img, err := NewValidator(tt.limits).Image(file)
+			if tt.wantKind == "" {
+				if err != nil {
+					t.Fatalf("Image error = %v", err)
+				}
+				if img.ContentType != "image/png" || !bytes.Equal(img.Data, tt.data) {
+					t.Errorf("Image = %s with %d bytes, want the PNG", img.ContentType, len(img.Data))
+				}
+				return
+			}
+
+			var uploadErr *Error
+			if !errors.As(err, &uploadErr) {
+				t.Fatalf("Image error = %v, want a %s *Error", err, tt.wantKind)
+			}
+			if uploadErr.Kind != tt.wantKind {
+				t.Errorf("Image error kind = %s (%q), want %s", uploadErr.Kind, uploadErr.Message, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{5 * 1024 * 1024, "5MB"},
+		{1536 * 1024, "1.5MB"},
+		{1024, "1KB"},
+		{1000, "1KB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
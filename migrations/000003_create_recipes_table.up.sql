@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS recipes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    post_id INT NOT NULL UNIQUE,
+    servings INT NOT NULL DEFAULT 1,
+    prep_time INT NOT NULL DEFAULT 0,
+    cook_time INT NOT NULL DEFAULT 0,
+    total_time INT NOT NULL DEFAULT 0,
+    difficulty ENUM('easy', 'medium', 'hard') DEFAULT 'easy',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
\ No newline at end of file
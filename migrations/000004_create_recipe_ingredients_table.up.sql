@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS recipe_ingredients (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    recipe_id INT NOT NULL,
+    position INT NOT NULL,
+    quantity DECIMAL(10, 3) DEFAULT NULL,
+    unit VARCHAR(32) DEFAULT NULL,
+    name VARCHAR(255) NOT NULL,
+    note VARCHAR(255) DEFAULT NULL,
+    FOREIGN KEY (recipe_id) REFERENCES recipes(id) ON DELETE CASCADE
+);
\ No newline at end of file
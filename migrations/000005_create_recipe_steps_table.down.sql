@@ -0,0 +1 @@
+DROP TABLE IF EXISTS recipe_steps;
\ No newline at end of file
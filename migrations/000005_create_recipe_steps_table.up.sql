@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS recipe_steps (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    recipe_id INT NOT NULL,
+    step_number INT NOT NULL,
+    instruction TEXT NOT NULL,
+    UNIQUE KEY uq_recipe_step (recipe_id, step_number),
+    FOREIGN KEY (recipe_id) REFERENCES recipes(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS cuisines;
\ No newline at end of file
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS cuisines (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(120) NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS post_categories;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS post_cuisines;
\ No newline at end of file
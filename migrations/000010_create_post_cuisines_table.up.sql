@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS post_cuisines (
+    post_id INT NOT NULL,
+    cuisine_id INT NOT NULL,
+    PRIMARY KEY (post_id, cuisine_id),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (cuisine_id) REFERENCES cuisines(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE recipe_ingredients DROP INDEX ft_recipe_ingredients_name;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE recipe_ingredients ADD FULLTEXT INDEX ft_recipe_ingredients_name (name);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE recipe_ingredients
+    DROP FOREIGN KEY fk_recipe_ingredients_ingredient,
+    DROP COLUMN ingredient_id;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE recipe_ingredients
+    ADD COLUMN ingredient_id INT DEFAULT NULL AFTER recipe_id,
+    ADD CONSTRAINT fk_recipe_ingredients_ingredient FOREIGN KEY (ingredient_id) REFERENCES ingredients(id) ON DELETE SET NULL;
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS comments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    post_id INT NOT NULL,
+    user_id INT NOT NULL,
+    parent_id INT NULL,
+    content TEXT NOT NULL,
+    status ENUM('pending', 'approved', 'hidden') NOT NULL DEFAULT 'pending',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    edited_at DATETIME NULL,
+    deleted_at DATETIME NULL,
+    INDEX idx_comments_post (post_id, created_at),
+    INDEX idx_comments_status (status, created_at),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_id) REFERENCES comments(id) ON DELETE CASCADE
+);
\ No newline at end of file
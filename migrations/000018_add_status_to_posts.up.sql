@@ -0,0 +1,4 @@
+ALTER TABLE posts
+    ADD COLUMN status ENUM('draft', 'published', 'scheduled', 'archived') NOT NULL DEFAULT 'draft',
+    ADD COLUMN published_at DATETIME NULL,
+    ADD INDEX idx_posts_status_published_at (status, published_at);
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE posts SET status = 'draft', published_at = NULL WHERE status = 'published' AND published_at = created_at;
\ No newline at end of file
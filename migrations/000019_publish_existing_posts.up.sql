@@ -0,0 +1 @@
+UPDATE posts SET status = 'published', published_at = created_at WHERE published_at IS NULL;
\ No newline at end of file
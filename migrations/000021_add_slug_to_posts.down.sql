@@ -0,0 +1,3 @@
+ALTER TABLE posts
+    DROP INDEX uq_posts_slug,
+    DROP COLUMN slug;
\ No newline at end of file
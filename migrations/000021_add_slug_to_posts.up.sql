@@ -0,0 +1,3 @@
+ALTER TABLE posts
+    ADD COLUMN slug VARCHAR(255) NULL AFTER title,
+    ADD UNIQUE INDEX uq_posts_slug (slug);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE posts
+    DROP INDEX idx_posts_deleted_at,
+    DROP COLUMN deleted_at;
\ No newline at end of file
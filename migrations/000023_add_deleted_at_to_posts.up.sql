@@ -0,0 +1,3 @@
+ALTER TABLE posts
+    ADD COLUMN deleted_at DATETIME NULL,
+    ADD INDEX idx_posts_deleted_at (deleted_at);
\ No newline at end of file
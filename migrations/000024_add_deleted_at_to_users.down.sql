@@ -0,0 +1,3 @@
+ALTER TABLE users
+    DROP INDEX idx_users_deleted_at,
+    DROP COLUMN deleted_at;
\ No newline at end of file
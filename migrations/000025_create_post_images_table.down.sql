@@ -0,0 +1 @@
+DROP TABLE IF EXISTS post_images;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS post_images (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    post_id INT NOT NULL,
+    image VARCHAR(255) NOT NULL,
+    position INT NOT NULL,
+    caption VARCHAR(255) DEFAULT NULL,
+    alt_text VARCHAR(255) DEFAULT NULL,
+    step_number INT DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_post_images_post_position (post_id, position),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
\ No newline at end of file
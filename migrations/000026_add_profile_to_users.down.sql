@@ -0,0 +1,5 @@
+ALTER TABLE users
+    DROP COLUMN location,
+    DROP COLUMN avatar,
+    DROP COLUMN bio,
+    DROP COLUMN display_name;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN display_name VARCHAR(100) NULL,
+    ADD COLUMN bio TEXT NULL,
+    ADD COLUMN avatar VARCHAR(255) NULL,
+    ADD COLUMN location VARCHAR(100) NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS user_social_links;
\ No newline at end of file
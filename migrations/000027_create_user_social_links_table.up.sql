@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS user_social_links (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    platform VARCHAR(30) NOT NULL,
+    url VARCHAR(255) NOT NULL,
+    UNIQUE KEY uq_user_social_link (user_id, platform),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
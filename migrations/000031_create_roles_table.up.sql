@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(30) PRIMARY KEY,
+    description VARCHAR(255) DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
@@ -0,0 +1,5 @@
+INSERT INTO roles (name, description) VALUES
+    ('user', 'Writes and manages their own posts and comments'),
+    ('moderator', 'Moderates comments on every post'),
+    ('editor', 'Edits and removes any post and manages categories, cuisines and tags'),
+    ('admin', 'Full access, including user management');
\ No newline at end of file
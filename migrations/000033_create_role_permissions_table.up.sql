@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(30) NOT NULL,
+    permission VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role, permission),
+    FOREIGN KEY (role) REFERENCES roles(name) ON DELETE CASCADE ON UPDATE CASCADE
+);
\ No newline at end of file
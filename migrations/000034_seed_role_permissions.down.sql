@@ -0,0 +1 @@
+DELETE FROM role_permissions WHERE role IN ('moderator', 'editor', 'admin');
\ No newline at end of file
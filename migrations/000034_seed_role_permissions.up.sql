@@ -0,0 +1,11 @@
+INSERT INTO role_permissions (role, permission) VALUES
+    ('moderator', 'comments:moderate'),
+    ('editor', 'posts:edit:any'),
+    ('editor', 'posts:delete:any'),
+    ('editor', 'taxonomy:manage'),
+    ('admin', 'posts:edit:any'),
+    ('admin', 'posts:delete:any'),
+    ('admin', 'comments:moderate'),
+    ('admin', 'taxonomy:manage'),
+    ('admin', 'trash:manage'),
+    ('admin', 'users:manage');
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users MODIFY role ENUM('user', 'admin') DEFAULT 'user';
\ No newline at end of file
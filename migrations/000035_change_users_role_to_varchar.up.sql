@@ -0,0 +1 @@
+ALTER TABLE users MODIFY role VARCHAR(30) DEFAULT 'user';
\ No newline at end of file
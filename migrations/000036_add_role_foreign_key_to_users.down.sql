@@ -0,0 +1 @@
+ALTER TABLE users DROP FOREIGN KEY fk_users_role;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
\ No newline at end of file
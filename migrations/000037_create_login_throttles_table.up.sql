@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS login_throttles (
+    throttle_key VARCHAR(100) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at DATETIME NOT NULL,
+    locked_until DATETIME DEFAULT NULL,
+    INDEX idx_login_throttles_last_failure (last_failure_at)
+);
\ No newline at end of file
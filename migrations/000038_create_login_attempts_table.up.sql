@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    username VARCHAR(50) NOT NULL,
+    user_id INT DEFAULT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(255) DEFAULT NULL,
+    outcome ENUM('success', 'failed', 'locked') NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_login_attempts_username (username, created_at),
+    INDEX idx_login_attempts_ip (ip_address, created_at),
+    INDEX idx_login_attempts_created_at (created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    DROP COLUMN totp_last_step,
+    DROP COLUMN totp_enabled_at,
+    DROP COLUMN totp_secret;
\ No newline at end of file
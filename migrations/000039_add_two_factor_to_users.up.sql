@@ -0,0 +1,4 @@
+ALTER TABLE users
+    ADD COLUMN totp_secret VARCHAR(64) NULL,
+    ADD COLUMN totp_enabled_at DATETIME NULL,
+    ADD COLUMN totp_last_step BIGINT NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE roles DROP COLUMN require_2fa;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    DROP INDEX uq_users_email,
+    DROP COLUMN email_verified_at,
+    DROP COLUMN email;
\ No newline at end of file
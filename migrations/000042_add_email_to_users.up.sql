@@ -0,0 +1,4 @@
+ALTER TABLE users
+    ADD COLUMN email VARCHAR(255) NULL,
+    ADD COLUMN email_verified_at DATETIME NULL,
+    ADD UNIQUE KEY uq_users_email (email);
\ No newline at end of file
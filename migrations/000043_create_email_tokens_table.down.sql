@@ -0,0 +1 @@
+DROP TABLE IF EXISTS email_tokens;
\ No newline at end of file
@@ -0,0 +1,135 @@
+// Package diff computes line-based differences between two texts using the
+// longest common subsequence of their lines.
+package diff
+
+import "strings"
+
+// Op says what happened to a line going from the old text to the new one.
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Line is one line of a diff. OldLine and NewLine are 1-based line numbers in
+// the old and new text; the one that does not apply to the operation is 0.
+type Line struct {
+	Op      Op     `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// maxCells bounds the LCS table. Texts whose differing middle is larger than
+// this are reported as a whole-block replacement instead.
+const maxCells = 4_000_000
+
+// Lines returns the line-by-line difference between a and b. Line endings are
+// normalized, so CRLF and LF texts compare equal.
+func Lines(a, b string) []Line {
+	oldLines := splitLines(a)
+	newLines := splitLines(b)
+
+	// Common leading and trailing lines need no LCS work.
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	result := make([]Line, 0, len(oldLines)+len(newLines))
+	for i := 0; i < prefix; i++ {
+		result = append(result, Line{Op: Equal, Text: oldLines[i], OldLine: i + 1, NewLine: i + 1})
+	}
+
+	oldMiddle := oldLines[prefix : len(oldLines)-suffix]
+	newMiddle := newLines[prefix : len(newLines)-suffix]
+	result = append(result, middle(oldMiddle, newMiddle, prefix)...)
+
+	for i := 0; i < suffix; i++ {
+		oldIndex := len(oldLines) - suffix + i
+		newIndex := len(newLines) - suffix + i
+		result = append(result, Line{Op: Equal, Text: oldLines[oldIndex], OldLine: oldIndex + 1, NewLine: newIndex + 1})
+	}
+
+	return result
+}
+
+// Changed reports whether a diff contains any inserted or deleted line.
+func Changed(lines []Line) bool {
+	for _, line := range lines {
+		if line.Op != Equal {
+			return true
+		}
+	}
+	return false
+}
+
+// middle diffs the differing parts of two texts; offset is the number of
+// equal lines before them.
+func middle(a, b []string, offset int) []Line {
+	result := make([]Line, 0, len(a)+len(b))
+
+	if len(a)*len(b) > maxCells {
+		for i, text := range a {
+			result = append(result, Line{Op: Delete, Text: text, OldLine: offset + i + 1})
+		}
+		for j, text := range b {
+			result = append(result, Line{Op: Insert, Text: text, NewLine: offset + j + 1})
+		}
+		return result
+	}
+
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, Line{Op: Equal, Text: a[i], OldLine: offset + i + 1, NewLine: offset + j + 1})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, Line{Op: Delete, Text: a[i], OldLine: offset + i + 1})
+			i++
+		default:
+			result = append(result, Line{Op: Insert, Text: b[j], NewLine: offset + j + 1})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, Line{Op: Delete, Text: a[i], OldLine: offset + i + 1})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, Line{Op: Insert, Text: b[j], NewLine: offset + j + 1})
+	}
+
+	return result
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,98 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func eq(text string, oldLine, newLine int) Line {
+	return Line{Op: Equal, Text: text, OldLine: oldLine, NewLine: newLine}
+}
+
+func del(text string, oldLine int) Line {
+	return Line{Op: Delete, Text: text, OldLine: oldLine}
+}
+
+func ins(text string, newLine int) Line {
+	return Line{Op: Insert, Text: text, NewLine: newLine}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{"both empty", "", "", []Line{}},
+		{"identical", "a\nb", "a\nb", []Line{eq("a", 1, 1), eq("b", 2, 2)}},
+		{"trailing newline ignored", "a\nb\n", "a\nb", []Line{eq("a", 1, 1), eq("b", 2, 2)}},
+		{"CRLF equals LF", "a\r\nb\r\n", "a\nb\n", []Line{eq("a", 1, 1), eq("b", 2, 2)}},
+		{"from empty", "", "a\nb", []Line{ins("a", 1), ins("b", 2)}},
+		{"to empty", "a\nb", "", []Line{del("a", 1), del("b", 2)}},
+		{"changed line", "a\nb\nc", "a\nx\nc", []Line{eq("a", 1, 1), del("b", 2), ins("x", 2), eq("c", 3, 3)}},
+		{"inserted line", "a\nc", "a\nb\nc", []Line{eq("a", 1, 1), ins("b", 2), eq("c", 2, 3)}},
+		{"deleted line", "a\nb\nc", "a\nc", []Line{eq("a", 1, 1), del("b", 2), eq("c", 3, 2)}},
+		{"appended line", "a", "a\nb", []Line{eq("a", 1, 1), ins("b", 2)}},
+		{"common lines kept in the middle", "a\nb\nc\nd", "b\nx\nd\ne", []Line{
+			del("a", 1), eq("b", 2, 1), del("c", 3), ins("x", 2), eq("d", 4, 3), ins("e", 4),
+		}},
+		{"repeated lines", "x\na\nx", "a\nx\na", []Line{del("x", 1), eq("a", 2, 1), eq("x", 3, 2), ins("a", 3)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines(%q, %q) =\n%v\nwant\n%v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+// numbered returns n distinct lines after a shared first line.
+func numbered(prefix string, n int) string {
+	lines := []string{"title"}
+	for i := range n {
+		lines = append(lines, fmt.Sprintf("%s%d", prefix, i))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLinesTooLargeForLCS(t *testing.T) {
+	// 2001 x 2001 differing lines exceed maxCells, so the middle is replaced
+	// as a whole.
+	const n = 2001
+	got := Lines(numbered("old", n), numbered("new", n))
+
+	if len(got) != 1+2*n {
+		t.Fatalf("got %d lines, want %d", len(got), 1+2*n)
+	}
+	checks := map[int]Line{
+		0:     eq("title", 1, 1),
+		1:     del("old0", 2),
+		n:     del(fmt.Sprintf("old%d", n-1), n+1),
+		n + 1: ins("new0", 2),
+		2 * n: ins(fmt.Sprintf("new%d", n-1), n+1),
+	}
+	for index, want := range checks {
+		if got[index] != want {
+			t.Errorf("line %d = %+v, want %+v", index, got[index], want)
+		}
+	}
+}
+
+func TestChanged(t *testing.T) {
+	if Changed(Lines("a\nb", "a\r\nb\n")) {
+		t.Error("equal texts reported as changed")
+	}
+	if !Changed(Lines("a\nb", "a\nc")) {
+		t.Error("changed line not reported")
+	}
+	if !Changed(Lines("", "a")) {
+		t.Error("inserted line not reported")
+	}
+	if Changed(nil) {
+		t.Error("empty diff reported as changed")
+	}
+}
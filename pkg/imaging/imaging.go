@@ -0,0 +1,121 @@
+// Package imaging decodes uploaded photos and re-encodes them as resized JPEG
+// variants. Re-encoding drops all metadata, including EXIF GPS positions; the
+// EXIF orientation is applied to the pixels first so photos stay upright.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Variant is a named size an image is rendered at. The image is scaled down to
+// fit within MaxWidth x MaxHeight, keeping its aspect ratio; smaller images
+// are never scaled up.
+type Variant struct {
+	Name      string
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Rendition is one encoded variant of an image.
+type Rendition struct {
+	Name   string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// JPEGQuality is the quality renditions are encoded with.
+const JPEGQuality = 85
+
+// ErrUnsupportedFormat is returned for data that is not a JPEG, PNG, GIF or
+// WebP image.
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// Process decodes an image and renders it at every variant as JPEG. Animated
+// GIFs are reduced to their first frame.
+func Process(data []byte, variants []Variant) ([]Rendition, error) {
+	img, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	renditions := make([]Rendition, 0, len(variants))
+	for _, variant := range variants {
+		resized := Resize(img, variant.MaxWidth, variant.MaxHeight)
+
+		var buf bytes.Buffer
+		if err := EncodeJPEG(&buf, resized); err != nil {
+			return nil, err
+		}
+
+		bounds := resized.Bounds()
+		renditions = append(renditions, Rendition{
+			Name:   variant.Name,
+			Width:  bounds.Dx(),
+			Height: bounds.Dy(),
+			Data:   buf.Bytes(),
+		})
+	}
+
+	return renditions, nil
+}
+
+// Decode decodes a JPEG, PNG, GIF or WebP image. JPEGs are rotated or flipped
+// according to their EXIF orientation.
+func Decode(data []byte) (image.Image, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, ErrUnsupportedFormat
+		}
+		return nil, err
+	}
+
+	if format == "jpeg" {
+		img = orient(img, exifOrientation(data))
+	}
+	return img, nil
+}
+
+// Resize scales img down to fit within maxWidth x maxHeight. An image that
+// already fits is returned unchanged.
+func Resize(img image.Image, maxWidth int, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxWidth && height <= maxHeight {
+		return img
+	}
+
+	// Scale by whichever side overflows more.
+	newWidth, newHeight := maxWidth, height*maxWidth/width
+	if newHeight > maxHeight {
+		newWidth, newHeight = width*maxHeight/height, maxHeight
+	}
+	newWidth, newHeight = max(newWidth, 1), max(newHeight, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// EncodeJPEG writes img as a JPEG. Transparent areas are filled with white,
+// since JPEG has no alpha channel.
+func EncodeJPEG(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+
+	return jpeg.Encode(w, flat, &jpeg.Options{Quality: JPEGQuality})
+}
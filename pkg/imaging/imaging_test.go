@@ -0,0 +1,314 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+// halves returns a width x height image that is red on the left and blue on
+// the right.
+func halves(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withExif inserts an EXIF APP1 segment after the SOI marker of a JPEG. The
+// segment holds an orientation tag and a GPS IFD with a latitude, like the
+// ones phones write.
+func withExif(data []byte, order binary.ByteOrder, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+	binary.Write(&tiff, order, uint16(42))
+	binary.Write(&tiff, order, uint32(8))
+
+	entry := func(tag, kind uint16, count uint32, value []byte) {
+		binary.Write(&tiff, order, tag)
+		binary.Write(&tiff, order, kind)
+		binary.Write(&tiff, order, count)
+		tiff.Write(append(value, make([]byte, 4-len(value))...))
+	}
+	short := func(v uint16) []byte {
+		b := make([]byte, 2)
+		order.PutUint16(b, v)
+		return b
+	}
+	long := func(v uint32) []byte {
+		b := make([]byte, 4)
+		order.PutUint32(b, v)
+		return b
+	}
+
+	// IFD0 at 8: orientation and a pointer to the GPS IFD at 38.
+	binary.Write(&tiff, order, uint16(2))
+	entry(0x0112, 3, 1, short(orientation))
+	entry(0x8825, 4, 1, long(38))
+	binary.Write(&tiff, order, uint32(0))
+
+	// GPS IFD at 38: latitude ref and latitude, stored at 68.
+	binary.Write(&tiff, order, uint16(2))
+	entry(0x0001, 2, 2, []byte("N\x00"))
+	entry(0x0002, 5, 3, long(68))
+	binary.Write(&tiff, order, uint32(0))
+	for _, v := range []uint32{48, 1, 51, 1, 30, 1} {
+		binary.Write(&tiff, order, v)
+	}
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+// hasAppSegments reports whether a JPEG has any APPn segment other than the
+// JFIF header, which is where EXIF, XMP and other metadata live.
+func hasAppSegments(data []byte) bool {
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xFF {
+		marker := data[pos+1]
+		if marker == 0xDA {
+			return false
+		}
+		if marker >= 0xE1 && marker <= 0xEF {
+			return true
+		}
+		pos += 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+	}
+	return false
+}
+
+func isColor(c color.Color, want color.RGBA) bool {
+	r, g, b, _ := c.RGBA()
+	near := func(got uint32, want uint8) bool {
+		diff := int(got>>8) - int(want)
+		return diff > -60 && diff < 60
+	}
+	return near(r, want.R) && near(g, want.G) && near(b, want.B)
+}
+
+func TestExifOrientation(t *testing.T) {
+	plain := encodeJPEG(t, halves(8, 4))
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"little endian", withExif(plain, binary.LittleEndian, 6), 6},
+		{"big endian", withExif(plain, binary.BigEndian, 8), 8},
+		{"out of range", withExif(plain, binary.LittleEndian, 9), 1},
+		{"no exif", plain, 1},
+		{"not a jpeg", encodePNG(t, halves(8, 4)), 1},
+		{"truncated", withExif(plain, binary.LittleEndian, 6)[:20], 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exifOrientation(tt.data); got != tt.want {
+				t.Errorf("exifOrientation = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// A 3x2 image with one marked pixel in its top left corner, and where
+	// that pixel must end up for each orientation.
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.Set(0, 0, red)
+
+	tests := []struct {
+		orientation int
+		width       int
+		height      int
+		x, y        int
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+	}
+
+	for _, tt := range tests {
+		got := orient(src, tt.orientation)
+		bounds := got.Bounds()
+		if bounds.Dx() != tt.width || bounds.Dy() != tt.height {
+			t.Errorf("orientation %d: size %dx%d, want %dx%d", tt.orientation, bounds.Dx(), bounds.Dy(), tt.width, tt.height)
+			continue
+		}
+		if got.At(tt.x, tt.y) != color.Color(red) {
+			t.Errorf("orientation %d: marked pixel not at (%d, %d)", tt.orientation, tt.x, tt.y)
+		}
+	}
+}
+
+func TestDecodeAppliesOrientation(t *testing.T) {
+	plain := encodeJPEG(t, halves(40, 20))
+
+	tests := []struct {
+		name          string
+		data          []byte
+		width, height int
+		// The colors at the start and the end of the long side.
+		first, last color.RGBA
+	}{
+		{"as stored", plain, 40, 20, red, blue},
+		{"rotated 180°", withExif(plain, binary.LittleEndian, 3), 40, 20, blue, red},
+		{"turned clockwise", withExif(plain, binary.BigEndian, 6), 20, 40, red, blue},
+		{"turned counter-clockwise", withExif(plain, binary.LittleEndian, 8), 20, 40, blue, red},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Decode(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bounds := img.Bounds()
+			if bounds.Dx() != tt.width || bounds.Dy() != tt.height {
+				t.Fatalf("size %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), tt.width, tt.height)
+			}
+
+			first, last := img.At(5, 5), img.At(tt.width-5, tt.height-5)
+			if !isColor(first, tt.first) || !isColor(last, tt.last) {
+				t.Errorf("colors %v and %v, want %v and %v", first, last, tt.first, tt.last)
+			}
+		})
+	}
+}
+
+func TestDecodeUnsupported(t *testing.T) {
+	if _, err := Decode([]byte("not an image at all")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Decode error = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestProcessSizes(t *testing.T) {
+	variants := []Variant{
+		{Name: "thumb", MaxWidth: 320, MaxHeight: 320},
+		{Name: "card", MaxWidth: 800, MaxHeight: 800},
+		{Name: "full", MaxWidth: 2048, MaxHeight: 2048},
+	}
+
+	tests := []struct {
+		name          string
+		width, height int
+		// The expected width and height of each variant, in order.
+		want [][2]int
+	}{
+		{"landscape", 3000, 1500, [][2]int{{320, 160}, {800, 400}, {2048, 1024}}},
+		{"portrait", 600, 1200, [][2]int{{160, 320}, {400, 800}, {600, 1200}}},
+		{"smaller than every variant", 100, 80, [][2]int{{100, 80}, {100, 80}, {100, 80}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renditions, err := Process(encodePNG(t, halves(tt.width, tt.height)), variants)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(renditions) != len(variants) {
+				t.Fatalf("got %d renditions, want %d", len(renditions), len(variants))
+			}
+
+			for i, rendition := range renditions {
+				if rendition.Name != variants[i].Name {
+					t.Errorf("rendition %d is %q, want %q", i, rendition.Name, variants[i].Name)
+				}
+				if rendition.Width != tt.want[i][0] || rendition.Height != tt.want[i][1] {
+					t.Errorf("%s is %dx%d, want %dx%d", rendition.Name, rendition.Width, rendition.Height, tt.want[i][0], tt.want[i][1])
+				}
+
+				config, format, err := image.DecodeConfig(bytes.NewReader(rendition.Data))
+				if err != nil || format != "jpeg" || config.Width != rendition.Width || config.Height != rendition.Height {
+					t.Errorf("%s decodes as %s %dx%d (%v)", rendition.Name, format, config.Width, config.Height, err)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessStripsMetadata(t *testing.T) {
+	data := withExif(encodeJPEG(t, halves(40, 20)), binary.LittleEndian, 6)
+	if !hasAppSegments(data) {
+		t.Fatal("fixture has no metadata to strip")
+	}
+
+	renditions, err := Process(data, []Variant{{Name: "full", MaxWidth: 2048, MaxHeight: 2048}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := renditions[0].Data
+	if hasAppSegments(out) || bytes.Contains(out, []byte("Exif")) {
+		t.Error("output still carries EXIF metadata")
+	}
+	// The orientation is baked into the pixels instead.
+	if renditions[0].Width != 20 || renditions[0].Height != 40 {
+		t.Errorf("output is %dx%d, want the upright 20x40", renditions[0].Width, renditions[0].Height)
+	}
+}
+
+func TestEncodeJPEGFillsTransparency(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeJPEG(&buf, image.NewNRGBA(image.Rect(0, 0, 16, 16))); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if white := (color.RGBA{R: 255, G: 255, B: 255, A: 255}); !isColor(img.At(8, 8), white) {
+		t.Errorf("transparent pixel encoded as %v, want white", img.At(8, 8))
+	}
+}
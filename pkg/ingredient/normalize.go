@@ -0,0 +1,228 @@
+// Package ingredient reduces free-form ingredient names to a canonical form so
+// "2 Green Onions, thinly sliced", "scallions" and "daun bawang" all compare
+// equal.
+package ingredient
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rafli2460/culinary-blog-api/pkg/measure"
+)
+
+// descriptors are preparation and size words that do not change what the
+// ingredient is.
+var descriptors = map[string]bool{
+	"a": true, "an": true, "of": true, "the": true, "and": true, "or": true,
+	"fresh": true, "freshly": true, "large": true, "medium": true, "small": true, "big": true,
+	"chopped": true, "diced": true, "minced": true, "sliced": true, "grated": true,
+	"shredded": true, "peeled": true, "crushed": true, "ground": true, "cubed": true,
+	"finely": true, "roughly": true, "thinly": true, "coarsely": true,
+	"boneless": true, "skinless": true, "whole": true, "ripe": true, "dried": true,
+	"softened": true, "melted": true, "cold": true, "warm": true, "room": true, "temperature": true,
+	"organic": true, "extra": true, "virgin": true, "optional": true, "to": true, "taste": true,
+	"iris": true, "cincang": true, "halus": true, "segar": true, "secukupnya": true,
+}
+
+// synonyms maps alternative names, including common Indonesian ones, to the
+// canonical name. Keys and values are already singular.
+var synonyms = map[string]string{
+	"scallion":          "green onion",
+	"spring onion":      "green onion",
+	"daun bawang":       "green onion",
+	"bawang merah":      "shallot",
+	"bawang putih":      "garlic",
+	"garlic clove":      "garlic",
+	"clove garlic":      "garlic",
+	"bawang bombay":     "onion",
+	"cilantro":          "coriander",
+	"daun ketumbar":     "coriander",
+	"aubergine":         "eggplant",
+	"terong":            "eggplant",
+	"courgette":         "zucchini",
+	"garbanzo bean":     "chickpea",
+	"capsicum":          "bell pepper",
+	"paprika merah":     "bell pepper",
+	"chili pepper":      "chili",
+	"cabai":             "chili",
+	"cabe":              "chili",
+	"cabai rawit":       "bird's eye chili",
+	"cabe rawit":        "bird's eye chili",
+	"telur":             "egg",
+	"ayam":              "chicken",
+	"daging sapi":       "beef",
+	"udang":             "shrimp",
+	"prawn":             "shrimp",
+	"tahu":              "tofu",
+	"bean curd":         "tofu",
+	"kecap manis":       "sweet soy sauce",
+	"kecap asin":        "soy sauce",
+	"soya sauce":        "soy sauce",
+	"santan":            "coconut milk",
+	"gula pasir":        "sugar",
+	"granulated sugar":  "sugar",
+	"caster sugar":      "sugar",
+	"gula merah":        "palm sugar",
+	"gula jawa":         "palm sugar",
+	"tepung terigu":     "flour",
+	"all-purpose flour": "flour",
+	"plain flour":       "flour",
+	"maizena":           "cornstarch",
+	"cornflour":         "cornstarch",
+	"corn starch":       "cornstarch",
+	"mentega":           "butter",
+	"nasi":              "cooked rice",
+	"beras":             "rice",
+	"jahe":              "ginger",
+	"kunyit":            "turmeric",
+	"lengkuas":          "galangal",
+	"serai":             "lemongrass",
+	"sereh":             "lemongrass",
+	"jeruk nipis":       "lime",
+	"nanas":             "pineapple",
+	"buncis":            "green bean",
+	"bayam":             "spinach",
+	"kentang":           "potato",
+	"wortel":            "carrot",
+	"tomat":             "tomato",
+	"garam":             "salt",
+	"lada":              "pepper",
+	"merica":            "pepper",
+	"black pepper":      "pepper",
+	"white pepper":      "pepper",
+	"air":               "water",
+	"minyak goreng":     "oil",
+	"vegetable oil":     "oil",
+	"cooking oil":       "oil",
+	"canola oil":        "oil",
+	"minyak":            "oil",
+}
+
+// spellings maps variant spellings of single words to the one used in
+// canonical names, so they also match inside longer names like "red chillies".
+var spellings = map[string]string{
+	"chilli": "chili",
+	"chile":  "chili",
+}
+
+// unchanged are words ending in "s" that are not plurals.
+var unchanged = map[string]bool{
+	"asparagus": true, "couscous": true, "hummus": true, "molasses": true, "swiss": true,
+	"lemongrass": true, "citrus": true, "octopus": true, "hibiscus": true, "bass": true,
+	"series": true, "species": true,
+	"manis": true, "nipis": true, "nanas": true, "buncis": true, "teras": true, "kipas": true,
+}
+
+var irregularPlurals = map[string]string{
+	"leaves": "leaf", "halves": "half", "loaves": "loaf", "knives": "knife",
+	"geese": "goose", "feet": "foot", "mice": "mouse",
+	// Plurals of words ending in "ie" or "i", which the "ies" rule would
+	// turn into "y".
+	"chilies": "chili", "chillies": "chilli", "pies": "pie", "cookies": "cookie",
+	"brownies": "brownie",
+}
+
+// staples are assumed to be in every kitchen and never count as missing.
+var staples = []string{"salt", "pepper", "water", "oil"}
+
+// Normalize returns the canonical name for an ingredient. Quantities, units,
+// notes after a comma or in parentheses, and descriptor words are dropped,
+// each word is made singular with a single spelling, and known synonyms are
+// resolved.
+func Normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if parsed, ok := measure.ParseLine(name); ok {
+		name = parsed.Name
+	}
+
+	name = stripParentheses(name)
+	if idx := strings.IndexAny(name, ",;"); idx >= 0 {
+		name = name[:idx]
+	}
+
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '\''
+	})
+
+	kept := make([]string, 0, len(words))
+	for _, word := range words {
+		if !descriptors[word] {
+			kept = append(kept, word)
+		}
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+
+	phrase := strings.Join(kept, " ")
+	if canonical, ok := synonyms[phrase]; ok {
+		return canonical
+	}
+
+	for i := range kept {
+		kept[i] = Singular(kept[i])
+		if spelling, ok := spellings[kept[i]]; ok {
+			kept[i] = spelling
+		}
+	}
+	phrase = strings.Join(kept, " ")
+	if canonical, ok := synonyms[phrase]; ok {
+		return canonical
+	}
+
+	return phrase
+}
+
+// Singular returns the singular form of an English plural ingredient word.
+func Singular(word string) string {
+	if irregular, ok := irregularPlurals[word]; ok {
+		return irregular
+	}
+	if unchanged[word] || len(word) <= 3 || strings.HasSuffix(word, "ss") || strings.HasSuffix(word, "us") {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "sses"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	default:
+		return word
+	}
+}
+
+// IsStaple reports whether a canonical ingredient is a pantry staple.
+func IsStaple(canonical string) bool {
+	for _, staple := range staples {
+		if canonical == staple {
+			return true
+		}
+	}
+	return false
+}
+
+// Staples returns the canonical names treated as always available.
+func Staples() []string {
+	return append([]string(nil), staples...)
+}
+
+func stripParentheses(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package ingredient
+
+import "testing"
+
+func TestSingular(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"onions", "onion"},
+		{"cherries", "cherry"},
+		{"tomatoes", "tomato"},
+		{"peaches", "peach"},
+		{"radishes", "radish"},
+		{"boxes", "box"},
+		{"leaves", "leaf"},
+		{"halves", "half"},
+		{"chilies", "chili"},
+		{"chillies", "chilli"},
+		{"chiles", "chile"},
+		{"pies", "pie"},
+		{"cookies", "cookie"},
+		{"brownies", "brownie"},
+		{"asparagus", "asparagus"},
+		{"molasses", "molasses"},
+		{"hummus", "hummus"},
+		{"bass", "bass"},
+		{"peas", "pea"},
+		{"oats", "oat"},
+		{"gas", "gas"},
+		{"flour", "flour"},
+		{"manis", "manis"},
+		{"buncis", "buncis"},
+		{"nanas", "nanas"},
+	}
+
+	for _, tt := range tests {
+		if got := Singular(tt.word); got != tt.want {
+			t.Errorf("Singular(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"2 Green Onions, thinly sliced", "green onion"},
+		{"scallions", "green onion"},
+		{"daun bawang", "green onion"},
+		{"3 red chilies", "red chili"},
+		{"chilies", "chili"},
+		{"chillies", "chili"},
+		{"2 chiles (seeded)", "chili"},
+		{"red chillies", "red chili"},
+		{"red chilies", "red chili"},
+		{"2 red chiles", "red chili"},
+		{"1 tsp chilli powder", "chili powder"},
+		{"green chile pepper", "green chili pepper"},
+		{"chile pepper", "chili"},
+		{"cabai", "chili"},
+		{"cabe rawit", "bird's eye chili"},
+		{"5 bawang merah, iris tipis", "shallot"},
+		{"3 cloves garlic, minced", "garlic"},
+		{"bawang putih cincang halus", "garlic"},
+		{"2 sdm kecap manis", "sweet soy sauce"},
+		{"santan", "coconut milk"},
+		{"200 g tepung terigu", "flour"},
+		{"1 cup all-purpose flour", "flour"},
+		{"gula jawa", "palm sugar"},
+		{"serai", "lemongrass"},
+		{"2 Tomatoes, diced", "tomato"},
+		{"1 kg kentang", "potato"},
+		{"telur", "egg"},
+		{"garam secukupnya", "salt"},
+		{"freshly ground black pepper", "pepper"},
+		{"fresh coriander (cilantro)", "coriander"},
+		{"extra virgin olive oil", "olive oil"},
+		{"asparagus", "asparagus"},
+		{"to taste", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.name); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsStaple(t *testing.T) {
+	for _, name := range []string{"garam", "air", "minyak goreng", "black pepper"} {
+		if !IsStaple(Normalize(name)) {
+			t.Errorf("%q is not a staple", name)
+		}
+	}
+	if IsStaple(Normalize("garlic")) {
+		t.Error("garlic is a staple")
+	}
+}
@@ -0,0 +1,173 @@
+package measure
+
+import (
+	"strings"
+	"unicode"
+)
+
+// densities holds grams per millilitre for dry and semi-solid ingredients so
+// volume measures can be turned into weights. Liquids are left in volume.
+var densities = map[string]float64{
+	"all-purpose flour": 0.528,
+	"flour":             0.528,
+	"tepung terigu":     0.528,
+	"bread flour":       0.537,
+	"whole wheat flour": 0.507,
+	"cornstarch":        0.541,
+	"maizena":           0.541,
+	"tapioca":           0.507,
+	"rice flour":        0.672,
+	"tepung beras":      0.672,
+	"sugar":             0.845,
+	"granulated sugar":  0.845,
+	"gula pasir":        0.845,
+	"brown sugar":       0.930,
+	"gula merah":        0.930,
+	"powdered sugar":    0.507,
+	"icing sugar":       0.507,
+	"butter":            0.959,
+	"mentega":           0.959,
+	"margarine":         0.959,
+	"cocoa":             0.359,
+	"cocoa powder":      0.359,
+	"rice":              0.782,
+	"beras":             0.782,
+	"oats":              0.380,
+	"rolled oats":       0.380,
+	"salt":              1.217,
+	"garam":             1.217,
+	"honey":             1.420,
+	"madu":              1.420,
+	"peanut butter":     1.082,
+	"grated cheese":     0.423,
+	"shredded coconut":  0.359,
+	"kelapa parut":      0.359,
+	"chopped nuts":      0.507,
+	"breadcrumbs":       0.456,
+}
+
+// liquids are words that make an ingredient a liquid, which stays in volume
+// even when a density key appears in its name, e.g. "rice vinegar".
+var liquids = map[string]bool{
+	"milk": true, "buttermilk": true, "cream": true, "water": true, "vinegar": true,
+	"oil": true, "juice": true, "wine": true, "stock": true, "broth": true,
+	"sauce": true, "syrup": true, "extract": true, "liqueur": true,
+	"air": true, "santan": true, "susu": true, "cuka": true, "minyak": true, "kecap": true,
+}
+
+// maxDensityWords is the most words a density table key has.
+const maxDensityWords = 3
+
+// Density returns grams per millilitre for an ingredient name, if known. Only
+// the end of the name is matched, as whole words, since that is where the
+// ingredient itself is named: "brown sugar, packed" is brown sugar, but
+// "sugar snap peas" is peas. Notes after a comma or in parentheses are
+// ignored.
+func Density(name string) (float64, bool) {
+	name = strings.ToLower(name)
+	if idx := strings.IndexAny(name, ",;("); idx >= 0 {
+		name = name[:idx]
+	}
+
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-'
+	})
+	for _, word := range words {
+		if liquids[word] {
+			return 0, false
+		}
+	}
+
+	// Longest match first, so "brown sugar" wins over "sugar".
+	for n := min(maxDensityWords, len(words)); n > 0; n-- {
+		if density, ok := densities[strings.Join(words[len(words)-n:], " ")]; ok {
+			return density, true
+		}
+	}
+	return 0, false
+}
+
+// Convert expresses the ingredient in the requested system. Using the density
+// table, cups of dry ingredients become grams when converting to metric and
+// grams become cups when converting to imperial; everything else keeps its
+// dimension and is moved to the most readable unit of the target system.
+// Unitless quantities are untouched.
+func Convert(ingredient Ingredient, system System) Ingredient {
+	if ingredient.Unit == nil || system == Original {
+		return ingredient
+	}
+
+	unit := *ingredient.Unit
+	base := ingredient.Quantity * unit.Factor
+	baseMax := ingredient.QuantityMax * unit.Factor
+	dimension := unit.Dimension
+
+	if unit.System != system {
+		if density, ok := Density(ingredient.Name); ok {
+			switch {
+			case system == Metric && dimension == Volume:
+				base, baseMax, dimension = base*density, baseMax*density, Mass
+			case system == Imperial && dimension == Mass:
+				base, baseMax, dimension = base/density, baseMax/density, Volume
+			}
+		}
+	}
+
+	target := bestUnit(base, dimension, system)
+	ingredient.Quantity = base / target.Factor
+	ingredient.QuantityMax = baseMax / target.Factor
+	ingredient.Unit = &target
+	return ingredient
+}
+
+// Normalize moves a quantity to the most readable unit of its own system,
+// e.g. 1500 ml becomes 1.5 l and 24 tsp becomes 1/2 cup.
+func Normalize(ingredient Ingredient) Ingredient {
+	if ingredient.Unit == nil {
+		return ingredient
+	}
+	return Convert(ingredient, ingredient.Unit.System)
+}
+
+// bestUnit picks the unit a cook would naturally use for an amount given in
+// base units (ml or g).
+func bestUnit(base float64, dimension Dimension, system System) Unit {
+	switch {
+	case dimension == Volume && system == Metric:
+		if base >= 1000 {
+			return Liter
+		}
+		return Milliliter
+	case dimension == Volume:
+		switch {
+		case base < Tablespoon.Factor:
+			return Teaspoon
+		case base < Cup.Factor/4:
+			return Tablespoon
+		case base < Gallon.Factor:
+			return Cup
+		default:
+			return Gallon
+		}
+	case dimension == Mass && system == Metric:
+		if base >= 1000 {
+			return Kilogram
+		}
+		return Gram
+	default:
+		if base >= Pound.Factor {
+			return Pound
+		}
+		return Ounce
+	}
+}
+
+// CelsiusToFahrenheit converts an oven temperature.
+func CelsiusToFahrenheit(c float64) float64 {
+	return c*9/5 + 32
+}
+
+// FahrenheitToCelsius converts an oven temperature.
+func FahrenheitToCelsius(f float64) float64 {
+	return (f - 32) * 5 / 9
+}
@@ -0,0 +1,121 @@
+package measure
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+type kitchenFraction struct {
+	value float64
+	text  string
+}
+
+// kitchenFractions are the fractions found on measuring cups and spoons.
+var kitchenFractions = []kitchenFraction{
+	{0, ""},
+	{1.0 / 8, "1/8"},
+	{1.0 / 4, "1/4"},
+	{1.0 / 3, "1/3"},
+	{3.0 / 8, "3/8"},
+	{1.0 / 2, "1/2"},
+	{5.0 / 8, "5/8"},
+	{2.0 / 3, "2/3"},
+	{3.0 / 4, "3/4"},
+	{7.0 / 8, "7/8"},
+	{1, ""},
+}
+
+// RoundQuantity rounds a quantity to something that can actually be measured:
+// the nearest kitchen fraction for imperial and unitless amounts, and a
+// sensible step for metric weights and volumes.
+func RoundQuantity(qty float64, unit *Unit) float64 {
+	if qty <= 0 {
+		return 0
+	}
+
+	if unit != nil && unit.System == Metric {
+		return roundMetric(qty, unit)
+	}
+
+	whole := math.Floor(qty)
+	fraction := nearestFraction(qty - whole)
+	rounded := whole + fraction.value
+	if rounded == 0 {
+		// Never round a real amount away entirely.
+		return kitchenFractions[1].value
+	}
+	return rounded
+}
+
+// FormatQuantity renders a rounded quantity, e.g. "1 1/2", "2/3" or "250".
+func FormatQuantity(qty float64, unit *Unit) string {
+	qty = RoundQuantity(qty, unit)
+
+	if unit != nil && unit.System == Metric {
+		return strconv.FormatFloat(qty, 'f', -1, 64)
+	}
+
+	whole := math.Floor(qty)
+	fraction := nearestFraction(qty - whole)
+	if fraction.value == 1 {
+		whole++
+		fraction = kitchenFractions[0]
+	}
+
+	switch {
+	case whole == 0:
+		return fraction.text
+	case fraction.text == "":
+		return strconv.Itoa(int(whole))
+	default:
+		return fmt.Sprintf("%d %s", int(whole), fraction.text)
+	}
+}
+
+// Format renders the whole ingredient line, e.g. "1 1/2 cup flour".
+func Format(ingredient Ingredient) string {
+	text := FormatQuantity(ingredient.Quantity, ingredient.Unit)
+	if ingredient.QuantityMax > 0 {
+		text += "-" + FormatQuantity(ingredient.QuantityMax, ingredient.Unit)
+	}
+	if ingredient.Unit != nil {
+		text += " " + ingredient.Unit.Name
+	}
+	return text + " " + ingredient.Name
+}
+
+func nearestFraction(remainder float64) kitchenFraction {
+	best := kitchenFractions[0]
+	for _, candidate := range kitchenFractions[1:] {
+		if math.Abs(remainder-candidate.value) < math.Abs(remainder-best.value) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+func roundMetric(qty float64, unit *Unit) float64 {
+	if unit.Factor >= 1000 {
+		// kg and l: two decimals are plenty.
+		return math.Round(qty*100) / 100
+	}
+
+	var step float64
+	switch {
+	case qty < 5:
+		step = 0.5
+	case qty < 50:
+		step = 1
+	case qty < 500:
+		step = 5
+	default:
+		step = 10
+	}
+
+	rounded := math.Round(qty/step) * step
+	if rounded == 0 {
+		return step
+	}
+	return rounded
+}
@@ -0,0 +1,108 @@
+package measure
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Ingredient is a single parsed ingredient line. Unit is nil for countable
+// ingredients such as "2 eggs". QuantityMax is set only for ranges.
+type Ingredient struct {
+	Original    string
+	Quantity    float64
+	QuantityMax float64
+	Unit        *Unit
+	Name        string
+}
+
+var (
+	bulletPrefix   = regexp.MustCompile(`^\s*(?:[-*•·]+|\d+[.)])\s+`)
+	stepPrefix     = regexp.MustCompile(`^\s*\d+[.)]\s+\D`)
+	servingsSuffix = regexp.MustCompile(`(?i)\b(?:serves|servings?|yield|makes|porsi|untuk)\s*:?\s*(\d+)`)
+	servingsPrefix = regexp.MustCompile(`(?i)\b(\d+)\s*(?:servings?|porsi|people|orang)\b`)
+	// notIngredient matches what follows the number in oven temperatures,
+	// cooking times and yields, such as "350°F oven", "30 minutes" or
+	// "4 servings".
+	notIngredient = regexp.MustCompile(`(?i)^(?:[°º]|(?:degrees?|derajat|minutes?|menit|hours?|jam|servings?|porsi|people|orang)\b)`)
+)
+
+// ParseLine parses an ingredient line such as "1 1/2 cups flour" or
+// "- 200 g sugar, sifted". Lines that do not start with a quantity, numbered
+// instructions like "1. Preheat the oven", temperatures or times such as
+// "350°F oven" or "30 minutes", and yields such as "4 servings" are rejected.
+func ParseLine(line string) (Ingredient, bool) {
+	original := strings.TrimSpace(line)
+	if original == "" {
+		return Ingredient{}, false
+	}
+
+	if stepPrefix.MatchString(original) {
+		return Ingredient{}, false
+	}
+	text := bulletPrefix.ReplaceAllString(original, "")
+
+	qty, max, rest, ok := ParseQuantity(text)
+	if !ok {
+		return Ingredient{}, false
+	}
+
+	ingredient := Ingredient{
+		Original:    original,
+		Quantity:    qty,
+		QuantityMax: max,
+	}
+
+	rest = strings.TrimLeft(rest, " ")
+	if notIngredient.MatchString(rest) {
+		return Ingredient{}, false
+	}
+	if unit, afterUnit, found := readUnit(rest); found {
+		ingredient.Unit = &unit
+		rest = afterUnit
+	}
+
+	rest = strings.TrimSpace(rest)
+	rest = strings.TrimPrefix(rest, "of ")
+	if rest == "" {
+		return Ingredient{}, false
+	}
+	ingredient.Name = rest
+
+	return ingredient, true
+}
+
+// ExtractIngredients returns every line of free-text content that parses as
+// an ingredient, in the order they appear.
+func ExtractIngredients(content string) []Ingredient {
+	ingredients := make([]Ingredient, 0)
+	for _, line := range strings.Split(content, "\n") {
+		if ingredient, ok := ParseLine(line); ok {
+			ingredients = append(ingredients, ingredient)
+		}
+	}
+	return ingredients
+}
+
+// ParseServings looks for a yield such as "Serves 4", "Servings: 6" or
+// "4 porsi" in free-text content.
+func ParseServings(content string) (int, bool) {
+	for _, pattern := range []*regexp.Regexp{servingsSuffix, servingsPrefix} {
+		match := pattern.FindStringSubmatch(content)
+		if match == nil {
+			continue
+		}
+		servings, err := strconv.Atoi(match[1])
+		if err == nil && servings > 0 {
+			return servings, true
+		}
+	}
+	return 0, false
+}
+
+// Scale multiplies the ingredient's quantity (and range upper bound) by factor.
+func (i Ingredient) Scale(factor float64) Ingredient {
+	i.Quantity *= factor
+	i.QuantityMax *= factor
+	return i
+}
@@ -0,0 +1,229 @@
+package measure
+
+import "testing"
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line string
+		qty  float64
+		max  float64
+		unit string
+		name string
+	}{
+		{"1 1/2 cups flour", 1.5, 0, "cup", "flour"},
+		{"- 200 g sugar, sifted", 200, 0, "g", "sugar, sifted"},
+		{"• 1/2 tsp salt", 0.5, 0, "tsp", "salt"},
+		{"* 2 eggs", 2, 0, "", "eggs"},
+		{"2-3 cloves garlic", 2, 3, "", "cloves garlic"},
+		{"3 sdm gula pasir", 3, 0, "tbsp", "gula pasir"},
+		{"1 cup of milk", 1, 0, "cup", "milk"},
+		{"1 T butter", 1, 0, "tbsp", "butter"},
+		{"1 t salt", 1, 0, "tsp", "salt"},
+		{"2 fl oz cream", 2, 0, "fl oz", "cream"},
+		{"2 cupcakes", 2, 0, "", "cupcakes"},
+		{"1,5 kg daging sapi", 1.5, 0, "kg", "daging sapi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := ParseLine(tt.line)
+			if !ok {
+				t.Fatalf("ParseLine(%q) was rejected", tt.line)
+			}
+			unit := ""
+			if got.Unit != nil {
+				unit = got.Unit.Name
+			}
+			if !approxEqual(got.Quantity, tt.qty) || !approxEqual(got.QuantityMax, tt.max) || unit != tt.unit || got.Name != tt.name {
+				t.Errorf("ParseLine(%q) = %v-%v %q %q, want %v-%v %q %q", tt.line, got.Quantity, got.QuantityMax, unit, got.Name, tt.qty, tt.max, tt.unit, tt.name)
+			}
+			if got.Original != tt.line {
+				t.Errorf("ParseLine(%q).Original = %q", tt.line, got.Original)
+			}
+		})
+	}
+}
+
+func TestParseLineRejects(t *testing.T) {
+	lines := []string{
+		"",
+		"Salt to taste",
+		"1. Preheat the oven",
+		"2) Mix everything",
+		"5",
+		"1/0 cup x",
+		"350°F oven",
+		"180 °C",
+		"400 degrees",
+		"180 derajat Celsius",
+		"30 minutes",
+		"2-3 hours",
+		"10 menit",
+		"1 jam",
+		"4 servings",
+		"4-6 Servings",
+		"1 serving",
+		"6 porsi",
+		"4 people",
+		"2 orang",
+	}
+
+	for _, line := range lines {
+		if got, ok := ParseLine(line); ok {
+			t.Errorf("ParseLine(%q) = %+v, want it rejected", line, got)
+		}
+	}
+}
+
+func TestExtractIngredients(t *testing.T) {
+	content := "Serves 4\n4 servings\n6 porsi\n\n- 2 cups rice\n- 1 tsp salt\n\n1. Bake at 350°F\n30 minutes\nEnjoy!"
+
+	got := ExtractIngredients(content)
+	if len(got) != 2 || got[0].Name != "rice" || got[1].Name != "salt" {
+		t.Errorf("ExtractIngredients = %+v, want rice and salt", got)
+	}
+}
+
+func TestParseServings(t *testing.T) {
+	tests := []struct {
+		content string
+		want    int
+		ok      bool
+	}{
+		{"Serves 4", 4, true},
+		{"Servings: 6", 6, true},
+		{"Untuk 3 porsi", 3, true},
+		{"Feeds 8 people", 8, true},
+		{"No yield here", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseServings(tt.content)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseServings(%q) = %d, %v, want %d, %v", tt.content, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     Ingredient
+		factor float64
+		qty    float64
+		max    float64
+	}{
+		{"double", Ingredient{Quantity: 2}, 2, 4, 0},
+		{"range", Ingredient{Quantity: 2, QuantityMax: 3}, 1.5, 3, 4.5},
+		{"halve", Ingredient{Quantity: 1}, 0.5, 0.5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.in.Scale(tt.factor)
+			if !approxEqual(got.Quantity, tt.qty) || !approxEqual(got.QuantityMax, tt.max) {
+				t.Errorf("Scale(%v) = %v-%v, want %v-%v", tt.factor, got.Quantity, got.QuantityMax, tt.qty, tt.max)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	unit := func(u Unit) *Unit { return &u }
+
+	tests := []struct {
+		name   string
+		in     Ingredient
+		system System
+		qty    float64
+		max    float64
+		unit   string
+	}{
+		{"flour cups to grams", Ingredient{Quantity: 1, Unit: unit(Cup), Name: "flour"}, Metric, 236.588 * 0.528, 0, "g"},
+		{"longest density key wins", Ingredient{Quantity: 1, Unit: unit(Cup), Name: "brown sugar"}, Metric, 236.588 * 0.930, 0, "g"},
+		{"flour range", Ingredient{Quantity: 1, QuantityMax: 2, Unit: unit(Cup), Name: "flour"}, Metric, 236.588 * 0.528, 2 * 236.588 * 0.528, "g"},
+		{"liquids stay volume", Ingredient{Quantity: 1, Unit: unit(Cup), Name: "milk"}, Metric, 236.588, 0, "ml"},
+		{"sugar grams to cups", Ingredient{Quantity: 250, Unit: unit(Gram), Name: "sugar"}, Imperial, 250 / 0.845 / 236.588, 0, "cup"},
+		{"pounds to grams", Ingredient{Quantity: 2, Unit: unit(Pound), Name: "chicken"}, Metric, 907.184, 0, "g"},
+		{"pounds to kilograms", Ingredient{Quantity: 3, Unit: unit(Pound), Name: "chicken"}, Metric, 1.360776, 0, "kg"},
+		{"same system keeps dimension", Ingredient{Quantity: 100, Unit: unit(Gram), Name: "flour"}, Metric, 100, 0, "g"},
+		{"original is untouched", Ingredient{Quantity: 1, Unit: unit(Cup), Name: "flour"}, Original, 1, 0, "cup"},
+		{"unitless is untouched", Ingredient{Quantity: 2, Name: "eggs"}, Metric, 2, 0, ""},
+		{"buttermilk stays volume", Ingredient{Quantity: 1, Unit: unit(Cup), Name: "buttermilk"}, Metric, 236.588, 0, "ml"},
+		{"rice vinegar stays volume", Ingredient{Quantity: 2, Unit: unit(Tablespoon), Name: "rice vinegar"}, Metric, 2 * 14.787, 0, "ml"},
+		{"sugar snap peas stay volume", Ingredient{Quantity: 1, Unit: unit(Cup), Name: "sugar snap peas"}, Metric, 236.588, 0, "ml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Convert(tt.in, tt.system)
+			name := ""
+			if got.Unit != nil {
+				name = got.Unit.Name
+			}
+			if !approxEqual(got.Quantity, tt.qty) || !approxEqual(got.QuantityMax, tt.max) || name != tt.unit {
+				t.Errorf("Convert = %v-%v %q, want %v-%v %q", got.Quantity, got.QuantityMax, name, tt.qty, tt.max, tt.unit)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	unit := func(u Unit) *Unit { return &u }
+
+	tests := []struct {
+		name string
+		in   Ingredient
+		qty  float64
+		unit string
+	}{
+		{"millilitres to litres", Ingredient{Quantity: 1500, Unit: unit(Milliliter), Name: "water"}, 1.5, "l"},
+		{"grams to kilograms", Ingredient{Quantity: 2000, Unit: unit(Gram), Name: "beef"}, 2, "kg"},
+		{"teaspoons to cups", Ingredient{Quantity: 24, Unit: unit(Teaspoon), Name: "sugar"}, 0.5, "cup"},
+		{"teaspoons to tablespoons", Ingredient{Quantity: 6, Unit: unit(Teaspoon), Name: "oil"}, 2, "tbsp"},
+		{"unitless", Ingredient{Quantity: 3, Name: "eggs"}, 3, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Normalize(tt.in)
+			name := ""
+			if got.Unit != nil {
+				name = got.Unit.Name
+			}
+			if !approxEqual(got.Quantity, tt.qty) || name != tt.unit {
+				t.Errorf("Normalize = %v %q, want %v %q", got.Quantity, name, tt.qty, tt.unit)
+			}
+		})
+	}
+}
+
+func TestDensity(t *testing.T) {
+	tests := []struct {
+		name    string
+		density float64
+		ok      bool
+	}{
+		{"flour", 0.528, true},
+		{"Brown Sugar, packed", 0.930, true},
+		{"whole wheat flour", 0.507, true},
+		{"unsalted butter", 0.959, true},
+		{"peanut butter", 1.082, true},
+		{"gula pasir", 0.845, true},
+		{"sugar (sifted)", 0.845, true},
+		// A density key inside another ingredient's name does not count.
+		{"buttermilk", 0, false},
+		{"rice vinegar", 0, false},
+		{"sugar snap peas", 0, false},
+		{"salted butter milk", 0, false},
+		{"flour tortillas", 0, false},
+		{"water", 0, false},
+	}
+
+	for _, tt := range tests {
+		density, ok := Density(tt.name)
+		if ok != tt.ok || density != tt.density {
+			t.Errorf("Density(%q) = %v, %v, want %v, %v", tt.name, density, ok, tt.density, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package measure parses, scales and converts the quantities found in recipe
+// ingredient lists. It has no knowledge of posts or the database so it can be
+// reused anywhere ingredient text needs to be understood.
+package measure
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var unicodeFractions = map[rune]float64{
+	'¼': 0.25, '½': 0.5, '¾': 0.75,
+	'⅓': 1.0 / 3, '⅔': 2.0 / 3,
+	'⅕': 0.2, '⅖': 0.4, '⅗': 0.6, '⅘': 0.8,
+	'⅙': 1.0 / 6, '⅚': 5.0 / 6,
+	'⅛': 0.125, '⅜': 0.375, '⅝': 0.625, '⅞': 0.875,
+}
+
+// ParseQuantity reads a leading quantity such as "2", "1.5", "1,5", "3/4",
+// "1 1/2", "1½" or a range like "2-3" from s. It returns the quantity, the
+// upper bound of a range (zero when s is not a range), the unparsed remainder
+// and whether a quantity was found at all.
+func ParseQuantity(s string) (qty float64, max float64, rest string, ok bool) {
+	qty, rest, ok = parseNumber(s)
+	if !ok {
+		return 0, 0, s, false
+	}
+
+	trimmed := strings.TrimLeft(rest, " ")
+	for _, sep := range []string{"-", "–", "to "} {
+		if !strings.HasPrefix(trimmed, sep) {
+			continue
+		}
+		upper, after, found := parseNumber(strings.TrimLeft(strings.TrimPrefix(trimmed, sep), " "))
+		if found && upper > qty {
+			return qty, upper, after, true
+		}
+	}
+
+	return qty, 0, rest, true
+}
+
+// parseNumber reads a whole number, decimal, simple fraction or mixed number
+// from the start of s.
+func parseNumber(s string) (float64, string, bool) {
+	s = strings.TrimLeft(s, " \t")
+	if s == "" {
+		return 0, s, false
+	}
+
+	first, size := firstRune(s)
+	if frac, ok := unicodeFractions[first]; ok {
+		return frac, s[size:], true
+	}
+
+	whole, rest, ok := readDecimal(s)
+	if !ok {
+		return 0, s, false
+	}
+
+	// "1½" or "1 ½"
+	afterSpace := strings.TrimLeft(rest, " ")
+	if r, size := firstRune(afterSpace); size > 0 {
+		if frac, ok := unicodeFractions[r]; ok {
+			return whole + frac, afterSpace[size:], true
+		}
+	}
+
+	// "3/4"
+	if strings.HasPrefix(rest, "/") {
+		denominator, after, ok := readDecimal(rest[1:])
+		if ok && denominator == 0 {
+			// "1/0" is not a quantity, and reading just the 1 would leave
+			// "/0" in the ingredient name.
+			return 0, s, false
+		}
+		if ok {
+			return whole / denominator, after, true
+		}
+		return whole, rest, true
+	}
+
+	// "1 1/2"
+	if afterSpace != rest {
+		numerator, afterNum, ok := readDecimal(afterSpace)
+		if ok && strings.HasPrefix(afterNum, "/") {
+			denominator, after, ok := readDecimal(afterNum[1:])
+			if ok && denominator != 0 && numerator < denominator {
+				return whole + numerator/denominator, after, true
+			}
+		}
+	}
+
+	return whole, rest, true
+}
+
+// readDecimal reads digits with an optional "." or "," decimal separator.
+func readDecimal(s string) (float64, string, bool) {
+	end := 0
+	seenSeparator := false
+	for end < len(s) {
+		ch := s[end]
+		if ch >= '0' && ch <= '9' {
+			end++
+			continue
+		}
+		if (ch == '.' || ch == ',') && !seenSeparator && end > 0 && end+1 < len(s) && s[end+1] >= '0' && s[end+1] <= '9' {
+			seenSeparator = true
+			end++
+			continue
+		}
+		break
+	}
+
+	if end == 0 {
+		return 0, s, false
+	}
+
+	value, err := strconv.ParseFloat(strings.Replace(s[:end], ",", ".", 1), 64)
+	if err != nil {
+		return 0, s, false
+	}
+
+	return value, s[end:], true
+}
+
+func firstRune(s string) (rune, int) {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return 0, 0
+	}
+	return r, size
+}
@@ -0,0 +1,128 @@
+package measure
+
+import (
+	"math"
+	"testing"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-3
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		in   string
+		qty  float64
+		max  float64
+		rest string
+		ok   bool
+	}{
+		{"2 cups flour", 2, 0, " cups flour", true},
+		{"1.5 kg beef", 1.5, 0, " kg beef", true},
+		{"1,5 kg daging", 1.5, 0, " kg daging", true},
+		{"3/4 cup milk", 0.75, 0, " cup milk", true},
+		{"1 1/2 cups sugar", 1.5, 0, " cups sugar", true},
+		{"½ tsp salt", 0.5, 0, " tsp salt", true},
+		{"1½ cups water", 1.5, 0, " cups water", true},
+		{"1 ½ cups water", 1.5, 0, " cups water", true},
+		{"2 ¾ cups rice", 2.75, 0, " cups rice", true},
+		{"⅓ cup oil", 1.0 / 3, 0, " cup oil", true},
+		{"2-3 cloves garlic", 2, 3, " cloves garlic", true},
+		{"2 – 3 cloves garlic", 2, 3, " cloves garlic", true},
+		{"2 to 3 eggs", 2, 3, " eggs", true},
+		{"1/2-1 tsp chili flakes", 0.5, 1, " tsp chili flakes", true},
+		// A "range" that goes down is not a range.
+		{"3-2 eggs", 3, 0, "-2 eggs", true},
+		// 1 1/2 is a mixed number, but 3 5/4 is not.
+		{"3 5/4 cups", 3, 0, " 5/4 cups", true},
+		{"1/0 cup x", 0, 0, "1/0 cup x", false},
+		{"salt to taste", 0, 0, "salt to taste", false},
+		{"", 0, 0, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			qty, max, rest, ok := ParseQuantity(tt.in)
+			if ok != tt.ok {
+				t.Fatalf("ParseQuantity(%q) ok = %v, want %v", tt.in, ok, tt.ok)
+			}
+			if !approxEqual(qty, tt.qty) || !approxEqual(max, tt.max) || rest != tt.rest {
+				t.Errorf("ParseQuantity(%q) = %v, %v, %q, want %v, %v, %q", tt.in, qty, max, rest, tt.qty, tt.max, tt.rest)
+			}
+		})
+	}
+}
+
+func TestRoundQuantity(t *testing.T) {
+	tests := []struct {
+		name string
+		qty  float64
+		unit *Unit
+		want float64
+	}{
+		{"zero", 0, &Cup, 0},
+		{"third of a cup", 0.3, &Cup, 1.0 / 3},
+		{"five eighths", 1.6, &Cup, 1.625},
+		{"rounds up to whole", 2.95, nil, 3},
+		{"never rounds to nothing", 0.05, nil, 0.125},
+		{"small metric", 3.2, &Gram, 3},
+		{"tiny metric", 0.1, &Gram, 0.5},
+		{"medium metric", 247, &Gram, 245},
+		{"large metric", 732, &Milliliter, 730},
+		{"kilograms", 1.234, &Kilogram, 1.23},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundQuantity(tt.qty, tt.unit); !approxEqual(got, tt.want) {
+				t.Errorf("RoundQuantity(%v) = %v, want %v", tt.qty, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatQuantity(t *testing.T) {
+	tests := []struct {
+		qty  float64
+		unit *Unit
+		want string
+	}{
+		{1.5, &Cup, "1 1/2"},
+		{0.33, &Cup, "1/3"},
+		{0.05, nil, "1/8"},
+		{2.95, nil, "3"},
+		{2, nil, "2"},
+		{247, &Gram, "245"},
+		{0.1, &Gram, "0.5"},
+		{1.234, &Kilogram, "1.23"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatQuantity(tt.qty, tt.unit); got != tt.want {
+			t.Errorf("FormatQuantity(%v) = %q, want %q", tt.qty, got, tt.want)
+		}
+	}
+}
+
+func TestConvertTemperatures(t *testing.T) {
+	tests := []struct {
+		text   string
+		system System
+		want   string
+	}{
+		{"Bake at 350°F for 30 minutes", Metric, "Bake at 180°C for 30 minutes"},
+		{"Preheat to 425 degrees F", Metric, "Preheat to 220°C"},
+		{"Panaskan oven 180 derajat Celsius", Imperial, "Panaskan oven 350°F"},
+		{"Oven at 200C", Imperial, "Oven at 400°F"},
+		{"Bake at 350°F", Imperial, "Bake at 350°F"},
+		{"Bake at 180°C", Metric, "Bake at 180°C"},
+		{"Bake at 350°F", Original, "Bake at 350°F"},
+		{"Serves 4", Metric, "Serves 4"},
+	}
+
+	for _, tt := range tests {
+		if got := ConvertTemperatures(tt.text, tt.system); got != tt.want {
+			t.Errorf("ConvertTemperatures(%q, %q) = %q, want %q", tt.text, tt.system, got, tt.want)
+		}
+	}
+}
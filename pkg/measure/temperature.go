@@ -0,0 +1,47 @@
+package measure
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var temperaturePattern = regexp.MustCompile(`(\d{2,3})\s*(?:°\s*|º\s*|degrees?\s+|derajat\s+)([CcFf])(?:elsius|elcius|ahrenheit)?\b|(\d{3})\s*([CF])\b`)
+
+// ConvertTemperatures rewrites oven temperatures found in text ("350°F",
+// "180 derajat Celsius", "200C") into the requested system, rounding to
+// settings an oven dial actually has.
+func ConvertTemperatures(text string, system System) string {
+	if system == Original {
+		return text
+	}
+
+	return temperaturePattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := temperaturePattern.FindStringSubmatch(match)
+		digits, scale := parts[1], parts[2]
+		if digits == "" {
+			digits, scale = parts[3], parts[4]
+		}
+
+		value, err := strconv.ParseFloat(digits, 64)
+		if err != nil {
+			return match
+		}
+
+		isFahrenheit := strings.EqualFold(scale, "F")
+		switch {
+		case system == Metric && isFahrenheit:
+			return fmt.Sprintf("%d°C", roundTo(FahrenheitToCelsius(value), 10))
+		case system == Imperial && !isFahrenheit:
+			return fmt.Sprintf("%d°F", roundTo(CelsiusToFahrenheit(value), 25))
+		default:
+			return match
+		}
+	})
+}
+
+func roundTo(value float64, step float64) int {
+	return int(math.Round(value/step) * step)
+}
@@ -0,0 +1,98 @@
+package measure
+
+import "strings"
+
+// Dimension groups units that can be converted into each other.
+type Dimension int
+
+const (
+	Count Dimension = iota
+	Volume
+	Mass
+)
+
+// System is the measurement system a quantity should be presented in.
+type System string
+
+const (
+	Original System = ""
+	Metric   System = "metric"
+	Imperial System = "imperial"
+)
+
+// Unit describes a cooking unit. Factor is the size of one unit expressed in
+// the base unit of its dimension: millilitres for volume, grams for mass.
+type Unit struct {
+	Name      string
+	Dimension Dimension
+	System    System
+	Factor    float64
+}
+
+var (
+	Milliliter = Unit{Name: "ml", Dimension: Volume, System: Metric, Factor: 1}
+	Liter      = Unit{Name: "l", Dimension: Volume, System: Metric, Factor: 1000}
+	Teaspoon   = Unit{Name: "tsp", Dimension: Volume, System: Imperial, Factor: 4.92892}
+	Tablespoon = Unit{Name: "tbsp", Dimension: Volume, System: Imperial, Factor: 14.7868}
+	FluidOunce = Unit{Name: "fl oz", Dimension: Volume, System: Imperial, Factor: 29.5735}
+	Cup        = Unit{Name: "cup", Dimension: Volume, System: Imperial, Factor: 236.588}
+	Pint       = Unit{Name: "pint", Dimension: Volume, System: Imperial, Factor: 473.176}
+	Quart      = Unit{Name: "quart", Dimension: Volume, System: Imperial, Factor: 946.353}
+	Gallon     = Unit{Name: "gallon", Dimension: Volume, System: Imperial, Factor: 3785.41}
+	Gram       = Unit{Name: "g", Dimension: Mass, System: Metric, Factor: 1}
+	Kilogram   = Unit{Name: "kg", Dimension: Mass, System: Metric, Factor: 1000}
+	Ounce      = Unit{Name: "oz", Dimension: Mass, System: Imperial, Factor: 28.3495}
+	Pound      = Unit{Name: "lb", Dimension: Mass, System: Imperial, Factor: 453.592}
+)
+
+// unitAliases maps every spelling we accept to its unit, including the
+// Indonesian spoon abbreviations (sdm, sdt) that show up in our recipes.
+var unitAliases = map[string]Unit{
+	"ml": Milliliter, "milliliter": Milliliter, "milliliters": Milliliter, "millilitre": Milliliter, "millilitres": Milliliter,
+	"l": Liter, "liter": Liter, "liters": Liter, "litre": Liter, "litres": Liter,
+	"tsp": Teaspoon, "teaspoon": Teaspoon, "teaspoons": Teaspoon, "t": Teaspoon, "sdt": Teaspoon,
+	"tbsp": Tablespoon, "tbs": Tablespoon, "tablespoon": Tablespoon, "tablespoons": Tablespoon, "T": Tablespoon, "sdm": Tablespoon,
+	"fl oz": FluidOunce, "fl. oz": FluidOunce, "fluid ounce": FluidOunce, "fluid ounces": FluidOunce,
+	"cup": Cup, "cups": Cup, "c": Cup,
+	"pint": Pint, "pints": Pint, "pt": Pint,
+	"quart": Quart, "quarts": Quart, "qt": Quart,
+	"gallon": Gallon, "gallons": Gallon, "gal": Gallon,
+	"g": Gram, "gr": Gram, "gram": Gram, "grams": Gram, "gramme": Gram, "grammes": Gram,
+	"kg": Kilogram, "kilogram": Kilogram, "kilograms": Kilogram, "kilo": Kilogram, "kilos": Kilogram,
+	"oz": Ounce, "ounce": Ounce, "ounces": Ounce,
+	"lb": Pound, "lbs": Pound, "pound": Pound, "pounds": Pound,
+}
+
+// LookupUnit resolves a unit spelling. Single-letter "T" and "t" are treated
+// case-sensitively (tablespoon and teaspoon); everything else is not.
+func LookupUnit(name string) (Unit, bool) {
+	name = strings.TrimRight(strings.TrimSpace(name), ".,")
+	if unit, ok := unitAliases[name]; ok {
+		return unit, true
+	}
+	unit, ok := unitAliases[strings.ToLower(name)]
+	return unit, ok
+}
+
+// readUnit matches the longest unit alias at the start of s, requiring the
+// alias to end on a word boundary so "cupcake" is not read as a cup.
+func readUnit(s string) (Unit, string, bool) {
+	words := strings.Fields(s)
+	for n := 2; n >= 1; n-- {
+		if len(words) < n {
+			continue
+		}
+		candidate := strings.Join(words[:n], " ")
+		unit, ok := LookupUnit(candidate)
+		if !ok {
+			continue
+		}
+
+		rest := strings.TrimLeft(s, " ")
+		for i := 0; i < n; i++ {
+			rest = strings.TrimLeft(strings.TrimPrefix(rest, words[i]), " ")
+		}
+		return unit, rest, true
+	}
+	return Unit{}, s, false
+}
@@ -0,0 +1,78 @@
+// Package slug turns human-readable names into URL-safe identifiers.
+package slug
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength is the longest slug Make returns.
+const MaxLength = 200
+
+// transliterations spells out letters that do not decompose into an ASCII
+// letter plus accents.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d",
+	'ł': "l", 'þ': "th", 'ı': "i", 'ħ': "h", 'ŋ': "ng",
+}
+
+// Make lowercases s, transliterates accented letters to ASCII and joins the
+// letters and digits with single hyphens, e.g. "Nasi Goreng & Sambal" becomes
+// "nasi-goreng-sambal" and "Crème Brûlée" becomes "creme-brulee". The result
+// is cut to MaxLength at a word boundary.
+func Make(s string) string {
+	var b strings.Builder
+	pendingHyphen := false
+
+	write := func(text string) {
+		if pendingHyphen && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		pendingHyphen = false
+		b.WriteString(text)
+	}
+
+	// NFD splits "é" into "e" and a combining accent, which is then dropped.
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			write(string(r))
+		case unicode.Is(unicode.Mn, r):
+			// Combining accent left over from decomposition.
+		case r == '\'' || r == '’':
+			// "Mom's" reads better as "moms" than "mom-s".
+		default:
+			if ascii, ok := transliterations[r]; ok {
+				write(ascii)
+			} else {
+				pendingHyphen = true
+			}
+		}
+	}
+
+	return Truncate(b.String(), MaxLength)
+}
+
+// Truncate shortens a slug to at most n bytes, cutting at the last hyphen
+// that fits so words are not split.
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	if idx := strings.LastIndexByte(s, '-'); idx > 0 {
+		s = s[:idx]
+	}
+	return strings.TrimSuffix(s, "-")
+}
+
+// WithSuffix appends a numeric suffix to a slug to tell it apart from an
+// existing one, e.g. "rendang" and 2 give "rendang-2". The base is shortened
+// if needed so the result stays within MaxLength.
+func WithSuffix(base string, n int) string {
+	suffix := "-" + strconv.Itoa(n)
+	return Truncate(base, MaxLength-len(suffix)) + suffix
+}
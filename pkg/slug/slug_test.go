@@ -0,0 +1,75 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Nasi Goreng & Sambal", "nasi-goreng-sambal"},
+		{"Crème Brûlée", "creme-brulee"},
+		{"  Soto -- Ayam!  ", "soto-ayam"},
+		{"Mom's Apple Pie", "moms-apple-pie"},
+		{"Mom’s Apple Pie", "moms-apple-pie"},
+		{"Straße", "strasse"},
+		{"Smørrebrød", "smorrebrod"},
+		{"Œufs à la neige", "oeufs-a-la-neige"},
+		{"Łódź Pierogi", "lodz-pierogi"},
+		{"Top 10 Recipes of 2024", "top-10-recipes-of-2024"},
+		{"ÉCLAIR", "eclair"},
+		{"ramen_bowl", "ramen-bowl"},
+		{"寿司", ""},
+		{"寿司 Sushi", "sushi"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Make(tt.in); got != tt.want {
+			t.Errorf("Make(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMakeMaxLength(t *testing.T) {
+	got := Make(strings.Repeat("rendang ", 40))
+	if len(got) > MaxLength || strings.HasSuffix(got, "-") || !strings.HasSuffix(got, "-rendang") {
+		t.Errorf("Make cut to %q (%d bytes)", got, len(got))
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"nasi-goreng", 20, "nasi-goreng"},
+		{"nasi-goreng", 11, "nasi-goreng"},
+		{"nasi-goreng-sambal", 15, "nasi-goreng"},
+		{"nasi-goreng-sambal", 12, "nasi-goreng"},
+		{"nasi-goreng", 6, "nasi"},
+		{"rendang", 4, "rend"},
+	}
+
+	for _, tt := range tests {
+		if got := Truncate(tt.in, tt.n); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestWithSuffix(t *testing.T) {
+	if got := WithSuffix("rendang", 2); got != "rendang-2" {
+		t.Errorf("WithSuffix = %q, want rendang-2", got)
+	}
+
+	long := Make(strings.Repeat("rendang ", 40))
+	got := WithSuffix(long, 12)
+	if len(got) > MaxLength || !strings.HasSuffix(got, "-rendang-12") {
+		t.Errorf("WithSuffix of a long slug = %q (%d bytes)", got, len(got))
+	}
+}
@@ -0,0 +1,162 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors, the ASCII string
+// "12345678901234567890", base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238(t *testing.T) {
+	// The RFC lists 8-digit codes; 6-digit codes are their last six digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("code at T=%d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeSecret(t *testing.T) {
+	lower, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 1)
+	if err != nil || lower != "287082" {
+		t.Errorf("lowercase secret = %q, %v, want 287082", lower, err)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("invalid secret gave no error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// T=59 is the last second of step 1, so step 2 starts a second later.
+	now := time.Unix(59, 0)
+	code := func(step int64) string {
+		c, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		at       time.Time
+		skew     int
+		wantStep int64
+		ok       bool
+	}{
+		{"current step", "287082", now, 0, 1, true},
+		{"surrounding spaces", " 287082\n", now, 0, 1, true},
+		{"next step without skew", "287082", now.Add(time.Second), 0, 0, false},
+		{"next step within skew", "287082", now.Add(time.Second), 1, 1, true},
+		{"previous step within skew", code(0), now, 1, 0, true},
+		{"following step within skew", code(2), now, 1, 2, true},
+		{"two steps back with skew 1", code(1), now.Add(2 * Period), 1, 0, false},
+		{"two steps back with skew 2", code(1), now.Add(2 * Period), 2, 1, true},
+		{"two steps ahead with skew 1", code(3), now, 1, 0, false},
+		{"wrong code", "123456", now, 1, 0, false},
+		{"too short", "28708", now, 1, 0, false},
+		{"too long", "2870820", now, 1, 0, false},
+		{"empty", "", now, 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, tt.code, tt.at, tt.skew)
+			if ok != tt.ok || step != tt.wantStep {
+				t.Errorf("Validate = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.ok)
+			}
+		})
+	}
+
+	if _, ok := Validate("not base32!", "287082", now, 1); ok {
+		t.Error("invalid secret validated")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := encoding.DecodeString(secret)
+	if err != nil || len(raw) != secretSize {
+		t.Fatalf("secret %q decodes to %d bytes, %v", secret, len(raw), err)
+	}
+	if other, _ := GenerateSecret(); other == secret {
+		t.Error("two secrets are the same")
+	}
+}
+
+func TestURI(t *testing.T) {
+	tests := []struct {
+		name        string
+		issuer      string
+		account     string
+		wantLabel   string
+		wantRawPath string
+	}{
+		{"plain", "Culinary Blog", "alice", "Culinary Blog:alice", "/Culinary%20Blog:alice"},
+		{"email account", "Culinary Blog", "alice@example.com", "Culinary Blog:alice@example.com", "/Culinary%20Blog:alice@example.com"},
+		{"reserved characters", "Chef & Co", "a/b?c#d", "Chef & Co:a/b?c#d", "/Chef%20&%20Co:a%2Fb%3Fc%23d"},
+		{"colons", "Chef: Kitchen", "x:y", "Chef: Kitchen:x:y", "/Chef%3A%20Kitchen:x%3Ay"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := URI(tt.issuer, tt.account, rfcSecret)
+			u, err := url.Parse(raw)
+			if err != nil {
+				t.Fatalf("URI %q does not parse: %v", raw, err)
+			}
+
+			// Some authenticator apps show a '+' in the query literally.
+			if strings.Contains(raw, "+") {
+				t.Errorf("URI %q encodes spaces as '+'", raw)
+			}
+			if u.Scheme != "otpauth" || u.Host != "totp" {
+				t.Errorf("URI %q is not an otpauth://totp URI", raw)
+			}
+			if u.EscapedPath() != tt.wantRawPath {
+				t.Errorf("label = %q, want %q", u.EscapedPath(), tt.wantRawPath)
+			}
+			if u.Path != "/"+tt.wantLabel {
+				t.Errorf("decoded label = %q, want %q", u.Path, "/"+tt.wantLabel)
+			}
+
+			query := u.Query()
+			want := map[string]string{
+				"secret":    rfcSecret,
+				"issuer":    tt.issuer,
+				"algorithm": "SHA1",
+				"digits":    "6",
+				"period":    "30",
+			}
+			for key, value := range want {
+				if got := query.Get(key); got != value {
+					t.Errorf("%s = %q, want %q", key, got, value)
+				}
+			}
+		})
+	}
+}